@@ -5,33 +5,63 @@ import (
 	"encoding/hex"
 	"flag"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/charmbracelet/ssh"
 
+	"ag/internal/api"
 	"ag/internal/auth"
 	"ag/internal/bbs"
+	"ag/internal/chat"
+	"ag/internal/search"
 	"ag/internal/server"
+	"ag/internal/web"
+	"ag/internal/webhook"
 )
 
 func main() {
 	addr := flag.String("addr", ":2323", "listen address for SSH clients")
 	boardsFile := flag.String("boards", "data/boards.json", "path to boards list json")
 	postsDir := flag.String("posts", "data/posts", "directory to store posts per board")
+	usersDir := flag.String("users", "data/users", "directory to store per-user state")
+	chatDir := flag.String("chat", "data/chat", "directory to keep chat history (empty keeps it in memory only)")
 	authFile := flag.String("auth", "", "path to auth JSON (optional)")
+	cachePosts := flag.Int("cache-posts", 50000, "posts to keep in memory before unloading idle boards (0 keeps all)")
+	saveWindow := flag.Duration("save-window", 0, "coalesce board writes within this window into one save")
+	asyncSaves := flag.Bool("async-saves", false, "acknowledge writes before they are saved; pending saves are flushed on shutdown")
+	attachDir := flag.String("attachments", "data/attachments", "directory to store attached files (empty disables attachments)")
+	attachSize := flag.Int64("attach-max-size", bbs.DefaultAttachmentLimits.MaxSize, "largest attachment in bytes")
+	attachFiles := flag.Int("attach-max-files", bbs.DefaultAttachmentLimits.MaxFiles, "most attachments per post")
+	attachTypes := flag.String("attach-types", strings.Join(bbs.DefaultAttachmentLimits.Types, ","), "comma separated MIME types that may be attached; a trailing / allows a family")
+	publicHost := flag.String("public-host", "", "host name shown in scp download instructions (defaults to the address clients connected to)")
+	httpAddr := flag.String("http", "", "listen address for the read-only web mirror of public boards (empty disables it)")
+	baseURL := flag.String("base-url", "", "external URL of the web mirror, such as https://bbs.example.com, linked from feeds")
+	exportFeeds := flag.String("export-feeds", "", "write the Atom and RSS feeds of public boards under this directory and exit (needs -base-url)")
+	apiAddr := flag.String("api", "", "listen address for the JSON API, authenticated with tokens users issue from their profile (empty disables it)")
+	webhooksFile := flag.String("webhooks", "", "path to webhook config JSON (empty disables webhooks)")
+	webhookDir := flag.String("webhook-dir", "data/webhooks", "directory to keep the webhook retry queue and delivery log (empty keeps them in memory only)")
 	flag.Parse()
 
 	// Load Auth
 	var authenticator auth.Authenticator
+	roles := map[string]bbs.Role{}
+	var users []string
 	if *authFile != "" {
 		authCfg, err := auth.LoadConfig(*authFile)
 		if err != nil {
 			log.Fatalf("load auth file: %v", err)
 		}
 		authenticator = auth.NewAuthenticator(authCfg)
+		for user, role := range authCfg.Roles() {
+			roles[user] = bbs.Role(role)
+		}
+		users = authCfg.Usernames()
 	}
 
 	// Load BBS Data
@@ -56,24 +86,93 @@ func main() {
 	if err != nil {
 		log.Printf("failed to load boards list, using defaults: %v", err)
 	}
-	board := bbs.NewWithBoards(nil, boardNames, store, postStore)
+	ack := bbs.AckSync
+	if *asyncSaves {
+		ack = bbs.AckAsync
+	}
+	opts := []bbs.Option{
+		bbs.WithRoles(roles),
+		bbs.WithUsers(users),
+		bbs.WithReadStore(bbs.ReadStateFile{Dir: filepath.Join(*usersDir, "read"), EncryptionKey: encryptionKey}),
+		bbs.WithBookmarkStore(bbs.BookmarkFile{Dir: filepath.Join(*usersDir, "bookmarks"), EncryptionKey: encryptionKey}),
+		bbs.WithNotificationStore(bbs.NotificationFile{Dir: filepath.Join(*usersDir, "notifications"), EncryptionKey: encryptionKey}),
+		bbs.WithMessageStore(bbs.MessageFile{Dir: filepath.Join(*usersDir, "messages"), EncryptionKey: encryptionKey}),
+		bbs.WithProfileStore(bbs.ProfileFile{Dir: filepath.Join(*usersDir, "profiles"), EncryptionKey: encryptionKey}),
+		bbs.WithTokenStore(bbs.TokenFile{Path: filepath.Join(*usersDir, "tokens.json"), EncryptionKey: encryptionKey}),
+		bbs.WithBoardCache(*cachePosts),
+		bbs.WithGroupCommit(*saveWindow, ack),
+	}
+	if *attachDir != "" {
+		opts = append(opts, bbs.WithAttachments(bbs.BlobFile{Dir: *attachDir, EncryptionKey: encryptionKey}, bbs.AttachmentLimits{
+			MaxSize:  *attachSize,
+			MaxFiles: *attachFiles,
+			Types:    strings.Split(*attachTypes, ","),
+		}))
+	}
+	board := bbs.NewWithBoards(nil, boardNames, store, postStore, opts...)
 
-	// Create SSH Server
-	s, err := server.New(*addr, ".ssh/term_info_ed25519", board)
-	if err != nil {
-		log.Fatalln(err)
+	var webOpts []web.Option
+	if *baseURL != "" {
+		webOpts = append(webOpts, web.WithBaseURL(*baseURL))
+	}
+	if *exportFeeds != "" {
+		err := web.New(board, webOpts...).ExportFeeds(*exportFeeds)
+		// Reading boards may have written their first index.
+		if ferr := board.Flush(); ferr != nil {
+			log.Println("flush posts:", ferr)
+		}
+		if err != nil {
+			log.Fatalf("export feeds: %v", err)
+		}
+		return
+	}
+
+	var chatOpts []chat.Option
+	if *chatDir != "" {
+		chatOpts = append(chatOpts, chat.WithStore(chat.FileStore{Dir: *chatDir, EncryptionKey: encryptionKey}))
+	}
+	hub := chat.NewHub(chatOpts...)
+
+	// The search index fills in the background so startup does not wait
+	// for every board to be read.
+	index := search.NewIndex()
+	go index.Watch(board)
+
+	// Webhooks are queued as events happen and delivered in the
+	// background; deliveries left over from the last run go out first.
+	var hooks *webhook.Dispatcher
+	if *webhooksFile != "" {
+		cfg, err := webhook.LoadConfig(*webhooksFile)
+		if err != nil {
+			log.Fatalf("load webhooks: %v", err)
+		}
+		hookOpts := []webhook.Option{webhook.WithEncryptionKey(encryptionKey)}
+		if *webhookDir != "" {
+			hookOpts = append(hookOpts, webhook.WithDir(*webhookDir))
+		}
+		hooks, err = webhook.New(cfg.Hooks, hookOpts...)
+		if err != nil {
+			log.Fatalf("start webhooks: %v", err)
+		}
+		hooks.Watch(board)
+		hooks.Start()
 	}
 
-	// Handle Auth (Simple Password check if enabled)
+	// Create SSH Server
+	serverOpts := []server.Option{
+		server.WithChat(hub),
+		server.WithSearch(index),
+		server.WithPublicHost(*publicHost),
+		server.WithWebhooks(hooks),
+	}
+	// Without an auth file anyone may connect under any name, so roles are
+	// only loaded together with password checks.
 	if authenticator.Enabled() {
-		// Note: Wish's password auth is a bit different, it checks public keys first usually.
-		// For simplicity in this migration, we might rely on the UI to handle login
-		// OR we can implement a custom auth handler.
-		// For now, let's keep it open or implement a basic check if we can.
-		// Since the original code did interactive auth INSIDE the session,
-		// we can replicate that in the Bubbletea model if we wanted to,
-		// but for now let's just allow connection and assume "guest" or the SSH user.
-		// A proper Wish auth middleware could be added later.
+		serverOpts = append(serverOpts, server.WithPasswordAuth(authenticator.Check))
+	}
+	s, err := server.New(*addr, ".ssh/term_info_ed25519", board, serverOpts...)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
 	done := make(chan os.Signal, 1)
@@ -85,12 +184,47 @@ func main() {
 		}
 	}()
 
+	var httpServers []*http.Server
+	serveHTTP := func(name, addr string, h http.Handler) {
+		srv := &http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+		httpServers = append(httpServers, srv)
+		log.Printf("Starting %s on %s", name, addr)
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalln(err)
+			}
+		}()
+	}
+	if *httpAddr != "" {
+		serveHTTP("web mirror", *httpAddr, web.New(board, webOpts...))
+	}
+	if *apiAddr != "" {
+		serveHTTP("JSON API", *apiAddr, api.New(board, api.WithSearch(index)))
+	}
+
 	<-done
 	log.Println("Stopping SSH server")
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	if err := s.Shutdown(ctx); err != nil {
-		log.Fatalln(err)
+	for _, srv := range httpServers {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("stop HTTP server on %s: %v", srv.Addr, err)
+		}
+	}
+	shutdownErr := s.Shutdown(ctx)
+	// Sessions are gone, so nothing new is queued; save what is pending
+	// before exiting, even if shutdown timed out.
+	if err := board.Flush(); err != nil {
+		log.Println("flush posts:", err)
+	}
+	hub.Flush()
+	if hooks != nil {
+		if err := hooks.Close(); err != nil {
+			log.Println("save webhook queue:", err)
+		}
+	}
+	if shutdownErr != nil {
+		log.Fatalln(shutdownErr)
 	}
 }
 
@@ -0,0 +1,352 @@
+// Package api serves a versioned JSON API over the BBS for bots and other
+// integrations. Requests carry an API token issued from the TUI in an
+// "Authorization: Bearer" header and act as the token's user, with the same
+// permission checks as an SSH session.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ag/internal/bbs"
+	"ag/internal/search"
+)
+
+const (
+	// maxBody caps a request body, like a post body read over SSH.
+	maxBody = 1 << 20
+	// defaultSearchLimit and maxSearchLimit bound search results.
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// errBadRequest signals a request the API cannot make sense of.
+var errBadRequest = errors.New("bad request")
+
+// API is an http.Handler serving /api/v1/.
+type API struct {
+	board *bbs.BBS
+	index *search.Index
+	mux   *http.ServeMux
+}
+
+// Option configures optional API behaviour.
+type Option func(*API)
+
+// WithSearch answers /api/v1/search from index. Without it search is not
+// served.
+func WithSearch(index *search.Index) Option {
+	return func(a *API) {
+		a.index = index
+	}
+}
+
+// handler serves an authenticated request for user. A returned error is
+// written as the response; see writeError.
+type handler func(w http.ResponseWriter, r *http.Request, user string) error
+
+// New returns the API of board.
+func New(board *bbs.BBS, opts ...Option) *API {
+	a := &API{board: board, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.handle("GET /api/v1/me", a.me)
+	a.handle("GET /api/v1/boards", a.boards)
+	a.handle("GET /api/v1/boards/{board}/posts", a.posts)
+	a.handle("POST /api/v1/boards/{board}/posts", a.addPost)
+	a.handle("GET /api/v1/boards/{board}/posts/{id}", a.post)
+	a.handle("PATCH /api/v1/boards/{board}/posts/{id}", a.editPost)
+	a.handle("DELETE /api/v1/boards/{board}/posts/{id}", a.deletePost)
+	a.handle("POST /api/v1/boards/{board}/posts/{id}/comments", a.addComment)
+	if a.index != nil {
+		a.handle("GET /api/v1/search", a.search)
+	}
+	a.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, Error{Error: "no such endpoint"})
+	})
+	return a
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mux.ServeHTTP(w, r)
+}
+
+// handle registers h behind token authentication.
+func (a *API) handle(pattern string, h handler) {
+	a.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		user, ok := a.authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="bbs"`)
+			writeJSON(w, http.StatusUnauthorized, Error{Error: "missing or invalid API token"})
+			return
+		}
+		if err := h(w, r, user); err != nil {
+			writeError(w, r, err)
+		}
+	})
+}
+
+func (a *API) authenticate(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", false
+	}
+	return a.board.TokenUser(strings.TrimSpace(token))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError answers with the status that fits err.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, bbs.ErrBoardNotFound), errors.Is(err, bbs.ErrPostNotFound), errors.Is(err, bbs.ErrCommentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, bbs.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, bbs.ErrBoardReadOnly), errors.Is(err, bbs.ErrBoardArchived), errors.Is(err, bbs.ErrPostLocked):
+		status = http.StatusConflict
+	case errors.Is(err, errBadRequest), errors.Is(err, bbs.ErrEmptyTitle), errors.Is(err, bbs.ErrBadCursor), errors.Is(err, search.ErrBadDate):
+		status = http.StatusBadRequest
+	}
+	msg := err.Error()
+	if status == http.StatusInternalServerError {
+		log.Printf("api: %s %s: %v", r.Method, r.URL.Path, err)
+		msg = "internal error"
+	}
+	writeJSON(w, status, Error{Error: msg})
+}
+
+// decode reads a JSON request body into v.
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+	return nil
+}
+
+// intParam reads an integer query parameter, or def if it is absent.
+func intParam(r *http.Request, name string, def int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: %s must be a non-negative number", errBadRequest, name)
+	}
+	return n, nil
+}
+
+// postID reads the {id} path segment.
+func postID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		// Not a post ID, so no such post.
+		return 0, bbs.ErrPostNotFound
+	}
+	return id, nil
+}
+
+func (a *API) me(w http.ResponseWriter, r *http.Request, user string) error {
+	writeJSON(w, http.StatusOK, map[string]string{"user": user})
+	return nil
+}
+
+func (a *API) boards(w http.ResponseWriter, r *http.Request, user string) error {
+	out := []Board{}
+	for _, s := range a.board.ListBoardsFor(user) {
+		out = append(out, boardOf(s))
+	}
+	writeJSON(w, http.StatusOK, out)
+	return nil
+}
+
+func parseSort(s string) (bbs.PostSort, error) {
+	if s == "" {
+		return bbs.SortOldest, nil
+	}
+	for _, sort := range bbs.PostSorts {
+		if sort.String() == s {
+			return sort, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown sort %q", errBadRequest, s)
+}
+
+func (a *API) posts(w http.ResponseWriter, r *http.Request, user string) error {
+	q := r.URL.Query()
+	sort, err := parseSort(q.Get("sort"))
+	if err != nil {
+		return err
+	}
+	limit, err := intParam(r, "limit", bbs.DefaultPageSize)
+	if err != nil {
+		return err
+	}
+	page, err := a.board.ListPostPage(r.PathValue("board"), bbs.PageOptions{
+		ListPostsOptions: bbs.ListPostsOptions{Sort: sort, Author: q.Get("author")},
+		After:            q.Get("after"),
+		Limit:            limit,
+	})
+	if err != nil {
+		return err
+	}
+	out := PostList{Posts: []PostSummary{}, Next: page.Next, Total: page.Total}
+	for _, h := range page.Posts {
+		out.Posts = append(out.Posts, postSummaryOf(h))
+	}
+	writeJSON(w, http.StatusOK, out)
+	return nil
+}
+
+func (a *API) post(w http.ResponseWriter, r *http.Request, user string) error {
+	id, err := postID(r)
+	if err != nil {
+		return err
+	}
+	board := r.PathValue("board")
+	p, err := a.board.GetPost(board, id)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, postOf(board, p))
+	return nil
+}
+
+// boardExists reports whether board exists. Posting would otherwise create
+// a missing board, which neither the TUI nor SSH commands do.
+func (a *API) boardExists(board string) bool {
+	for _, b := range a.board.ListBoards() {
+		if b.Name == board {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *API) addPost(w http.ResponseWriter, r *http.Request, user string) error {
+	board := r.PathValue("board")
+	if !a.boardExists(board) {
+		return bbs.ErrBoardNotFound
+	}
+	var in NewPost
+	if err := decode(w, r, &in); err != nil {
+		return err
+	}
+	p, err := a.board.AddPost(board, user, in.Title, in.Content, in.Tags...)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/boards/%s/posts/%d", url.PathEscape(board), p.ID))
+	writeJSON(w, http.StatusCreated, postOf(board, p))
+	return nil
+}
+
+func (a *API) editPost(w http.ResponseWriter, r *http.Request, user string) error {
+	id, err := postID(r)
+	if err != nil {
+		return err
+	}
+	var in PostEdit
+	if err := decode(w, r, &in); err != nil {
+		return err
+	}
+	board := r.PathValue("board")
+	p, err := a.board.PatchPost(board, id, user, bbs.PostPatch{Title: in.Title, Content: in.Content, Tags: in.Tags})
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, postOf(board, p))
+	return nil
+}
+
+func (a *API) deletePost(w http.ResponseWriter, r *http.Request, user string) error {
+	id, err := postID(r)
+	if err != nil {
+		return err
+	}
+	if err := a.board.DeletePost(r.PathValue("board"), id, user); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (a *API) addComment(w http.ResponseWriter, r *http.Request, user string) error {
+	id, err := postID(r)
+	if err != nil {
+		return err
+	}
+	var in NewComment
+	if err := decode(w, r, &in); err != nil {
+		return err
+	}
+	if strings.TrimSpace(in.Content) == "" {
+		return fmt.Errorf("%w: content is required", errBadRequest)
+	}
+	board := r.PathValue("board")
+	if in.ParentID != 0 {
+		p, err := a.board.GetPost(board, id)
+		if err != nil {
+			return err
+		}
+		if !hasComment(p, in.ParentID) {
+			return bbs.ErrCommentNotFound
+		}
+	}
+	c, err := a.board.AddComment(board, id, user, in.Content, in.ParentID)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusCreated, commentOf(*c))
+	return nil
+}
+
+func hasComment(p bbs.Post, id int) bool {
+	for _, c := range p.Comments {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *API) search(w http.ResponseWriter, r *http.Request, user string) error {
+	q, err := search.ParseQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		return err
+	}
+	if q.Empty() {
+		return fmt.Errorf("%w: q is required", errBadRequest)
+	}
+	limit, err := intParam(r, "limit", defaultSearchLimit)
+	if err != nil {
+		return err
+	}
+	if limit == 0 || limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	out := []SearchResult{}
+	for _, res := range a.index.Search(q, limit) {
+		out = append(out, searchResultOf(res))
+	}
+	writeJSON(w, http.StatusOK, out)
+	return nil
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ag/internal/bbs"
+	"ag/internal/search"
+)
+
+type client struct {
+	t     *testing.T
+	url   string
+	token string
+}
+
+// do sends a request with an optional JSON body and decodes the response
+// into out, returning the status.
+func (c client) do(method, path, body string, out any) int {
+	c.t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, _ := http.NewRequest(method, c.url+path, r)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			c.t.Fatalf("%s %s: decode: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func newAPI(t *testing.T) (*bbs.BBS, string, func(user string) client) {
+	t.Helper()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := bbs.NewWithBoards(func() time.Time { return now }, []string{"general", "news"}, nil, nil,
+		bbs.WithRoles(map[string]bbs.Role{"mod": bbs.RoleModerator}))
+	index := search.NewIndex()
+	go index.Watch(b)
+	srv := httptest.NewServer(New(b, WithSearch(index)))
+	t.Cleanup(srv.Close)
+	as := func(user string) client {
+		secret, _, err := b.IssueToken(user, "test")
+		if err != nil {
+			t.Fatalf("IssueToken: %v", err)
+		}
+		return client{t: t, url: srv.URL, token: secret}
+	}
+	return b, srv.URL, as
+}
+
+func TestAPIRequiresToken(t *testing.T) {
+	_, url, _ := newAPI(t)
+	for _, token := range []string{"", "bbs_nope"} {
+		var e Error
+		if status := (client{t: t, url: url, token: token}).do("GET", "/api/v1/boards", "", &e); status != http.StatusUnauthorized || e.Error == "" {
+			t.Fatalf("token %q: status %d, %+v", token, status, e)
+		}
+	}
+}
+
+func TestAPIPostsAndComments(t *testing.T) {
+	_, _, as := newAPI(t)
+	alice, bob := as("alice"), as("bob")
+
+	var created Post
+	if status := alice.do("POST", "/api/v1/boards/general/posts", `{"title":"build 42","content":"green","tags":["ci"]}`, &created); status != http.StatusCreated {
+		t.Fatalf("create post: %d", status)
+	}
+	if created.ID != 1 || created.Author != "alice" || created.Board != "general" || len(created.Tags) != 1 {
+		t.Fatalf("unexpected post: %+v", created)
+	}
+
+	var c Comment
+	if status := bob.do("POST", "/api/v1/boards/general/posts/1/comments", `{"content":"nice"}`, &c); status != http.StatusCreated || c.Author != "bob" {
+		t.Fatalf("comment: %d %+v", status, c)
+	}
+	var list PostList
+	if status := bob.do("GET", "/api/v1/boards/general/posts?sort=newest", "", &list); status != http.StatusOK || list.Total != 1 || list.Posts[0].Comments != 1 {
+		t.Fatalf("list: %d %+v", status, list)
+	}
+	var got Post
+	if status := bob.do("GET", "/api/v1/boards/general/posts/1", "", &got); status != http.StatusOK || len(got.Comments) != 1 || got.Content != "green" {
+		t.Fatalf("get: %d %+v", status, got)
+	}
+	var boards []Board
+	if status := alice.do("GET", "/api/v1/boards", "", &boards); status != http.StatusOK || len(boards) != 2 || boards[0].Posts != 1 {
+		t.Fatalf("boards: %d %+v", status, boards)
+	}
+
+	var edited Post
+	if status := alice.do("PATCH", "/api/v1/boards/general/posts/1", `{"content":"green again"}`, &edited); status != http.StatusOK ||
+		edited.Title != "build 42" || edited.Content != "green again" || edited.EditedAt.IsZero() {
+		t.Fatalf("edit: %d %+v", status, edited)
+	}
+	var e Error
+	if status := bob.do("PATCH", "/api/v1/boards/general/posts/1", `{"title":"mine"}`, &e); status != http.StatusForbidden {
+		t.Fatalf("expected bob's edit to be forbidden, got %d %+v", status, e)
+	}
+	if status := bob.do("DELETE", "/api/v1/boards/general/posts/1", "", &e); status != http.StatusForbidden {
+		t.Fatalf("expected bob's delete to be forbidden, got %d %+v", status, e)
+	}
+	if status := alice.do("DELETE", "/api/v1/boards/general/posts/1", "", nil); status != http.StatusNoContent {
+		t.Fatalf("delete: %d", status)
+	}
+}
+
+func TestAPIServesPrivateBoards(t *testing.T) {
+	b, _, as := newAPI(t)
+	alice := as("alice")
+	if _, err := b.AddPost("news", "mod", "staff only", "body"); err != nil {
+		t.Fatalf("AddPost: %v", err)
+	}
+	if err := b.SetBoardPrivate("news", true, "mod"); err != nil {
+		t.Fatalf("SetBoardPrivate: %v", err)
+	}
+
+	// Tokens act as the SSH user who issued them, who still sees the board.
+	var boards []Board
+	if status := alice.do("GET", "/api/v1/boards", "", &boards); status != http.StatusOK || len(boards) != 2 || boards[1].Name != "news" || !boards[1].Private {
+		t.Fatalf("boards: %d %+v", status, boards)
+	}
+	var list PostList
+	if status := alice.do("GET", "/api/v1/boards/news/posts", "", &list); status != http.StatusOK || list.Total != 1 {
+		t.Fatalf("list: %d %+v", status, list)
+	}
+	var got Post
+	if status := alice.do("GET", "/api/v1/boards/news/posts/1", "", &got); status != http.StatusOK || got.Title != "staff only" {
+		t.Fatalf("get: %d %+v", status, got)
+	}
+}
+
+func TestAPIErrorStatuses(t *testing.T) {
+	b, _, as := newAPI(t)
+	alice := as("alice")
+	_, _ = b.AddPost("general", "alice", "topic", "")
+	_ = b.SetBoardState("news", bbs.BoardReadOnly, "mod")
+
+	cases := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"GET", "/api/v1/boards/nowhere/posts", "", http.StatusNotFound},
+		{"POST", "/api/v1/boards/nowhere/posts", `{"title":"x"}`, http.StatusNotFound},
+		{"GET", "/api/v1/boards/general/posts/99", "", http.StatusNotFound},
+		{"GET", "/api/v1/boards/general/posts/abc", "", http.StatusNotFound},
+		{"POST", "/api/v1/boards/general/posts/99/comments", `{"content":"x"}`, http.StatusNotFound},
+		{"POST", "/api/v1/boards/general/posts/1/comments", `{"content":"x","parent_id":7}`, http.StatusNotFound},
+		{"POST", "/api/v1/boards/general/posts", `{"title":""}`, http.StatusBadRequest},
+		{"POST", "/api/v1/boards/general/posts", `{"title":"x","bogus":1}`, http.StatusBadRequest},
+		{"POST", "/api/v1/boards/general/posts/1/comments", `{"content":" "}`, http.StatusBadRequest},
+		{"GET", "/api/v1/boards/general/posts?sort=loudest", "", http.StatusBadRequest},
+		{"GET", "/api/v1/boards/general/posts?after=garbage", "", http.StatusBadRequest},
+		{"POST", "/api/v1/boards/news/posts", `{"title":"x"}`, http.StatusConflict},
+		{"GET", "/api/v2/boards", "", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		var e Error
+		if status := alice.do(c.method, c.path, c.body, &e); status != c.want || e.Error == "" {
+			t.Errorf("%s %s: got %d %+v, want %d", c.method, c.path, status, e, c.want)
+		}
+	}
+}
+
+func TestAPISearch(t *testing.T) {
+	b, _, as := newAPI(t)
+	alice := as("alice")
+	_, _ = b.AddPost("general", "bob", "release notes", "what changed")
+
+	var results []SearchResult
+	deadline := time.Now().Add(time.Second)
+	for len(results) == 0 && time.Now().Before(deadline) {
+		if status := alice.do("GET", "/api/v1/search?q=release", "", &results); status != http.StatusOK {
+			t.Fatalf("search: %d", status)
+		}
+	}
+	if len(results) != 1 || results[0].Board != "general" || results[0].PostID != 1 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	var e Error
+	if status := alice.do("GET", "/api/v1/search?q=", "", &e); status != http.StatusBadRequest {
+		t.Fatalf("expected an empty query to be refused, got %d", status)
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"time"
+
+	"ag/internal/bbs"
+	"ag/internal/search"
+)
+
+// The types below are the v1 wire format. They are kept apart from the bbs
+// types so the storage can change without breaking integrations.
+
+// Board is a board as listed for the token's user.
+type Board struct {
+	Name       string `json:"name"`
+	Posts      int    `json:"posts"`
+	Unread     int    `json:"unread"`
+	State      string `json:"state"`
+	Private    bool   `json:"private,omitempty"`
+	Subscribed bool   `json:"subscribed,omitempty"`
+}
+
+// PostSummary is a post in a list: no body, no comments.
+type PostSummary struct {
+	ID           int       `json:"id"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActivity time.Time `json:"last_activity"`
+	Comments     int       `json:"comments"`
+	Score        int       `json:"score"`
+	Locked       bool      `json:"locked,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+}
+
+// PostList is one page of posts. Next is passed back as ?after= for the
+// following page and is empty on the last one.
+type PostList struct {
+	Posts []PostSummary `json:"posts"`
+	Next  string        `json:"next,omitempty"`
+	Total int           `json:"total"`
+}
+
+// Post is a post with its comments.
+type Post struct {
+	ID          int          `json:"id"`
+	Board       string       `json:"board"`
+	Title       string       `json:"title"`
+	Content     string       `json:"content"`
+	Author      string       `json:"author"`
+	CreatedAt   time.Time    `json:"created_at"`
+	EditedAt    time.Time    `json:"edited_at,omitzero"`
+	Score       int          `json:"score"`
+	Locked      bool         `json:"locked,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Comments    []Comment    `json:"comments"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Comment is a comment on a post. ParentID is 0 for top-level comments.
+type Comment struct {
+	ID        int       `json:"id"`
+	ParentID  int       `json:"parent_id,omitempty"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	Score     int       `json:"score"`
+}
+
+// Attachment describes a file attached to a post; files themselves are
+// fetched over SCP or SFTP.
+type Attachment struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Type string `json:"type"`
+}
+
+// SearchResult is a post matching a search, best first.
+type SearchResult struct {
+	Board     string    `json:"board"`
+	PostID    int       `json:"post_id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	Snippet   string    `json:"snippet"`
+}
+
+// NewPost is the body of a request to write a post.
+type NewPost struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// PostEdit is the body of a request to edit a post. Fields left out keep
+// their current value.
+type PostEdit struct {
+	Title   *string   `json:"title,omitempty"`
+	Content *string   `json:"content,omitempty"`
+	Tags    *[]string `json:"tags,omitempty"`
+}
+
+// NewComment is the body of a request to comment on a post.
+type NewComment struct {
+	Content  string `json:"content"`
+	ParentID int    `json:"parent_id,omitempty"`
+}
+
+// Error is the body of every failed request.
+type Error struct {
+	Error string `json:"error"`
+}
+
+func boardOf(s bbs.BoardSummary) Board {
+	return Board{
+		Name:       s.Name,
+		Posts:      s.PostCount,
+		Unread:     s.Unread,
+		State:      s.State.String(),
+		Private:    s.Private,
+		Subscribed: s.Subscribed,
+	}
+}
+
+func postSummaryOf(h bbs.PostHeader) PostSummary {
+	return PostSummary{
+		ID:           h.ID,
+		Title:        h.Title,
+		Author:       h.Author,
+		CreatedAt:    h.CreatedAt,
+		LastActivity: h.LastActivity,
+		Comments:     h.Comments,
+		Score:        h.Score,
+		Locked:       h.Locked,
+		Tags:         h.Tags,
+	}
+}
+
+func postOf(board string, p bbs.Post) Post {
+	out := Post{
+		ID:        p.ID,
+		Board:     board,
+		Title:     p.Title,
+		Content:   p.Content,
+		Author:    p.Author,
+		CreatedAt: p.CreatedAt,
+		EditedAt:  p.EditedAt,
+		Score:     p.Score(),
+		Locked:    p.Locked,
+		Tags:      p.Tags,
+		Comments:  []Comment{},
+	}
+	for _, c := range p.Comments {
+		out.Comments = append(out.Comments, commentOf(c))
+	}
+	for _, a := range p.Attachments {
+		out.Attachments = append(out.Attachments, Attachment{Name: a.Name, Size: a.Size, Type: a.Type})
+	}
+	return out
+}
+
+func commentOf(c bbs.Comment) Comment {
+	return Comment{
+		ID:        c.ID,
+		ParentID:  c.ParentID,
+		Author:    c.Author,
+		Content:   c.Content,
+		CreatedAt: c.CreatedAt,
+		Score:     c.Score(),
+	}
+}
+
+func searchResultOf(r search.Result) SearchResult {
+	return SearchResult{
+		Board:     r.Board,
+		PostID:    r.PostID,
+		Title:     r.Title,
+		Author:    r.Author,
+		CreatedAt: r.CreatedAt,
+		Snippet:   r.Snippet,
+	}
+}
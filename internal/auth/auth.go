@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bufio"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,9 +12,11 @@ import (
 )
 
 // User is a simple username/password credential.
+// Role is optional ("moderator" or "admin").
 type User struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Role     string `json:"role,omitempty"`
 }
 
 // Config describes the auth file format.
@@ -58,11 +61,43 @@ func NewAuthenticator(cfg Config) Authenticator {
 	return Authenticator{users: users}
 }
 
+// Roles returns the configured role of every user that has one.
+func (c Config) Roles() map[string]string {
+	roles := make(map[string]string)
+	for _, u := range c.Users {
+		name := strings.TrimSpace(u.Username)
+		role := strings.TrimSpace(u.Role)
+		if name == "" || role == "" {
+			continue
+		}
+		roles[name] = role
+	}
+	return roles
+}
+
+// Usernames returns the configured usernames in file order.
+func (c Config) Usernames() []string {
+	names := make([]string, 0, len(c.Users))
+	for _, u := range c.Users {
+		if name := strings.TrimSpace(u.Username); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // Enabled returns true when any users are configured.
 func (a Authenticator) Enabled() bool {
 	return len(a.users) > 0
 }
 
+// Check reports whether password is the password of username. Unknown
+// users never pass.
+func (a Authenticator) Check(username, password string) bool {
+	want, ok := a.users[strings.TrimSpace(username)]
+	return ok && subtle.ConstantTimeCompare([]byte(want), []byte(password)) == 1
+}
+
 // Authenticate prompts for username/password up to maxAttempts.
 // io.Writer is used for prompts; bufio.Reader is reused for input.
 func (a Authenticator) Authenticate(r *bufio.Reader, w io.Writer, initialUser string, maxAttempts int) (string, bool) {
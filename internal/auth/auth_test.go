@@ -29,6 +29,18 @@ func TestAuthenticateSuccess(t *testing.T) {
 	}
 }
 
+func TestCheck(t *testing.T) {
+	a := NewAuthenticator(Config{Users: []User{{Username: "alice", Password: "pw"}}})
+	if !a.Check("alice", "pw") {
+		t.Fatal("expected the right password to pass")
+	}
+	for _, c := range [][2]string{{"alice", "nope"}, {"alice", ""}, {"mallory", "pw"}, {"", ""}} {
+		if a.Check(c[0], c[1]) {
+			t.Errorf("expected %q/%q to fail", c[0], c[1])
+		}
+	}
+}
+
 func TestAuthenticateFail(t *testing.T) {
 	a := NewAuthenticator(Config{Users: []User{{Username: "alice", Password: "pw"}}})
 	in := bytes.NewBufferString("alice\nwrong\nagain\nbad\n")
@@ -76,3 +88,23 @@ func TestLoadConfigParses(t *testing.T) {
 		t.Fatalf("unexpected cfg: %+v", cfg)
 	}
 }
+
+func TestConfigRoles(t *testing.T) {
+	cfg := Config{Users: []User{
+		{Username: "alice", Password: "pw", Role: "admin"},
+		{Username: "bob", Password: "pw"},
+		{Username: " carol ", Password: "pw", Role: "moderator"},
+	}}
+	roles := cfg.Roles()
+	if len(roles) != 2 || roles["alice"] != "admin" || roles["carol"] != "moderator" {
+		t.Fatalf("unexpected roles: %v", roles)
+	}
+}
+
+func TestConfigUsernames(t *testing.T) {
+	cfg := Config{Users: []User{{Username: "alice"}, {Username: "  "}, {Username: " bob "}}}
+	names := cfg.Usernames()
+	if len(names) != 2 || names[0] != "alice" || names[1] != "bob" {
+		t.Fatalf("unexpected usernames: %v", names)
+	}
+}
@@ -0,0 +1,271 @@
+package bbs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const maxAttachmentName = 128
+
+var (
+	// ErrAttachmentsDisabled signals an upload to a BBS without a blob store.
+	ErrAttachmentsDisabled = errors.New("attachments are disabled")
+	// ErrAttachmentTooLarge signals a file over the size limit.
+	ErrAttachmentTooLarge = errors.New("attachment is too large")
+	// ErrAttachmentType signals a file type that is not allowed.
+	ErrAttachmentType = errors.New("attachment type is not allowed")
+	// ErrTooManyAttachments signals a post that already has the most files allowed.
+	ErrTooManyAttachments = errors.New("post has too many attachments")
+	// ErrAttachmentName signals an empty or unusable file name.
+	ErrAttachmentName = errors.New("invalid attachment name")
+	// ErrAttachmentNotFound signals an unknown attachment.
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+// Attachment describes a file attached to a post. The content is kept in
+// the blob store under Hash, so identical files are stored once.
+type Attachment struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	Type      string    `json:"type"` // MIME type detected from the content
+	Hash      string    `json:"hash"` // hex SHA-256 of the content
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BlobStore keeps file contents by their hash.
+type BlobStore interface {
+	// Put stores data and returns its hex SHA-256. Storing the same data
+	// twice is a no-op.
+	Put(data []byte) (hash string, err error)
+	Get(hash string) ([]byte, error)
+}
+
+// BlobFile stores blobs as files in Dir named by their hash, fanned out over
+// subdirectories by the first two hex digits.
+type BlobFile struct {
+	Dir           string
+	EncryptionKey []byte // 32 bytes for AES-256
+}
+
+func (f BlobFile) path(hash string) string {
+	return filepath.Join(f.Dir, hash[:2], hash)
+}
+
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+func (f BlobFile) Put(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := f.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := writeSealed(path, f.EncryptionKey, data); err != nil {
+		return "", fmt.Errorf("write blob: %w", err)
+	}
+	return hash, nil
+}
+
+func (f BlobFile) Get(hash string) ([]byte, error) {
+	if !validHash(hash) {
+		return nil, ErrAttachmentNotFound
+	}
+	data, err := os.ReadFile(f.path(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read blob: %w", err)
+	}
+	return maybeDecrypt(f.EncryptionKey, data), nil
+}
+
+// AttachmentLimits caps what may be attached to a post.
+type AttachmentLimits struct {
+	MaxSize  int64 // bytes per file
+	MaxFiles int   // files per post
+	// Types lists the allowed MIME types. An entry ending in "/" allows the
+	// whole family, e.g. "image/".
+	Types []string
+}
+
+// DefaultAttachmentLimits allow ten files of up to 5 MiB per post: images,
+// plain text, PDFs and zip archives.
+var DefaultAttachmentLimits = AttachmentLimits{
+	MaxSize:  5 << 20,
+	MaxFiles: 10,
+	Types:    []string{"image/", "text/plain", "application/pdf", "application/zip"},
+}
+
+// Allows reports whether files of the MIME type typ may be attached.
+func (l AttachmentLimits) Allows(typ string) bool {
+	if media, _, err := mime.ParseMediaType(typ); err == nil {
+		typ = media
+	}
+	for _, t := range l.Types {
+		if t == typ || (strings.HasSuffix(t, "/") && strings.HasPrefix(typ, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithAttachments enables file attachments stored in store. Zero fields of
+// limits fall back to DefaultAttachmentLimits.
+func WithAttachments(store BlobStore, limits AttachmentLimits) Option {
+	return func(b *BBS) {
+		if limits.MaxSize <= 0 {
+			limits.MaxSize = DefaultAttachmentLimits.MaxSize
+		}
+		if limits.MaxFiles <= 0 {
+			limits.MaxFiles = DefaultAttachmentLimits.MaxFiles
+		}
+		if len(limits.Types) == 0 {
+			limits.Types = DefaultAttachmentLimits.Types
+		}
+		b.blobs = store
+		b.attachLimits = limits
+	}
+}
+
+// AttachmentLimits returns the limits uploads are checked against, and
+// false when attachments are disabled.
+func (b *BBS) AttachmentLimits() (AttachmentLimits, bool) {
+	return b.attachLimits, b.blobs != nil
+}
+
+// cleanAttachmentName keeps the base name of a client supplied path and
+// rejects names that would be awkward to download again.
+func cleanAttachmentName(name string) (string, error) {
+	name = strings.TrimSpace(filepath.Base(filepath.FromSlash(name)))
+	if name == "" || name == "." || name == ".." || name == string(filepath.Separator) || len(name) > maxAttachmentName {
+		return "", ErrAttachmentName
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || r == '/' || r == '\\' {
+			return "", ErrAttachmentName
+		}
+	}
+	return name, nil
+}
+
+// Attach reads a file from r and attaches it to a post owned by user. A file
+// with the same name replaces the one already attached.
+func (b *BBS) Attach(boardName string, postID int, user, name string, r io.Reader) (_ Attachment, err error) {
+	if b.blobs == nil {
+		return Attachment{}, ErrAttachmentsDisabled
+	}
+	name, err = cleanAttachmentName(name)
+	if err != nil {
+		return Attachment{}, err
+	}
+	board, ok := b.board(boardName)
+	if !ok {
+		return Attachment{}, ErrBoardNotFound
+	}
+	// Check ownership before reading what may be a large upload.
+	post, err := b.GetPost(boardName, postID)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if post.Author != user {
+		return Attachment{}, ErrForbidden
+	}
+
+	limits := b.attachLimits
+	data, err := io.ReadAll(io.LimitReader(r, limits.MaxSize+1))
+	if err != nil {
+		return Attachment{}, fmt.Errorf("read attachment: %w", err)
+	}
+	if int64(len(data)) > limits.MaxSize {
+		return Attachment{}, ErrAttachmentTooLarge
+	}
+	typ := http.DetectContentType(data)
+	if !limits.Allows(typ) {
+		return Attachment{}, fmt.Errorf("%w: %s", ErrAttachmentType, typ)
+	}
+	hash, err := b.blobs.Put(data)
+	if err != nil {
+		return Attachment{}, err
+	}
+	att := Attachment{Name: name, Size: int64(len(data)), Type: typ, Hash: hash, CreatedAt: b.now()}
+
+	if err := b.lockBoard(board); err != nil {
+		return Attachment{}, err
+	}
+	var saved pendingSave
+	defer board.unlockAndWait(&saved, &err)
+
+	p := board.findPost(postID)
+	if p == nil {
+		return Attachment{}, ErrPostNotFound
+	}
+	if p.Author != user {
+		return Attachment{}, ErrForbidden
+	}
+	if board.state == BoardArchived {
+		return Attachment{}, ErrBoardArchived
+	}
+	replaced := false
+	for i := range p.Attachments {
+		if p.Attachments[i].Name == name {
+			p.Attachments[i] = att
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		if len(p.Attachments) >= limits.MaxFiles {
+			return Attachment{}, ErrTooManyAttachments
+		}
+		p.Attachments = append(p.Attachments, att)
+	}
+
+	saved = b.persist(board)
+	b.events.publish(Event{Kind: EventAttach, Board: board.Name, PostID: postID, Actor: user, At: att.CreatedAt, Post: *p})
+	return att, nil
+}
+
+// OpenAttachment returns an attachment of a post and its content.
+func (b *BBS) OpenAttachment(boardName string, postID int, name string) (Attachment, []byte, error) {
+	if b.blobs == nil {
+		return Attachment{}, nil, ErrAttachmentsDisabled
+	}
+	post, err := b.GetPost(boardName, postID)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	for _, att := range post.Attachments {
+		if att.Name == name {
+			data, err := b.blobs.Get(att.Hash)
+			return att, data, err
+		}
+	}
+	return Attachment{}, nil, ErrAttachmentNotFound
+}
+
+// findPost returns the post with id, or nil. Callers must hold board.mu.
+func (board *Board) findPost(id int) *Post {
+	for i := range board.posts {
+		if board.posts[i].ID == id {
+			return &board.posts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package bbs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestAttachStoresFileOnOwnPost(t *testing.T) {
+	dir := t.TempDir()
+	blobs := BlobFile{Dir: filepath.Join(dir, "blobs")}
+	postStore := &memoryPostStore{data: map[string][]Post{}}
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, postStore, WithAttachments(blobs, AttachmentLimits{}))
+	post, _ := b.AddPost("general", "alice", "topic", "body")
+
+	att, err := b.Attach("general", post.ID, "alice", "shots/cat.png", bytes.NewReader(pngHeader))
+	if err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if att.Name != "cat.png" || att.Type != "image/png" || att.Size != int64(len(pngHeader)) {
+		t.Fatalf("unexpected attachment %+v", att)
+	}
+	if got := postStore.data["general"][0].Attachments; len(got) != 1 || got[0].Hash != att.Hash {
+		t.Fatalf("expected the attachment to be persisted, got %+v", got)
+	}
+
+	got, data, err := b.OpenAttachment("general", post.ID, "cat.png")
+	if err != nil || got != att || !bytes.Equal(data, pngHeader) {
+		t.Fatalf("OpenAttachment: %+v, %q, %v", got, data, err)
+	}
+
+	// The same content under another name is stored once; the same name
+	// replaces the earlier file.
+	if _, err := b.Attach("general", post.ID, "alice", "copy.png", bytes.NewReader(pngHeader)); err != nil {
+		t.Fatalf("Attach copy: %v", err)
+	}
+	if _, err := b.Attach("general", post.ID, "alice", "cat.png", strings.NewReader("now it is text")); err != nil {
+		t.Fatalf("Attach replacement: %v", err)
+	}
+	p, _ := b.GetPost("general", post.ID)
+	if len(p.Attachments) != 2 || p.Attachments[0].Type != "text/plain; charset=utf-8" {
+		t.Fatalf("unexpected attachments %+v", p.Attachments)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "blobs", att.Hash[:2]))
+	if len(entries) != 1 {
+		t.Fatalf("expected one blob for identical files, got %d", len(entries))
+	}
+}
+
+func TestAttachChecksOwnerAndLimits(t *testing.T) {
+	limits := AttachmentLimits{MaxSize: 32, MaxFiles: 1, Types: []string{"image/"}}
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, nil, WithAttachments(BlobFile{Dir: t.TempDir()}, limits))
+	post, _ := b.AddPost("general", "alice", "topic", "body")
+
+	cases := []struct {
+		user, name string
+		data       []byte
+		want       error
+	}{
+		{"bob", "cat.png", pngHeader, ErrForbidden},
+		{"alice", "..", pngHeader, ErrAttachmentName},
+		{"alice", "big.png", append(pngHeader, make([]byte, 32)...), ErrAttachmentTooLarge},
+		{"alice", "notes.txt", []byte("hello"), ErrAttachmentType},
+	}
+	for _, c := range cases {
+		if _, err := b.Attach("general", post.ID, c.user, c.name, bytes.NewReader(c.data)); !errors.Is(err, c.want) {
+			t.Fatalf("%s uploading %s: expected %v, got %v", c.user, c.name, c.want, err)
+		}
+	}
+	if _, err := b.Attach("general", post.ID, "alice", "cat.png", bytes.NewReader(pngHeader)); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if _, err := b.Attach("general", post.ID, "alice", "dog.png", bytes.NewReader(pngHeader)); !errors.Is(err, ErrTooManyAttachments) {
+		t.Fatalf("expected ErrTooManyAttachments, got %v", err)
+	}
+	if _, _, err := b.OpenAttachment("general", post.ID, "dog.png"); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Fatalf("expected ErrAttachmentNotFound, got %v", err)
+	}
+
+	plain := New(fixedNow)
+	post, _ = plain.AddPost("general", "alice", "topic", "body")
+	if _, err := plain.Attach("general", post.ID, "alice", "cat.png", bytes.NewReader(pngHeader)); !errors.Is(err, ErrAttachmentsDisabled) {
+		t.Fatalf("expected ErrAttachmentsDisabled, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,17 +16,32 @@ type Post struct {
 	Content   string
 	Author    string
 	CreatedAt time.Time
-	Comments  []Comment `json:"comments,omitempty"`
+	EditedAt  time.Time           `json:"edited_at,omitzero"` // zero until the post is edited
+	Comments  []Comment           `json:"comments,omitempty"`
+	Locked    bool                `json:"locked,omitempty"` // locked threads reject new comments
+	Tags      []string            `json:"tags,omitempty"`
+	Votes     map[string]int      `json:"votes,omitempty"`     // username -> +1 or -1
+	Reactions map[string][]string `json:"reactions,omitempty"` // emoji -> usernames
+
+	Subscribers []string `json:"subscribers,omitempty"` // users following the thread
+	Mentions    []string `json:"mentions,omitempty"`    // known users @mentioned in Content
+	Signature   string   `json:"signature,omitempty"`   // the author's signature when posted; shown by Signed, never part of Content
+
+	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
 // Comment represents a comment on a post.
 type Comment struct {
-	ID        int       `json:"id"`
-	PostID    int       `json:"post_id"`
-	ParentID  int       `json:"parent_id,omitempty"` // 0 for top-level comments
-	Author    string    `json:"author"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int                 `json:"id"`
+	PostID    int                 `json:"post_id"`
+	ParentID  int                 `json:"parent_id,omitempty"` // 0 for top-level comments
+	Author    string              `json:"author"`
+	Content   string              `json:"content"`
+	CreatedAt time.Time           `json:"created_at"`
+	Votes     map[string]int      `json:"votes,omitempty"`
+	Reactions map[string][]string `json:"reactions,omitempty"`
+	Mentions  []string            `json:"mentions,omitempty"`
+	Signature string              `json:"signature,omitempty"` // as for Post
 }
 
 // Board keeps ordered posts.
@@ -34,25 +50,67 @@ type Board struct {
 	mu     sync.RWMutex
 	posts  []Post
 	nextID int
+	state  BoardState
+
+	subscribers []string // users notified of new posts
+	private     bool     // SSH users only; see SetBoardPrivate
+
+	loaded  bool       // posts are in memory; see lockBoard and rlockBoard
+	index   BoardIndex // stands in for posts while they are not loaded
+	summary atomic.Pointer[boardSummary]
+	writer  *boardWriter
 }
 
 // BBS stores boards and posts in memory.
 type BBS struct {
 	mu     sync.RWMutex
+	saveMu sync.Mutex // orders writes to store; taken before mu
 	boards map[string]*Board
 	order  []string
 	now    func() time.Time
 	store  BoardListStore
 	posts  PostStore
+	roles  map[string]Role
+	users  directory
+	reads  readTracker
+
+	bookmarks bookmarkBook
+	inbox     notifier
+	dms       mailroom
+	events    Bus
+	profiles  profileBook
+	tokens    tokenBook
+	cache     boardCache
+
+	blobs        BlobStore // nil disables attachments
+	attachLimits AttachmentLimits
+
+	saveWindow time.Duration // see WithGroupCommit
+	ack        Ack
 }
 
+// Option configures optional BBS behaviour.
+type Option func(*BBS)
+
 var (
 	// ErrBoardNotFound signals an unknown board.
 	ErrBoardNotFound = errors.New("board not found")
 	// ErrPostNotFound signals an unknown post.
 	ErrPostNotFound = errors.New("post not found")
+	// ErrCommentNotFound signals an unknown comment.
+	ErrCommentNotFound = errors.New("comment not found")
 	// ErrEmptyTitle signals a missing post title.
 	ErrEmptyTitle = errors.New("title is required")
+	// ErrPostLocked signals a comment on a locked thread.
+	ErrPostLocked = errors.New("post is locked")
+	// ErrBoardReadOnly signals a new post on a read-only or archived board.
+	ErrBoardReadOnly = errors.New("board is read-only")
+	// ErrBoardArchived signals a write to an archived board.
+	ErrBoardArchived = errors.New("board is archived")
+	// ErrInvalidBoardState signals an unknown board state.
+	ErrInvalidBoardState = errors.New("invalid board state")
+	// ErrForbidden signals an action the user is not allowed to perform.
+	ErrForbidden = errors.New("permission denied")
 )
 
 // New returns an in-memory BBS with a default "general" board.
@@ -63,7 +121,7 @@ func New(now func() time.Time) *BBS {
 // NewWithBoards builds a BBS with provided board names and optional stores.
 // If names is empty, defaults are used. Board stores are invoked when new boards are created.
 // Post stores persist per-board posts.
-func NewWithBoards(now func() time.Time, names []string, store BoardListStore, posts PostStore) *BBS {
+func NewWithBoards(now func() time.Time, names []string, store BoardListStore, posts PostStore, opts ...Option) *BBS {
 	if now == nil {
 		now = time.Now
 	}
@@ -82,14 +140,22 @@ func NewWithBoards(now func() time.Time, names []string, store BoardListStore, p
 		store:  store,
 		posts:  posts,
 	}
-	b.loadPosts()
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.loadMeta()
+	b.loadIndexes()
 	return b
 }
 
 // BoardSummary describes a board and its stats.
 type BoardSummary struct {
-	Name      string
-	PostCount int
+	Name       string
+	PostCount  int
+	State      BoardState
+	Private    bool
+	Unread     int  // set by ListBoardsFor
+	Subscribed bool // set by ListBoardsFor
 }
 
 // ListBoards returns board summaries sorted by name.
@@ -101,11 +167,14 @@ func (b *BBS) ListBoards() []BoardSummary {
 	for _, name := range b.order {
 		board := b.boards[name]
 		board.mu.RLock()
-		count := len(board.posts)
+		count := board.postCount()
+		state, private := board.state, board.private
 		board.mu.RUnlock()
 		out = append(out, BoardSummary{
 			Name:      name,
 			PostCount: count,
+			State:     state,
+			Private:   private,
 		})
 	}
 	return out
@@ -117,7 +186,7 @@ func (b *BBS) ListPosts(boardName string) ([]Post, error) {
 	if !ok {
 		return nil, ErrBoardNotFound
 	}
-	board.mu.RLock()
+	b.rlockBoard(board)
 	defer board.mu.RUnlock()
 
 	posts := make([]Post, len(board.posts))
@@ -126,7 +195,8 @@ func (b *BBS) ListPosts(boardName string) ([]Post, error) {
 }
 
 // AddPost adds a post to the board, creating the board implicitly.
-func (b *BBS) AddPost(boardName, author, title, content string) (Post, error) {
+// Tags are normalized before they are stored.
+func (b *BBS) AddPost(boardName, author, title, content string, tags ...string) (Post, error) {
 	title = strings.TrimSpace(title)
 	if title == "" {
 		return Post{}, ErrEmptyTitle
@@ -138,26 +208,62 @@ func (b *BBS) AddPost(boardName, author, title, content string) (Post, error) {
 	if err != nil {
 		return Post{}, fmt.Errorf("ensure board: %w", err)
 	}
+	content = strings.TrimSpace(content)
+	b.users.seen(author)
+	mentions := b.mentions(content)
+	signature := b.signature(author)
 
-	board.mu.Lock()
-	defer board.mu.Unlock()
+	post, recipients, err := b.addPost(board, Post{
+		Title:   title,
+		Content: content,
+		Author:  author,
+		Tags:    normalizeTags(tags),
+		// Authors follow their own threads.
+		Subscribers: []string{author},
+		Mentions:    mentions,
+		Signature:   signature,
+	})
+	if err != nil {
+		return Post{}, err
+	}
+
+	// Both save to disk, so they wait until the board is unlocked.
+	// Authors have read their own posts.
+	_ = b.MarkRead(author, board.Name, post.ID, 0)
+	b.notify(recipients, Notification{
+		Board: board.Name, PostID: post.ID, Actor: author, Title: post.Title, CreatedAt: post.CreatedAt,
+	})
+	return post, nil
+}
+
+// addPost numbers, stamps and stores post, returning it with the users to
+// notify.
+func (b *BBS) addPost(board *Board, post Post) (_ Post, _ map[string]NotificationKind, err error) {
+	if err := b.lockBoard(board); err != nil {
+		return Post{}, nil, err
+	}
+	var saved pendingSave
+	defer board.unlockAndWait(&saved, &err)
 
-	post := Post{
-		ID:        board.nextID,
-		Title:     title,
-		Content:   strings.TrimSpace(content),
-		Author:    author,
-		CreatedAt: b.now(),
+	if board.state != BoardOpen {
+		return Post{}, nil, ErrBoardReadOnly
 	}
+
+	post.ID = board.nextID
+	post.CreatedAt = b.now()
 	board.nextID++
 	board.posts = append(board.posts, post)
+	saved = b.persist(board)
 
-	if b.posts != nil {
-		if err := b.posts.Save(board.Name, board.posts); err != nil {
-			return Post{}, fmt.Errorf("store post: %w", err)
-		}
+	recipients := make(map[string]NotificationKind, len(board.subscribers))
+	for _, u := range board.subscribers {
+		recipients[u] = NotifyPost
 	}
-	return post, nil
+	for _, u := range post.Mentions {
+		recipients[u] = NotifyMention
+	}
+	b.events.publish(Event{Kind: EventPost, Board: board.Name, PostID: post.ID, Actor: post.Author, At: post.CreatedAt, Post: post})
+	return post, recipients, nil
 }
 
 // GetPost returns one post by ID.
@@ -166,7 +272,7 @@ func (b *BBS) GetPost(boardName string, id int) (Post, error) {
 	if !ok {
 		return Post{}, ErrBoardNotFound
 	}
-	board.mu.RLock()
+	b.rlockBoard(board)
 	defer board.mu.RUnlock()
 	for _, p := range board.posts {
 		if p.ID == id {
@@ -183,44 +289,52 @@ func (b *BBS) board(name string) (*Board, bool) {
 	return board, ok
 }
 
+// boardList returns boards in display order.
+func (b *BBS) boardList() []*Board {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	out := make([]*Board, 0, len(b.order))
+	for _, name := range b.order {
+		out = append(out, b.boards[name])
+	}
+	return out
+}
+
 func (b *BBS) ensureBoard(name string) (*Board, error) {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		name = "general"
 	}
 	b.mu.Lock()
-	defer b.mu.Unlock()
 	if board, ok := b.boards[name]; ok {
+		b.mu.Unlock()
 		return board, nil
 	}
-	board := &Board{Name: name, nextID: 1}
+	// Without a post store there is nothing to load later.
+	board := &Board{Name: name, nextID: 1, loaded: b.posts == nil}
 	b.boards[name] = board
 	b.order = append(b.order, name)
-	if b.store != nil {
-		if err := b.store.Save(boardNames(b.boards, b.order)); err != nil {
-			return nil, err
-		}
+	b.mu.Unlock()
+
+	if err := b.saveBoards(); err != nil {
+		return nil, err
 	}
 	return board, nil
 }
 
-func (b *BBS) loadPosts() {
-	if b.posts == nil {
-		return
-	}
-	for _, name := range b.order {
-		posts, err := b.posts.Load(name)
-		if err != nil {
-			continue
-		}
-		board := b.boards[name]
-		for _, p := range posts {
-			board.posts = append(board.posts, p)
-			if p.ID >= board.nextID {
-				board.nextID = p.ID + 1
-			}
-		}
+// saveBoards writes the board list. The store is written without holding
+// b.mu; b.saveMu keeps writers in order, and each writes the list as it is
+// once its turn comes, so the last write is always current.
+func (b *BBS) saveBoards() error {
+	if b.store == nil {
+		return nil
 	}
+	b.saveMu.Lock()
+	defer b.saveMu.Unlock()
+	b.mu.RLock()
+	names := boardNames(b.boards, b.order)
+	b.mu.RUnlock()
+	return b.store.Save(names)
 }
 
 func boardNames(m map[string]*Board, order []string) []string {
@@ -235,41 +349,106 @@ func boardNames(m map[string]*Board, order []string) []string {
 
 // DeletePost removes a post if the author matches.
 func (b *BBS) DeletePost(boardName string, postID int, author string) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
-	board, ok := b.boards[boardName]
+	board, ok := b.board(boardName)
 	if !ok {
 		return ErrBoardNotFound
 	}
 
-	if err := board.deletePost(postID, author); err != nil {
+	removed, err := b.deletePost(board, postID, author)
+	if err != nil {
 		return err
 	}
 
-	// Save to disk
-	if b.posts != nil {
-		if err := b.posts.Save(boardName, board.posts); err != nil {
-			return err
+	b.events.publish(Event{Kind: EventDelete, Board: boardName, PostID: postID, Actor: author, At: b.now(), Post: removed})
+	return nil
+}
+
+// EditPost replaces the title, content and tags of a post and stamps its
+// edit time. Only the author may edit a post, and not on archived boards.
+func (b *BBS) EditPost(boardName string, postID int, user, title, content string, tags ...string) (Post, error) {
+	return b.PatchPost(boardName, postID, user, PostPatch{Title: &title, Content: &content, Tags: &tags})
+}
+
+// PostPatch holds the fields of a post to change. Nil fields keep their
+// current value.
+type PostPatch struct {
+	Title   *string
+	Content *string
+	Tags    *[]string
+}
+
+// PatchPost changes the fields set in patch like EditPost. The unset fields
+// are read under the same lock as the write, so concurrent patches of
+// different fields never undo each other.
+func (b *BBS) PatchPost(boardName string, postID int, user string, patch PostPatch) (_ Post, err error) {
+	var title, content string
+	var mentions []string
+	if patch.Title != nil {
+		title = strings.TrimSpace(*patch.Title)
+		if title == "" {
+			return Post{}, ErrEmptyTitle
 		}
 	}
+	if patch.Content != nil {
+		content = strings.TrimSpace(*patch.Content)
+		mentions = b.mentions(content)
+	}
+	board, ok := b.board(boardName)
+	if !ok {
+		return Post{}, ErrBoardNotFound
+	}
 
-	return nil
+	if err := b.lockBoard(board); err != nil {
+		return Post{}, err
+	}
+	var saved pendingSave
+	defer board.unlockAndWait(&saved, &err)
+
+	p := board.findPost(postID)
+	if p == nil {
+		return Post{}, ErrPostNotFound
+	}
+	if p.Author != user {
+		return Post{}, fmt.Errorf("%w: only the author can edit this post", ErrForbidden)
+	}
+	if board.state == BoardArchived {
+		return Post{}, ErrBoardArchived
+	}
+	if patch.Title != nil {
+		p.Title = title
+	}
+	if patch.Content != nil {
+		p.Content = content
+		p.Mentions = mentions
+	}
+	if patch.Tags != nil {
+		p.Tags = normalizeTags(*patch.Tags)
+	}
+	p.EditedAt = b.now()
+	saved = b.persist(board)
+
+	b.events.publish(Event{Kind: EventEdit, Board: board.Name, PostID: p.ID, Actor: user, At: p.EditedAt, Post: *p})
+	return *p, nil
 }
 
-func (b *Board) deletePost(postID int, author string) error {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+func (b *BBS) deletePost(board *Board, postID int, author string) (_ Post, err error) {
+	if err := b.lockBoard(board); err != nil {
+		return Post{}, err
+	}
+	var saved pendingSave
+	defer board.unlockAndWait(&saved, &err)
 
-	for i, p := range b.posts {
+	for i, p := range board.posts {
 		if p.ID == postID {
 			if p.Author != author {
-				return errors.New("unauthorized: only the author can delete this post")
+				return Post{}, fmt.Errorf("%w: only the author can delete this post", ErrForbidden)
 			}
 			// Remove post
-			b.posts = append(b.posts[:i], b.posts[i+1:]...)
-			return nil
+			board.posts = append(board.posts[:i], board.posts[i+1:]...)
+			// Save to disk
+			saved = b.persist(board)
+			return p, nil
 		}
 	}
-	return ErrPostNotFound
+	return Post{}, ErrPostNotFound
 }
@@ -1,6 +1,9 @@
 package bbs
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 )
@@ -102,3 +105,62 @@ func TestStoresBoardsOnCreate(t *testing.T) {
 		t.Fatalf("unexpected stored names: %v", last)
 	}
 }
+
+func TestEditPost(t *testing.T) {
+	b := New(fixedNow)
+	events, stop := b.Subscribe()
+	defer stop()
+	post, _ := b.AddPost("general", "alice", "draft", "first try")
+	<-events
+
+	if _, err := b.EditPost("general", post.ID, "bob", "mine", ""); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for bob, got %v", err)
+	}
+	if _, err := b.EditPost("general", post.ID, "alice", " ", ""); !errors.Is(err, ErrEmptyTitle) {
+		t.Fatalf("expected ErrEmptyTitle, got %v", err)
+	}
+	edited, err := b.EditPost("general", post.ID, "alice", "final", "second try", "Go")
+	if err != nil {
+		t.Fatalf("EditPost: %v", err)
+	}
+	got, _ := b.GetPost("general", post.ID)
+	if got.Title != "final" || got.Content != "second try" || !got.HasTag("go") || got.EditedAt.IsZero() || !got.EditedAt.Equal(edited.EditedAt) {
+		t.Fatalf("unexpected post after edit: %+v", got)
+	}
+	if e := <-events; e.Kind != EventEdit || e.Post.Title != "final" || e.Actor != "alice" {
+		t.Fatalf("expected an edit event, got %+v", e)
+	}
+}
+
+func TestConcurrentPatchesKeepEachField(t *testing.T) {
+	b := New(fixedNow)
+	post, _ := b.AddPost("general", "alice", "draft", "body", "old")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		title, content := fmt.Sprintf("title %d", i), fmt.Sprintf("content %d", i)
+		go func() {
+			defer wg.Done()
+			if _, err := b.PatchPost("general", post.ID, "alice", PostPatch{Title: &title}); err != nil {
+				t.Errorf("PatchPost: %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := b.PatchPost("general", post.ID, "alice", PostPatch{Content: &content}); err != nil {
+				t.Errorf("PatchPost: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := b.GetPost("general", post.ID)
+	if got.Title == "draft" || got.Content == "body" || !got.HasTag("old") {
+		t.Fatalf("a patch undid another: %+v", got)
+	}
+	blank := " "
+	if _, err := b.PatchPost("general", post.ID, "alice", PostPatch{Title: &blank}); !errors.Is(err, ErrEmptyTitle) {
+		t.Fatalf("expected ErrEmptyTitle, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package bbs
+
+import (
+	"sync"
+	"time"
+)
+
+// Bookmark references a saved post. Title is kept so the entry still reads
+// well after the post is deleted.
+type Bookmark struct {
+	Board   string    `json:"board"`
+	PostID  int       `json:"post_id"`
+	Title   string    `json:"title"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// SavedPost is a bookmark resolved against the current posts.
+// Missing is set when the post or its board no longer exists.
+type SavedPost struct {
+	Bookmark
+	Post    Post
+	Missing bool
+}
+
+// BookmarkStore persists bookmarks per user.
+type BookmarkStore interface {
+	Load(user string) ([]Bookmark, error)
+	Save(user string, bookmarks []Bookmark) error
+}
+
+// BookmarkFile stores bookmarks as one JSON file per user in Dir.
+// File format: {"bookmarks":[{"board":"general","post_id":3,...}]}.
+type BookmarkFile struct {
+	Dir           string
+	EncryptionKey []byte // 32 bytes for AES-256
+}
+
+type bookmarkFileData struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+}
+
+func (f BookmarkFile) Load(user string) ([]Bookmark, error) {
+	var wrapper bookmarkFileData
+	err := userFile{Dir: f.Dir, EncryptionKey: f.EncryptionKey}.load(user, &wrapper)
+	return wrapper.Bookmarks, err
+}
+
+func (f BookmarkFile) Save(user string, bookmarks []Bookmark) error {
+	return userFile{Dir: f.Dir, EncryptionKey: f.EncryptionKey}.save(user, bookmarkFileData{Bookmarks: bookmarks})
+}
+
+// WithBookmarkStore persists bookmarks through store.
+func WithBookmarkStore(store BookmarkStore) Option {
+	return func(b *BBS) {
+		b.bookmarks.store = store
+	}
+}
+
+// bookmarkBook caches bookmarks per user, newest first.
+type bookmarkBook struct {
+	mu    sync.Mutex
+	store BookmarkStore
+	lists map[string][]Bookmark
+}
+
+// get returns the user's bookmarks, loading them on first use.
+// Callers must hold k.mu.
+func (k *bookmarkBook) get(user string) []Bookmark {
+	if list, ok := k.lists[user]; ok {
+		return list
+	}
+	var list []Bookmark
+	if k.store != nil {
+		if loaded, err := k.store.Load(user); err == nil {
+			list = loaded
+		}
+	}
+	if k.lists == nil {
+		k.lists = make(map[string][]Bookmark)
+	}
+	k.lists[user] = list
+	return list
+}
+
+// set replaces and persists the user's bookmarks. Callers must hold k.mu.
+func (k *bookmarkBook) set(user string, list []Bookmark) error {
+	k.lists[user] = list
+	if k.store == nil {
+		return nil
+	}
+	return k.store.Save(user, list)
+}
+
+func bookmarkIndex(list []Bookmark, boardName string, postID int) int {
+	for i, bm := range list {
+		if bm.Board == boardName && bm.PostID == postID {
+			return i
+		}
+	}
+	return -1
+}
+
+// ToggleBookmark saves a post for user, or removes it if already saved.
+// It reports whether the post is now saved.
+func (b *BBS) ToggleBookmark(user, boardName string, postID int) (bool, error) {
+	b.bookmarks.mu.Lock()
+	defer b.bookmarks.mu.Unlock()
+
+	list := b.bookmarks.get(user)
+	if i := bookmarkIndex(list, boardName, postID); i >= 0 {
+		next := append(append([]Bookmark(nil), list[:i]...), list[i+1:]...)
+		return false, b.bookmarks.set(user, next)
+	}
+
+	post, err := b.GetPost(boardName, postID)
+	if err != nil {
+		return false, err
+	}
+	bm := Bookmark{Board: boardName, PostID: postID, Title: post.Title, SavedAt: b.now()}
+	next := append([]Bookmark{bm}, list...)
+	return true, b.bookmarks.set(user, next)
+}
+
+// RemoveBookmark drops a saved post, whether or not the post still exists.
+func (b *BBS) RemoveBookmark(user, boardName string, postID int) error {
+	b.bookmarks.mu.Lock()
+	defer b.bookmarks.mu.Unlock()
+
+	list := b.bookmarks.get(user)
+	i := bookmarkIndex(list, boardName, postID)
+	if i < 0 {
+		return nil
+	}
+	next := append(append([]Bookmark(nil), list[:i]...), list[i+1:]...)
+	return b.bookmarks.set(user, next)
+}
+
+// IsBookmarked reports whether user saved the post.
+func (b *BBS) IsBookmarked(user, boardName string, postID int) bool {
+	b.bookmarks.mu.Lock()
+	defer b.bookmarks.mu.Unlock()
+	return bookmarkIndex(b.bookmarks.get(user), boardName, postID) >= 0
+}
+
+// ListBookmarks returns the user's saved posts, newest first. Posts that were
+// deleted since are returned with Missing set instead of being dropped.
+func (b *BBS) ListBookmarks(user string) []SavedPost {
+	b.bookmarks.mu.Lock()
+	list := append([]Bookmark(nil), b.bookmarks.get(user)...)
+	b.bookmarks.mu.Unlock()
+
+	out := make([]SavedPost, 0, len(list))
+	for _, bm := range list {
+		saved := SavedPost{Bookmark: bm}
+		post, err := b.GetPost(bm.Board, bm.PostID)
+		if err != nil {
+			saved.Missing = true
+		} else {
+			saved.Post = post
+		}
+		out = append(out, saved)
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package bbs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToggleBookmark(t *testing.T) {
+	b := New(fixedNow)
+	post, _ := b.AddPost("general", "alice", "keep me", "")
+
+	if _, err := b.ToggleBookmark("bob", "general", 42); !errors.Is(err, ErrPostNotFound) {
+		t.Fatalf("expected ErrPostNotFound, got %v", err)
+	}
+	saved, err := b.ToggleBookmark("bob", "general", post.ID)
+	if err != nil || !saved {
+		t.Fatalf("ToggleBookmark: saved %v err %v", saved, err)
+	}
+	if !b.IsBookmarked("bob", "general", post.ID) || b.IsBookmarked("alice", "general", post.ID) {
+		t.Fatalf("bookmarks must be per user")
+	}
+	saved, _ = b.ToggleBookmark("bob", "general", post.ID)
+	if saved || len(b.ListBookmarks("bob")) != 0 {
+		t.Fatalf("expected toggle to remove bookmark")
+	}
+}
+
+func TestBookmarksSurviveDeletedPosts(t *testing.T) {
+	store := BookmarkFile{Dir: t.TempDir()}
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, nil, WithBookmarkStore(store))
+	first, _ := b.AddPost("general", "alice", "first", "")
+	second, _ := b.AddPost("general", "alice", "second", "")
+	_, _ = b.ToggleBookmark("bob", "general", first.ID)
+	_, _ = b.ToggleBookmark("bob", "general", second.ID)
+
+	if err := b.DeletePost("general", first.ID, "alice"); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+
+	list := b.ListBookmarks("bob")
+	if len(list) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %d", len(list))
+	}
+	if list[0].PostID != second.ID || list[0].Missing || list[0].Post.Title != "second" {
+		t.Fatalf("unexpected newest bookmark: %+v", list[0])
+	}
+	if !list[1].Missing || list[1].Title != "first" {
+		t.Fatalf("expected deleted post to be flagged missing: %+v", list[1])
+	}
+
+	if err := b.RemoveBookmark("bob", "general", first.ID); err != nil {
+		t.Fatalf("RemoveBookmark: %v", err)
+	}
+	persisted, err := store.Load("bob")
+	if err != nil || len(persisted) != 1 || persisted[0].PostID != second.ID {
+		t.Fatalf("unexpected persisted bookmarks %+v err %v", persisted, err)
+	}
+}
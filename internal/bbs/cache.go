@@ -0,0 +1,390 @@
+package bbs
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// indexVersion is bumped whenever BoardIndex gains fields older indexes
+// lack; boards with an older index are read once at startup to rebuild it.
+const indexVersion = 2
+
+// BoardIndex summarizes a board without its posts, so board lists, unread
+// counts, tags, profiles and the mention directory work while the posts are
+// not loaded.
+type BoardIndex struct {
+	Version int         `json:"version,omitempty"`
+	NextID  int         `json:"next_id"`
+	Threads []ThreadRef `json:"threads"`           // one per post, in board order
+	Authors []string    `json:"authors,omitempty"` // everyone who posted or commented
+}
+
+// ThreadRef is what the index keeps of a post: its ID, the ID of its last
+// comment, the fields cross-board lists show and who commented on it.
+type ThreadRef struct {
+	ID          int         `json:"id"`
+	LastComment int         `json:"last_comment,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	Author      string      `json:"author,omitempty"`
+	CreatedAt   time.Time   `json:"created_at,omitzero"`
+	EditedAt    time.Time   `json:"edited_at,omitzero"`
+	Tags        []string    `json:"tags,omitempty"`
+	Commenters  []Commenter `json:"commenters,omitempty"` // sorted by user
+}
+
+// Commenter counts one user's comments on a post.
+type Commenter struct {
+	User     string    `json:"user"`
+	Comments int       `json:"comments"`
+	First    time.Time `json:"first"`
+}
+
+// IndexStore persists board indexes. Post stores may optionally implement
+// it; without one every board is read once at startup to build its index.
+type IndexStore interface {
+	// LoadIndex returns ok=false when the board has no index yet.
+	LoadIndex(board string) (idx BoardIndex, ok bool, err error)
+	SaveIndex(board string, idx BoardIndex) error
+}
+
+// WithBoardCache keeps at most maxPosts posts in memory, unloading the
+// least recently used boards beyond that. Zero keeps every board loaded
+// once it has been read. It only applies with a post store, since boards
+// without one cannot be read back.
+func WithBoardCache(maxPosts int) Option {
+	return func(b *BBS) {
+		b.cache.limit = maxPosts
+	}
+}
+
+func indexPosts(nextID int, posts []Post) BoardIndex {
+	idx := BoardIndex{Version: indexVersion, NextID: nextID, Threads: make([]ThreadRef, len(posts))}
+	authors := make(map[string]struct{})
+	for i, p := range posts {
+		idx.Threads[i] = threadRef(p)
+		authors[p.Author] = struct{}{}
+		for _, c := range p.Comments {
+			authors[c.Author] = struct{}{}
+		}
+	}
+	for a := range authors {
+		idx.Authors = append(idx.Authors, a)
+	}
+	sort.Strings(idx.Authors)
+	return idx
+}
+
+// threadRef summarizes p for the index.
+func threadRef(p Post) ThreadRef {
+	ref := ThreadRef{
+		ID:          p.ID,
+		LastComment: lastCommentID(p),
+		Title:       p.Title,
+		Author:      p.Author,
+		CreatedAt:   p.CreatedAt,
+		EditedAt:    p.EditedAt,
+		Tags:        p.Tags,
+	}
+	byUser := make(map[string]int)
+	for _, c := range p.Comments {
+		i, ok := byUser[c.Author]
+		if !ok {
+			i = len(ref.Commenters)
+			byUser[c.Author] = i
+			ref.Commenters = append(ref.Commenters, Commenter{User: c.Author})
+		}
+		ref.Commenters[i].Comments++
+		ref.Commenters[i].First = earliest(ref.Commenters[i].First, c.CreatedAt)
+	}
+	sort.Slice(ref.Commenters, func(i, j int) bool {
+		return ref.Commenters[i].User < ref.Commenters[j].User
+	})
+	return ref
+}
+
+// threads returns the board's ID and last comment pairs, from the posts when
+// they are loaded and from the index otherwise. Callers must hold board.mu.
+func (board *Board) threads() []ThreadRef {
+	if !board.loaded {
+		return board.index.Threads
+	}
+	out := make([]ThreadRef, len(board.posts))
+	for i, p := range board.posts {
+		out[i] = ThreadRef{ID: p.ID, LastComment: lastCommentID(p)}
+	}
+	return out
+}
+
+// refs returns the full ThreadRef of every post, from the posts when they
+// are loaded and from the index otherwise. Callers must hold board.mu.
+func (board *Board) refs() []ThreadRef {
+	if !board.loaded {
+		return board.index.Threads
+	}
+	out := make([]ThreadRef, len(board.posts))
+	for i, p := range board.posts {
+		out[i] = threadRef(p)
+	}
+	return out
+}
+
+// postCount is len(threads()) without building the slice. Callers must
+// hold board.mu.
+func (board *Board) postCount() int {
+	if !board.loaded {
+		return len(board.index.Threads)
+	}
+	return len(board.posts)
+}
+
+// loadLocked reads the board's posts. Callers must hold board.mu for writing.
+func (b *BBS) loadLocked(board *Board) error {
+	if board.loaded {
+		return nil
+	}
+	posts, err := b.posts.Load(board.Name)
+	if err != nil {
+		// The board stays unloaded so the next access retries.
+		return fmt.Errorf("load board %s: %w", board.Name, err)
+	}
+	board.posts = posts
+	for _, p := range posts {
+		if p.ID >= board.nextID {
+			board.nextID = p.ID + 1
+		}
+	}
+	board.loaded = true
+	return nil
+}
+
+// rlockBoard read-locks board with its posts in memory. A board that fails
+// to load reads as empty.
+func (b *BBS) rlockBoard(board *Board) {
+	board.mu.RLock()
+	for !board.loaded {
+		board.mu.RUnlock()
+		board.mu.Lock()
+		err := b.loadLocked(board)
+		board.mu.Unlock()
+		board.mu.RLock()
+		if err != nil {
+			return
+		}
+		// Another session may have evicted the board between the two
+		// locks; go around again if so.
+	}
+	b.cache.touch(b, board)
+}
+
+// lockBoard write-locks board with its posts in memory. On error the board
+// is left unlocked, so a failed read is never saved over the posts on disk.
+func (b *BBS) lockBoard(board *Board) error {
+	board.mu.Lock()
+	if err := b.loadLocked(board); err != nil {
+		board.mu.Unlock()
+		return err
+	}
+	// The caller may change the posts; the summary is rebuilt on next use.
+	board.summary.Store(nil)
+	b.cache.touch(b, board)
+	return nil
+}
+
+// boardCache orders loaded boards by last use and unloads the oldest once
+// more than limit posts are in memory.
+type boardCache struct {
+	mu    sync.Mutex
+	limit int
+	lru   *list.List // of *Board, most recently used first
+	elems map[*Board]*list.Element
+	sizes map[*Board]int
+	total int
+}
+
+// touch marks board as just used. Callers hold board.mu, which keeps it
+// from being evicted here.
+func (c *boardCache) touch(b *BBS, board *Board) {
+	if c.limit <= 0 || b.posts == nil || !board.loaded {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lru == nil {
+		c.lru = list.New()
+		c.elems = make(map[*Board]*list.Element)
+		c.sizes = make(map[*Board]int)
+	}
+	if e, ok := c.elems[board]; ok {
+		c.lru.MoveToFront(e)
+	} else {
+		c.elems[board] = c.lru.PushFront(board)
+	}
+	c.total += len(board.posts) - c.sizes[board]
+	c.sizes[board] = len(board.posts)
+
+	// Walk from the coldest board. Boards someone is using are skipped;
+	// TryLock never waits, so this cannot deadlock with lock holders.
+	for e := c.lru.Back(); e != nil && c.total > c.limit; {
+		prev := e.Prev()
+		victim := e.Value.(*Board)
+		// Boards with saves still queued stay until the disk catches up.
+		if victim != board && victim.mu.TryLock() {
+			if !victim.dirty() {
+				victim.unload()
+				c.total -= c.sizes[victim]
+				delete(c.sizes, victim)
+				delete(c.elems, victim)
+				c.lru.Remove(e)
+			}
+			victim.mu.Unlock()
+		}
+		e = prev
+	}
+}
+
+// unload drops the posts, keeping an index in their place. Callers must
+// hold board.mu for writing.
+func (board *Board) unload() {
+	board.index = indexPosts(board.nextID, board.posts)
+	board.posts = nil
+	board.loaded = false
+}
+
+// loadIndexes prepares every board at startup. Boards with a saved index
+// start unloaded; the rest are read now and their index saved for next time.
+func (b *BBS) loadIndexes() {
+	if b.posts == nil {
+		for _, board := range b.boards {
+			board.loaded = true
+		}
+		return
+	}
+	is, _ := b.posts.(IndexStore)
+	for _, name := range b.order {
+		board := b.boards[name]
+		if is != nil {
+			if idx, ok, err := is.LoadIndex(name); err == nil && ok && idx.Version == indexVersion {
+				board.index = idx
+				board.nextID = max(board.nextID, idx.NextID)
+				for _, a := range idx.Authors {
+					b.users.seen(a)
+				}
+				continue
+			}
+		}
+		board.mu.Lock()
+		if err := b.loadLocked(board); err != nil {
+			board.mu.Unlock()
+			continue
+		}
+		idx := indexPosts(board.nextID, board.posts)
+		b.cache.touch(b, board)
+		board.mu.Unlock()
+		for _, a := range idx.Authors {
+			b.users.seen(a)
+		}
+		if is != nil {
+			_ = is.SaveIndex(name, idx)
+		}
+	}
+}
+
+// boardSummary indexes a board's posts by tag and by author for the
+// cross-board queries. It is built from refs, so it never loads the posts,
+// and is dropped by lockBoard whenever they may change. Once built it is
+// never modified.
+type boardSummary struct {
+	posts   []PostRef // in board order
+	tags    map[string][]PostRef
+	authors map[string]*authorActivity
+}
+
+// authorActivity is one user's posts and comments on a board.
+type authorActivity struct {
+	posts    []PostRef // in board order
+	comments int
+	first    time.Time // earliest post or comment
+}
+
+func (s *boardSummary) author(user string) *authorActivity {
+	a, ok := s.authors[user]
+	if !ok {
+		a = &authorActivity{}
+		s.authors[user] = a
+	}
+	return a
+}
+
+// summarize returns the board's summary, building it on first use. Callers
+// must hold board.mu.
+func (board *Board) summarize() *boardSummary {
+	if s := board.summary.Load(); s != nil {
+		return s
+	}
+	s := &boardSummary{
+		tags:    make(map[string][]PostRef),
+		authors: make(map[string]*authorActivity),
+	}
+	for _, t := range board.refs() {
+		ref := PostRef{Board: board.Name, ID: t.ID, Title: t.Title, Author: t.Author, CreatedAt: t.CreatedAt, UpdatedAt: t.CreatedAt}
+		if t.EditedAt.After(t.CreatedAt) {
+			ref.UpdatedAt = t.EditedAt
+		}
+		s.posts = append(s.posts, ref)
+		for _, tag := range t.Tags {
+			s.tags[tag] = append(s.tags[tag], ref)
+		}
+		a := s.author(t.Author)
+		a.posts = append(a.posts, ref)
+		a.first = earliest(a.first, t.CreatedAt)
+		for _, c := range t.Commenters {
+			a := s.author(c.User)
+			a.comments += c.Comments
+			a.first = earliest(a.first, c.First)
+		}
+	}
+	// Readers racing here build the same summary, so either copy will do.
+	board.summary.Store(s)
+	return s
+}
+
+// summaries returns the summary of every board without loading any posts.
+func (b *BBS) summaries() []*boardSummary {
+	boards := b.boardList()
+	out := make([]*boardSummary, len(boards))
+	for i, board := range boards {
+		board.mu.RLock()
+		out[i] = board.summarize()
+		board.mu.RUnlock()
+	}
+	return out
+}
+
+// EachPost calls fn with every post on the board, in board order, without
+// adding the board to the cache: an unloaded board is read from the store
+// and dropped again afterwards. It suits one-off passes over every board,
+// such as building a search index. fn runs under the board's lock and must
+// not call back into the BBS.
+func (b *BBS) EachPost(boardName string, fn func(Post)) error {
+	board, ok := b.board(boardName)
+	if !ok {
+		return ErrBoardNotFound
+	}
+	board.mu.RLock()
+	defer board.mu.RUnlock()
+	posts := board.posts
+	if !board.loaded {
+		// Boards are only unloaded once saved, so the store is current.
+		var err error
+		if posts, err = b.posts.Load(board.Name); err != nil {
+			return fmt.Errorf("load board %s: %w", board.Name, err)
+		}
+	}
+	for _, p := range posts {
+		fn(p)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package bbs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingStore counts how often boards are read from disk.
+type countingStore struct {
+	PostFile
+	loads atomic.Int32
+}
+
+func (s *countingStore) Load(board string) ([]Post, error) {
+	s.loads.Add(1)
+	return s.PostFile.Load(board)
+}
+
+// plainStore hides the IndexStore methods of PostFile.
+type plainStore struct{ f PostFile }
+
+func (s plainStore) Load(board string) ([]Post, error)     { return s.f.Load(board) }
+func (s plainStore) Save(board string, posts []Post) error { return s.f.Save(board, posts) }
+
+var cacheBoards = []string{"a", "b", "c"}
+
+// seedBoards writes two posts, one with a comment, to each board.
+func seedBoards(t *testing.T, store PostStore) {
+	t.Helper()
+	b := NewWithBoards(fixedNow, cacheBoards, nil, store)
+	for _, name := range cacheBoards {
+		for i := 0; i < 2; i++ {
+			if _, err := b.AddPost(name, "alice", fmt.Sprintf("%s-%d", name, i), "body"); err != nil {
+				t.Fatalf("AddPost: %v", err)
+			}
+		}
+		if _, err := b.AddComment(name, 1, "bob", "hi", 0); err != nil {
+			t.Fatalf("AddComment: %v", err)
+		}
+	}
+}
+
+func loadedBoards(b *BBS) []string {
+	var out []string
+	for _, board := range b.boardList() {
+		board.mu.RLock()
+		if board.loaded {
+			out = append(out, board.Name)
+		}
+		board.mu.RUnlock()
+	}
+	return out
+}
+
+func TestBoardsLoadOnFirstAccess(t *testing.T) {
+	store := &countingStore{PostFile: PostFile{Dir: t.TempDir()}}
+	seedBoards(t, store)
+	store.loads.Store(0)
+
+	b := NewWithBoards(fixedNow, cacheBoards, nil, store, WithBoardCache(100))
+	if n := store.loads.Load(); n != 0 {
+		t.Fatalf("expected no board reads at startup, got %d", n)
+	}
+
+	// Summaries and unread counts come from the index.
+	for _, s := range b.ListBoardsFor("carol") {
+		if s.PostCount != 2 || s.Unread != 2 {
+			t.Fatalf("unexpected summary %+v", s)
+		}
+	}
+	if err := b.MarkBoardRead("carol", "b"); err != nil {
+		t.Fatalf("MarkBoardRead: %v", err)
+	}
+	if n := store.loads.Load(); n != 0 {
+		t.Fatalf("summaries read %d boards", n)
+	}
+	if got := b.mentions("@bob"); len(got) != 1 {
+		t.Fatalf("authors from the index should be mentionable, got %v", got)
+	}
+
+	// The next unread post only loads the board it is on.
+	next, ok := b.NextUnread("carol", "b", 0)
+	if !ok || next.Board != "c" || next.Title != "c-0" {
+		t.Fatalf("unexpected next unread %+v", next)
+	}
+	if got := loadedBoards(b); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("expected only board c loaded, got %v", got)
+	}
+
+	// New posts continue the IDs of the unloaded board.
+	p, err := b.AddPost("a", "alice", "third", "")
+	if err != nil || p.ID != 3 {
+		t.Fatalf("AddPost on unloaded board: %+v, %v", p, err)
+	}
+}
+
+func TestTagsAndProfilesReadTheIndex(t *testing.T) {
+	store := &countingStore{PostFile: PostFile{Dir: t.TempDir()}}
+	seeded := NewWithBoards(fixedNow, cacheBoards, nil, store)
+	_, _ = seeded.AddPost("a", "alice", "tagged", "", "go")
+	_, _ = seeded.AddPost("b", "bob", "also tagged", "", "go", "news")
+	_, _ = seeded.AddComment("b", 1, "alice", "hi", 0)
+	store.loads.Store(0)
+
+	b := NewWithBoards(fixedNow, cacheBoards, nil, store, WithBoardCache(100))
+	if got := b.ListTags(); len(got) != 2 || got[0] != (TagCount{Tag: "go", Count: 2}) {
+		t.Fatalf("unexpected tags %+v", got)
+	}
+	if got := b.ListPostsByTag("news"); len(got) != 1 || got[0].Board != "b" || got[0].Title != "also tagged" {
+		t.Fatalf("unexpected tagged posts %+v", got)
+	}
+	if p := b.Profile("alice"); p.Posts != 1 || p.Comments != 1 || !p.JoinedAt.Equal(fixedNow()) {
+		t.Fatalf("unexpected profile %+v", p)
+	}
+	if got := b.ListPostsByAuthor("bob", 0); len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("unexpected posts by author %+v", got)
+	}
+	if n := store.loads.Load(); n != 0 {
+		t.Fatalf("tag and profile queries read %d boards", n)
+	}
+
+	// Writes show up in the next query.
+	_, _ = b.AddPost("c", "alice", "more", "", "go")
+	if got := b.ListTags(); got[0].Count != 3 {
+		t.Fatalf("the new post was not counted: %+v", got)
+	}
+	if got := b.ListPostsByAuthor("alice", 0); len(got) != 2 {
+		t.Fatalf("unexpected posts by author %+v", got)
+	}
+}
+
+func TestListRecentlyUpdatedReadsTheIndex(t *testing.T) {
+	store := &countingStore{PostFile: PostFile{Dir: t.TempDir()}}
+	now := fixedNow()
+	clock := func() time.Time { now = now.Add(time.Minute); return now }
+	seeded := NewWithBoards(clock, cacheBoards, nil, store)
+	_, _ = seeded.AddPost("a", "alice", "old", "body")
+	_, _ = seeded.AddPost("b", "bob", "middle", "body")
+	_, _ = seeded.AddPost("a", "alice", "new", "body")
+	if _, err := seeded.EditPost("a", 1, "alice", "old, edited", "body"); err != nil {
+		t.Fatalf("EditPost: %v", err)
+	}
+	store.loads.Store(0)
+
+	b := NewWithBoards(clock, cacheBoards, nil, store, WithBoardCache(100))
+	got := b.ListRecentlyUpdated([]string{"a", "b", "missing"}, 0)
+	var titles []string
+	for _, ref := range got {
+		titles = append(titles, ref.Title)
+	}
+	if strings.Join(titles, ",") != "old, edited,new,middle" {
+		t.Fatalf("unexpected order %q", titles)
+	}
+	if !got[0].UpdatedAt.After(got[0].CreatedAt) {
+		t.Fatalf("the edit time was not indexed: %+v", got[0])
+	}
+	if got := b.ListRecentlyUpdated([]string{"b"}, 0); len(got) != 1 || got[0].Board != "b" {
+		t.Fatalf("unexpected posts of board b %+v", got)
+	}
+	if got := b.ListRecentlyUpdated([]string{"a", "b"}, 1); len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("the limit was not applied: %+v", got)
+	}
+	if n := store.loads.Load(); n != 0 {
+		t.Fatalf("ListRecentlyUpdated read %d boards", n)
+	}
+}
+
+func TestEachPostLeavesTheCacheAlone(t *testing.T) {
+	store := &countingStore{PostFile: PostFile{Dir: t.TempDir()}}
+	seedBoards(t, store)
+	b := NewWithBoards(fixedNow, cacheBoards, nil, store, WithBoardCache(100))
+
+	var titles []string
+	for _, name := range cacheBoards {
+		if err := b.EachPost(name, func(p Post) { titles = append(titles, p.Title) }); err != nil {
+			t.Fatalf("EachPost: %v", err)
+		}
+	}
+	if len(titles) != 6 || titles[0] != "a-0" {
+		t.Fatalf("unexpected posts %v", titles)
+	}
+	if got := loadedBoards(b); len(got) != 0 {
+		t.Fatalf("EachPost loaded %v", got)
+	}
+}
+
+func TestBoardCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	store := &countingStore{PostFile: PostFile{Dir: t.TempDir()}}
+	seedBoards(t, store)
+
+	// Room for two boards of two posts.
+	b := NewWithBoards(fixedNow, cacheBoards, nil, store, WithBoardCache(4))
+	for _, name := range []string{"a", "b", "a", "c"} {
+		if _, err := b.ListPosts(name); err != nil {
+			t.Fatalf("ListPosts: %v", err)
+		}
+	}
+	if got := loadedBoards(b); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Fatalf("expected a and c loaded, got %v", got)
+	}
+
+	// An evicted board reads back in full, including later writes.
+	if _, err := b.AddComment("b", 2, "carol", "late", 0); err != nil {
+		t.Fatalf("AddComment: %v", err)
+	}
+	_, _ = b.ListPosts("a")
+	_, _ = b.ListPosts("c")
+	post, err := b.GetPost("b", 2)
+	if err != nil || len(post.Comments) != 1 || post.Comments[0].Content != "late" {
+		t.Fatalf("unexpected post after reload: %+v, %v", post, err)
+	}
+	if summaries := b.ListBoards(); summaries[1].PostCount != 2 {
+		t.Fatalf("unexpected summaries %+v", summaries)
+	}
+}
+
+func TestStoresWithoutIndexLoadAtStartup(t *testing.T) {
+	store := plainStore{f: PostFile{Dir: t.TempDir()}}
+	seedBoards(t, store)
+
+	b := NewWithBoards(fixedNow, cacheBoards, nil, store, WithBoardCache(2))
+	if got := loadedBoards(b); len(got) != 1 {
+		t.Fatalf("expected the cache to keep one board after startup, got %v", got)
+	}
+	for _, s := range b.ListBoards() {
+		if s.PostCount != 2 {
+			t.Fatalf("unexpected summary %+v", s)
+		}
+	}
+}
+
+func TestBoardCacheConcurrentAccess(t *testing.T) {
+	store := PostFile{Dir: t.TempDir()}
+	seedBoards(t, store)
+	b := NewWithBoards(fixedNow, cacheBoards, nil, store, WithBoardCache(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				name := cacheBoards[(i+j)%len(cacheBoards)]
+				posts, err := b.ListPosts(name)
+				if err != nil {
+					t.Errorf("ListPosts: %v", err)
+					return
+				}
+				if len(posts) < 2 {
+					t.Errorf("board %s read %d posts", name, len(posts))
+					return
+				}
+				if j%10 == 0 {
+					if _, err := b.AddPost(name, "alice", "more", ""); err != nil {
+						t.Errorf("AddPost: %v", err)
+						return
+					}
+				}
+				_ = b.ListBoardsFor("alice")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, s := range b.ListBoards() {
+		total += s.PostCount
+	}
+	if want := 3*2 + 8*5; total != want {
+		t.Fatalf("expected %d posts, got %d", want, total)
+	}
+}
@@ -2,63 +2,100 @@ package bbs
 
 // AddComment adds a comment to a post.
 func (b *BBS) AddComment(boardName string, postID int, author, content string, parentID int) (*Comment, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.users.seen(author)
+	mentions := b.mentions(content)
+	signature := b.signature(author)
 
-	board, ok := b.boards[boardName]
+	// The BBS lock is only needed to find the board.
+	board, ok := b.board(boardName)
 	if !ok {
 		return nil, ErrBoardNotFound
 	}
 
-	board.mu.Lock()
-	defer board.mu.Unlock()
-
-	// Find the post
-	var post *Post
-	for i := range board.posts {
-		if board.posts[i].ID == postID {
-			post = &board.posts[i]
-			break
-		}
-	}
-	if post == nil {
-		return nil, ErrPostNotFound
-	}
-
-	// Create comment
-	commentID := len(post.Comments) + 1
-	comment := Comment{
-		ID:        commentID,
+	comment, title, recipients, err := b.addComment(board, Comment{
 		PostID:    postID,
 		ParentID:  parentID,
 		Author:    author,
 		Content:   content,
-		CreatedAt: b.now(),
+		Mentions:  mentions,
+		Signature: signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Both save to disk, so they wait until the board is unlocked.
+	_ = b.MarkRead(author, boardName, postID, comment.ID)
+	b.notify(recipients, Notification{
+		Board: boardName, PostID: postID, CommentID: comment.ID,
+		Actor: author, Title: title, CreatedAt: comment.CreatedAt,
+	})
+	return &comment, nil
+}
+
+// addComment numbers, stamps and stores comment, returning it with the
+// post's title and the users to notify.
+func (b *BBS) addComment(board *Board, comment Comment) (_ Comment, title string, _ map[string]NotificationKind, err error) {
+	if err := b.lockBoard(board); err != nil {
+		return Comment{}, "", nil, err
 	}
+	var saved pendingSave
+	defer board.unlockAndWait(&saved, &err)
 
+	post := board.findPost(comment.PostID)
+	if post == nil {
+		return Comment{}, "", nil, ErrPostNotFound
+	}
+	if board.state == BoardArchived {
+		return Comment{}, "", nil, ErrBoardArchived
+	}
+	if post.Locked {
+		return Comment{}, "", nil, ErrPostLocked
+	}
+
+	comment.ID = len(post.Comments) + 1
+	comment.CreatedAt = b.now()
 	post.Comments = append(post.Comments, comment)
 
-	// Save to disk
-	if b.posts != nil {
-		if err := b.posts.Save(boardName, board.posts); err != nil {
-			return nil, err
+	// Work out who hears about it before the commenter starts following.
+	recipients := make(map[string]NotificationKind, len(post.Subscribers)+1)
+	for _, u := range post.Subscribers {
+		recipients[u] = NotifyComment
+	}
+	if post.SubscribedBy(post.Author) {
+		recipients[post.Author] = NotifyReply
+	}
+	if comment.ParentID > 0 {
+		for _, c := range post.Comments {
+			if c.ID == comment.ParentID {
+				recipients[c.Author] = NotifyReply
+				break
+			}
 		}
 	}
+	for _, u := range comment.Mentions {
+		recipients[u] = NotifyMention
+	}
+	if !post.SubscribedBy(comment.Author) {
+		post.Subscribers = toggleUser(post.Subscribers, comment.Author, true)
+	}
 
-	return &comment, nil
+	saved = b.persist(board)
+	b.events.publish(Event{
+		Kind: EventComment, Board: board.Name, PostID: post.ID, Actor: comment.Author,
+		At: comment.CreatedAt, Post: *post, Comment: comment,
+	})
+	return comment, post.Title, recipients, nil
 }
 
 // ListComments returns all comments for a post.
 func (b *BBS) ListComments(boardName string, postID int) ([]Comment, error) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-
-	board, ok := b.boards[boardName]
+	board, ok := b.board(boardName)
 	if !ok {
 		return nil, ErrBoardNotFound
 	}
 
-	board.mu.RLock()
+	b.rlockBoard(board)
 	defer board.mu.RUnlock()
 
 	for _, post := range board.posts {
@@ -0,0 +1,103 @@
+package bbs
+
+import (
+	"sync"
+	"time"
+)
+
+// eventBuffer is how many events a subscriber may fall behind before new
+// events are dropped for it.
+const eventBuffer = 64
+
+// EventKind names what happened.
+type EventKind string
+
+const (
+	EventPost    EventKind = "post"    // a post was added
+	EventComment EventKind = "comment" // a comment was added
+	EventEdit    EventKind = "edit"    // a post was edited
+	EventDelete  EventKind = "delete"  // a post was deleted
+	EventAttach  EventKind = "attach"  // a file was attached to a post
+)
+
+// Event describes a change to a board.
+type Event struct {
+	Kind    EventKind
+	Board   string
+	PostID  int
+	Actor   string
+	At      time.Time
+	Post    Post    // the post after the change; before it for EventDelete
+	Comment Comment // set for EventComment
+}
+
+// Bus fans events out to subscribers. Publishing never blocks: a subscriber
+// that is not keeping up misses events rather than stalling writers.
+// Listeners, in contrast, run synchronously and see every event.
+type Bus struct {
+	mu        sync.Mutex
+	subs      map[int]chan Event
+	next      int
+	listeners []func(Event)
+}
+
+// Listen calls fn for every future event, in order, before the change that
+// caused it returns to its caller. fn runs while board locks are held, so it
+// must be quick and must not call back into the BBS.
+func (bus *Bus) Listen(fn func(Event)) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.listeners = append(bus.listeners, fn)
+}
+
+// Subscribe returns a channel of future events and a function that ends the
+// subscription and closes the channel.
+func (bus *Bus) Subscribe() (<-chan Event, func()) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if bus.subs == nil {
+		bus.subs = make(map[int]chan Event)
+	}
+	id := bus.next
+	bus.next++
+	ch := make(chan Event, eventBuffer)
+	bus.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			bus.mu.Lock()
+			defer bus.mu.Unlock()
+			delete(bus.subs, id)
+			close(ch)
+		})
+	}
+}
+
+func (bus *Bus) publish(e Event) {
+	bus.mu.Lock()
+	listeners := bus.listeners
+	for _, ch := range bus.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	bus.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(e)
+	}
+}
+
+// Subscribe listens for events on every board.
+func (b *BBS) Subscribe() (<-chan Event, func()) {
+	return b.events.Subscribe()
+}
+
+// Listen calls fn synchronously for every event.
+// See Bus.Listen for the rules fn must follow.
+func (b *BBS) Listen(fn func(Event)) {
+	b.events.Listen(fn)
+}
@@ -0,0 +1,64 @@
+package bbs
+
+import "testing"
+
+func TestSubscribeReceivesEvents(t *testing.T) {
+	b := New(fixedNow)
+	events, stop := b.Subscribe()
+	defer stop()
+
+	post, _ := b.AddPost("general", "alice", "hello", "")
+	_, _ = b.AddComment("general", post.ID, "bob", "hi", 0)
+	_ = b.DeletePost("general", post.ID, "alice")
+
+	want := []EventKind{EventPost, EventComment, EventDelete}
+	for _, kind := range want {
+		e := <-events
+		if e.Kind != kind || e.Board != "general" || e.PostID != post.ID {
+			t.Fatalf("expected %s event for post %d, got %+v", kind, post.ID, e)
+		}
+		if kind == EventComment && (e.Comment.Author != "bob" || len(e.Post.Comments) != 1) {
+			t.Fatalf("comment event missing details: %+v", e)
+		}
+		if kind == EventDelete && e.Post.Title != "hello" {
+			t.Fatalf("delete event should carry the removed post: %+v", e)
+		}
+	}
+}
+
+func TestSlowSubscribersDoNotBlockWriters(t *testing.T) {
+	b := New(fixedNow)
+	events, stop := b.Subscribe()
+
+	for i := 0; i < eventBuffer*2; i++ {
+		if _, err := b.AddPost("general", "alice", "spam", ""); err != nil {
+			t.Fatalf("AddPost: %v", err)
+		}
+	}
+	if got := len(events); got != eventBuffer {
+		t.Fatalf("expected a full buffer of %d events, got %d", eventBuffer, got)
+	}
+
+	stop()
+	stop() // stopping twice is harmless
+	for range events {
+	}
+	if _, err := b.AddPost("general", "alice", "after", ""); err != nil {
+		t.Fatalf("AddPost after unsubscribe: %v", err)
+	}
+}
+
+func TestListenersSeeEveryEvent(t *testing.T) {
+	b := New(fixedNow)
+	var seen []EventKind
+	b.Listen(func(e Event) { seen = append(seen, e.Kind) })
+
+	for i := 0; i < eventBuffer*2; i++ {
+		_, _ = b.AddPost("general", "alice", "spam", "")
+	}
+	_ = b.DeletePost("general", 1, "alice")
+
+	if len(seen) != eventBuffer*2+1 || seen[len(seen)-1] != EventDelete {
+		t.Fatalf("listener missed events: got %d", len(seen))
+	}
+}
@@ -0,0 +1,144 @@
+package bbs
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// PostSort orders a post listing.
+type PostSort string
+
+const (
+	// SortOldest is board order: oldest post first.
+	SortOldest PostSort = ""
+	// SortNewest lists the newest post first.
+	SortNewest PostSort = "newest"
+	// SortActivity lists the most recently posted or commented thread first.
+	SortActivity PostSort = "activity"
+	// SortComments lists the most commented thread first.
+	SortComments PostSort = "comments"
+	// SortScore lists the highest voted post first.
+	SortScore PostSort = "score"
+)
+
+// PostSorts lists the sorts in the order the post list cycles through them.
+var PostSorts = []PostSort{SortOldest, SortNewest, SortActivity, SortComments, SortScore}
+
+// String returns a display label for the sort.
+func (s PostSort) String() string {
+	if s == SortOldest {
+		return "oldest"
+	}
+	return string(s)
+}
+
+// Valid reports whether s is a known sort.
+func (s PostSort) Valid() bool {
+	for _, known := range PostSorts {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ListPostsOptions sorts and filters a post listing. The zero value lists
+// every post in board order.
+type ListPostsOptions struct {
+	Sort        PostSort
+	Author      string    // only posts by this user (case-insensitive)
+	Since       time.Time // only posts created at or after Since
+	Until       time.Time // only posts created before Until
+	HasComments bool      // only posts with at least one comment
+	// Match, if set, must also accept the post; callers use it for search hits.
+	Match func(Post) bool
+}
+
+// Filtered reports whether any filter is set.
+func (o ListPostsOptions) Filtered() bool {
+	return o.Author != "" || !o.Since.IsZero() || !o.Until.IsZero() || o.HasComments || o.Match != nil
+}
+
+func (o ListPostsOptions) match(p Post) bool {
+	if o.Author != "" && !strings.EqualFold(p.Author, o.Author) {
+		return false
+	}
+	if !o.Since.IsZero() && p.CreatedAt.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && !p.CreatedAt.Before(o.Until) {
+		return false
+	}
+	if o.HasComments && len(p.Comments) == 0 {
+		return false
+	}
+	return o.Match == nil || o.Match(p)
+}
+
+// LastActivity returns when the post or its newest comment was written.
+func (p Post) LastActivity() time.Time {
+	last := p.CreatedAt
+	for _, c := range p.Comments {
+		if c.CreatedAt.After(last) {
+			last = c.CreatedAt
+		}
+	}
+	return last
+}
+
+// UpdatedAt returns when the post was last written: its edit time, or its
+// creation time if it was never edited. Comments do not count.
+func (p Post) UpdatedAt() time.Time {
+	if p.EditedAt.After(p.CreatedAt) {
+		return p.EditedAt
+	}
+	return p.CreatedAt
+}
+
+// sortKey is what posts are ordered by under a sort other than SortOldest,
+// largest first. Ties, and SortNewest itself, fall back to the newest ID.
+func sortKey(p Post, by PostSort) int64 {
+	switch by {
+	case SortActivity:
+		return p.LastActivity().UnixNano()
+	case SortComments:
+		return int64(len(p.Comments))
+	case SortScore:
+		return int64(p.Score())
+	}
+	return 0
+}
+
+// before reports whether the post with key ka and ID a lists before the
+// one with kb and b under a descending sort.
+func before(ka int64, a int, kb int64, b int) bool {
+	if ka != kb {
+		return ka > kb
+	}
+	return a > b
+}
+
+// ListRecentlyUpdated returns up to limit posts from the named boards,
+// most recently updated first (see Post.UpdatedAt), read from the board
+// indexes so no posts are loaded. Unknown boards are skipped. A limit of 0
+// returns them all.
+func (b *BBS) ListRecentlyUpdated(boards []string, limit int) []PostRef {
+	var out []PostRef
+	for _, name := range boards {
+		board, ok := b.board(name)
+		if !ok {
+			continue
+		}
+		board.mu.RLock()
+		out = append(out, board.summarize().posts...)
+		board.mu.RUnlock()
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return before(out[i].UpdatedAt.UnixNano(), out[i].ID, out[j].UpdatedAt.UnixNano(), out[j].ID)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
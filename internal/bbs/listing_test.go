@@ -0,0 +1,123 @@
+package bbs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func listingFixture(t *testing.T) (*BBS, time.Time) {
+	t.Helper()
+	start := fixedNow()
+	now := start
+	clock := func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}
+	b := New(clock)
+	for _, p := range []struct{ author, title string }{
+		{"alice", "first"},
+		{"bob", "second"},
+		{"alice", "third"},
+	} {
+		if _, err := b.AddPost("general", p.author, p.title, "body"); err != nil {
+			t.Fatalf("AddPost: %v", err)
+		}
+	}
+	// first gets the newest comment, second the most comments.
+	for _, c := range []int{2, 2, 1} {
+		if _, err := b.AddComment("general", c, "carol", "reply", 0); err != nil {
+			t.Fatalf("AddComment: %v", err)
+		}
+	}
+	if _, err := b.Vote("general", 3, 0, "bob", 1); err != nil {
+		t.Fatalf("Vote: %v", err)
+	}
+	return b, start
+}
+
+// listAll returns every post of the board matching opts, in listing order.
+func listAll(b *BBS, board string, opts ListPostsOptions) ([]PostHeader, error) {
+	page, err := b.ListPostPage(board, PageOptions{ListPostsOptions: opts, Limit: MaxPageSize})
+	return page.Posts, err
+}
+
+func titles(posts []PostHeader) []string {
+	out := make([]string, len(posts))
+	for i, p := range posts {
+		out[i] = p.Title
+	}
+	return out
+}
+
+func TestListingSorts(t *testing.T) {
+	b, _ := listingFixture(t)
+	cases := []struct {
+		sort PostSort
+		want string
+	}{
+		{SortOldest, "first second third"},
+		{SortNewest, "third second first"},
+		{SortActivity, "first second third"},
+		{SortComments, "second first third"},
+		{SortScore, "third second first"},
+	}
+	for _, tc := range cases {
+		posts, err := listAll(b, "general", ListPostsOptions{Sort: tc.sort})
+		if err != nil {
+			t.Fatalf("listAll(%s): %v", tc.sort, err)
+		}
+		if got := titles(posts); strings.Join(got, " ") != tc.want {
+			t.Errorf("sort %s: got %v want %s", tc.sort, got, tc.want)
+		}
+	}
+}
+
+func TestListingFilters(t *testing.T) {
+	b, start := listingFixture(t)
+
+	posts, _ := listAll(b, "general", ListPostsOptions{Author: "ALICE", Sort: SortNewest})
+	if got := strings.Join(titles(posts), " "); got != "third first" {
+		t.Fatalf("author filter: got %s", got)
+	}
+
+	posts, _ = listAll(b, "general", ListPostsOptions{HasComments: true})
+	if got := strings.Join(titles(posts), " "); got != "first second" {
+		t.Fatalf("has-comments filter: got %s", got)
+	}
+
+	// Posts were written at start+1h, +2h and +3h.
+	opts := ListPostsOptions{Since: start.Add(2 * time.Hour), Until: start.Add(3 * time.Hour)}
+	posts, _ = listAll(b, "general", opts)
+	if got := strings.Join(titles(posts), " "); got != "second" {
+		t.Fatalf("date filter: got %s", got)
+	}
+	if !opts.Filtered() || (ListPostsOptions{Sort: SortScore}).Filtered() {
+		t.Fatalf("Filtered reports wrong value")
+	}
+
+	if _, err := listAll(b, "missing", ListPostsOptions{}); err != ErrBoardNotFound {
+		t.Fatalf("expected ErrBoardNotFound, got %v", err)
+	}
+}
+
+func TestPostSortsCycleAndValidate(t *testing.T) {
+	if SortOldest.String() != "oldest" || SortActivity.String() != "activity" {
+		t.Fatalf("unexpected labels")
+	}
+	if !SortScore.Valid() || PostSort("random").Valid() {
+		t.Fatalf("Valid reports wrong value")
+	}
+}
+
+func TestUpdatedAtPrefersEditTime(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := Post{CreatedAt: created, Comments: []Comment{{CreatedAt: created.Add(time.Hour)}}}
+	if !p.UpdatedAt().Equal(created) {
+		t.Fatalf("expected the creation time, got %v", p.UpdatedAt())
+	}
+	p.EditedAt = created.Add(time.Minute)
+	if !p.UpdatedAt().Equal(p.EditedAt) {
+		t.Fatalf("expected the edit time, got %v", p.UpdatedAt())
+	}
+}
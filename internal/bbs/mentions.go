@@ -0,0 +1,106 @@
+package bbs
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// mentionPattern matches @name when the @ starts a word, so e-mail
+// addresses are left alone.
+var mentionPattern = regexp.MustCompile(`(^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_.-]+)`)
+
+// ParseMentions returns the distinct @usernames in content, in order of
+// first appearance, without the leading '@'.
+func ParseMentions(content string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimRight(m[2], ".-")
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+	return out
+}
+
+// ReplaceMentions rewrites every @name in content whose name is listed in
+// mentions with fn("@name"). Other text is returned unchanged.
+func ReplaceMentions(content string, mentions []string, fn func(string) string) string {
+	if len(mentions) == 0 {
+		return content
+	}
+	return mentionPattern.ReplaceAllStringFunc(content, func(match string) string {
+		at := strings.IndexByte(match, '@')
+		name := match[at+1:]
+		trimmed := strings.TrimRight(name, ".-")
+		if !containsUser(mentions, trimmed) {
+			return match
+		}
+		return match[:at] + fn("@"+trimmed) + name[len(trimmed):]
+	})
+}
+
+// WithUsers limits mentions to the given usernames, typically the users of
+// the auth config. Without it, anyone who has posted or commented counts.
+func WithUsers(names []string) Option {
+	return func(b *BBS) {
+		if len(names) == 0 {
+			return
+		}
+		b.users.fixed = true
+		for _, name := range names {
+			b.users.add(name)
+		}
+	}
+}
+
+// directory tracks the usernames that can be mentioned.
+type directory struct {
+	mu    sync.RWMutex
+	fixed bool // names come from configuration; authors are not added
+	names map[string]struct{}
+}
+
+func (d *directory) add(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.names == nil {
+		d.names = make(map[string]struct{})
+	}
+	d.names[name] = struct{}{}
+}
+
+// seen records an author unless the directory is fixed.
+func (d *directory) seen(name string) {
+	d.mu.RLock()
+	_, known := d.names[name]
+	fixed := d.fixed
+	d.mu.RUnlock()
+	if !fixed && !known {
+		d.add(name)
+	}
+}
+
+func (d *directory) known(name string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.names[name]
+	return ok
+}
+
+// mentions returns the known users mentioned in content.
+func (b *BBS) mentions(content string) []string {
+	var out []string
+	for _, name := range ParseMentions(content) {
+		if b.users.known(name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package bbs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	got := ParseMentions("hi @bob, @alice. mail bob@example.com or @bob again @민수")
+	want := []string{"bob", "alice", "민수"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseMentions = %v, want %v", got, want)
+	}
+}
+
+func TestReplaceMentions(t *testing.T) {
+	got := ReplaceMentions("@bob and @eve.", []string{"bob", "eve"}, strings.ToUpper)
+	if got != "@BOB and @EVE." {
+		t.Fatalf("unexpected replacement %q", got)
+	}
+}
+
+func TestMentionsNotifyKnownUsers(t *testing.T) {
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, nil, WithUsers([]string{"alice", "bob"}))
+	post, _ := b.AddPost("general", "alice", "hello", "ping @bob and @ghost")
+	if !reflect.DeepEqual(post.Mentions, []string{"bob"}) {
+		t.Fatalf("expected only known users stored, got %v", post.Mentions)
+	}
+	got := b.Notifications("bob")
+	if len(got) != 1 || got[0].Kind != NotifyMention || got[0].Actor != "alice" {
+		t.Fatalf("unexpected notifications for bob: %+v", got)
+	}
+
+	comment, err := b.AddComment("general", post.ID, "bob", "thanks @alice", 0)
+	if err != nil {
+		t.Fatalf("AddComment: %v", err)
+	}
+	if !reflect.DeepEqual(comment.Mentions, []string{"alice"}) {
+		t.Fatalf("unexpected comment mentions %v", comment.Mentions)
+	}
+	if n := b.Notifications("alice"); len(n) != 1 || n[0].Kind != NotifyMention {
+		t.Fatalf("expected mention to win over reply: %+v", n)
+	}
+}
+
+func TestMentionsFallBackToAuthors(t *testing.T) {
+	b := New(fixedNow)
+	_, _ = b.AddPost("general", "carol", "intro", "")
+	post, _ := b.AddPost("general", "dave", "hey", "@carol @stranger")
+	if !reflect.DeepEqual(post.Mentions, []string{"carol"}) {
+		t.Fatalf("expected previous authors to be mentionable, got %v", post.Mentions)
+	}
+}
@@ -0,0 +1,340 @@
+package bbs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrConversationNotFound signals an unknown conversation or one the user is not part of.
+	ErrConversationNotFound = errors.New("conversation not found")
+	// ErrNoRecipients signals a conversation without anyone to talk to.
+	ErrNoRecipients = errors.New("no recipients")
+	// ErrUnknownUser signals a recipient who is not a known user.
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrEmptyMessage signals a message without content.
+	ErrEmptyMessage = errors.New("message is empty")
+)
+
+// Message is one direct message in a conversation.
+type Message struct {
+	ID        int       `json:"id"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Conversation is a private thread between two or more users.
+// The same set of members always shares one conversation.
+type Conversation struct {
+	ID        string    `json:"id"`
+	Members   []string  `json:"members"` // sorted
+	CreatedAt time.Time `json:"created_at"`
+	Messages  []Message `json:"messages,omitempty"`
+}
+
+// ConversationSummary describes a conversation in a user's inbox.
+type ConversationSummary struct {
+	ID      string
+	Members []string
+	Last    Message
+	Unread  int
+}
+
+// With returns the members other than user.
+func (c Conversation) With(user string) []string {
+	return others(c.Members, user)
+}
+
+// With returns the members other than user.
+func (s ConversationSummary) With(user string) []string {
+	return others(s.Members, user)
+}
+
+func others(members []string, user string) []string {
+	out := make([]string, 0, len(members))
+	for _, m := range members {
+		if m != user {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// DMInbox lists a user's conversations with the last message ID they read.
+type DMInbox struct {
+	Conversations map[string]int `json:"conversations"`
+}
+
+// MessageStore persists conversations and per-user inboxes.
+type MessageStore interface {
+	LoadConversation(id string) (Conversation, error)
+	SaveConversation(c Conversation) error
+	LoadInbox(user string) (DMInbox, error)
+	SaveInbox(user string, inbox DMInbox) error
+}
+
+// MessageFile stores conversations in Dir/conversations/<id>.json and
+// inboxes in Dir/inbox/<user>.json, encrypted like PostFile when a key is set.
+type MessageFile struct {
+	Dir           string
+	EncryptionKey []byte // 32 bytes for AES-256
+}
+
+func (f MessageFile) files(kind string) userFile {
+	if f.Dir == "" {
+		return userFile{}
+	}
+	return userFile{Dir: filepath.Join(f.Dir, kind), EncryptionKey: f.EncryptionKey}
+}
+
+func (f MessageFile) LoadConversation(id string) (Conversation, error) {
+	var c Conversation
+	err := f.files("conversations").load(id, &c)
+	return c, err
+}
+
+func (f MessageFile) SaveConversation(c Conversation) error {
+	return f.files("conversations").save(c.ID, c)
+}
+
+func (f MessageFile) LoadInbox(user string) (DMInbox, error) {
+	var inbox DMInbox
+	err := f.files("inbox").load(user, &inbox)
+	return inbox, err
+}
+
+func (f MessageFile) SaveInbox(user string, inbox DMInbox) error {
+	return f.files("inbox").save(user, inbox)
+}
+
+// WithMessageStore persists direct messages through store.
+func WithMessageStore(store MessageStore) Option {
+	return func(b *BBS) {
+		b.dms.store = store
+	}
+}
+
+// mailroom caches conversations and inboxes. Everything is loaded lazily,
+// so only users who are online pay for their messages.
+type mailroom struct {
+	mu      sync.Mutex
+	store   MessageStore
+	convs   map[string]*Conversation
+	inboxes map[string]*DMInbox
+}
+
+// inbox returns the inbox for user. Callers must hold r.mu.
+func (r *mailroom) inbox(user string) *DMInbox {
+	if in, ok := r.inboxes[user]; ok {
+		return in
+	}
+	in := &DMInbox{}
+	if r.store != nil {
+		if loaded, err := r.store.LoadInbox(user); err == nil {
+			*in = loaded
+		}
+	}
+	if in.Conversations == nil {
+		in.Conversations = make(map[string]int)
+	}
+	if r.inboxes == nil {
+		r.inboxes = make(map[string]*DMInbox)
+	}
+	r.inboxes[user] = in
+	return in
+}
+
+// conversation returns a conversation by ID, or nil. Callers must hold r.mu.
+func (r *mailroom) conversation(id string) *Conversation {
+	if c, ok := r.convs[id]; ok {
+		return c
+	}
+	if r.store == nil {
+		return nil
+	}
+	loaded, err := r.store.LoadConversation(id)
+	if err != nil || loaded.ID != id {
+		return nil
+	}
+	if r.convs == nil {
+		r.convs = make(map[string]*Conversation)
+	}
+	r.convs[id] = &loaded
+	return &loaded
+}
+
+func (r *mailroom) saveInbox(user string) error {
+	if r.store == nil {
+		return nil
+	}
+	return r.store.SaveInbox(user, *r.inboxes[user])
+}
+
+// conversationID derives a stable ID from the sorted member list.
+func conversationID(members []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(members, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// StartConversation opens the conversation between from and the recipients,
+// creating it if these users have not talked before. It returns its ID.
+func (b *BBS) StartConversation(from string, to []string) (string, error) {
+	members := []string{from}
+	for _, name := range to {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+		if name == "" || containsUser(members, name) {
+			continue
+		}
+		if !b.canMessage(name) {
+			return "", ErrUnknownUser
+		}
+		members = append(members, name)
+	}
+	if len(members) < 2 {
+		return "", ErrNoRecipients
+	}
+	sort.Strings(members)
+	id := conversationID(members)
+
+	b.dms.mu.Lock()
+	defer b.dms.mu.Unlock()
+
+	if b.dms.conversation(id) == nil {
+		c := &Conversation{ID: id, Members: members, CreatedAt: b.now()}
+		if b.dms.store != nil {
+			if err := b.dms.store.SaveConversation(*c); err != nil {
+				return "", err
+			}
+		}
+		if b.dms.convs == nil {
+			b.dms.convs = make(map[string]*Conversation)
+		}
+		b.dms.convs[id] = c
+	}
+	for _, m := range members {
+		in := b.dms.inbox(m)
+		if _, ok := in.Conversations[id]; ok {
+			continue
+		}
+		in.Conversations[id] = 0
+		if err := b.dms.saveInbox(m); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// canMessage reports whether name may receive direct messages. With a
+// configured user list only those users can; otherwise anyone can.
+func (b *BBS) canMessage(name string) bool {
+	b.users.mu.RLock()
+	fixed := b.users.fixed
+	b.users.mu.RUnlock()
+	return !fixed || b.users.known(name)
+}
+
+// SendMessage appends a message from a member to a conversation.
+func (b *BBS) SendMessage(from, convID, content string) (Message, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return Message{}, ErrEmptyMessage
+	}
+
+	b.dms.mu.Lock()
+	defer b.dms.mu.Unlock()
+
+	c := b.dms.conversation(convID)
+	if c == nil || !containsUser(c.Members, from) {
+		return Message{}, ErrConversationNotFound
+	}
+	msg := Message{ID: len(c.Messages) + 1, Author: from, Content: content, CreatedAt: b.now()}
+	next := *c
+	next.Messages = append(append([]Message(nil), c.Messages...), msg)
+	if b.dms.store != nil {
+		if err := b.dms.store.SaveConversation(next); err != nil {
+			return Message{}, err
+		}
+	}
+	*c = next
+
+	// Senders have read their own message.
+	b.dms.inbox(from).Conversations[convID] = msg.ID
+	if err := b.dms.saveInbox(from); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+// Conversation returns a conversation if user is a member of it.
+func (b *BBS) Conversation(user, convID string) (Conversation, error) {
+	b.dms.mu.Lock()
+	defer b.dms.mu.Unlock()
+	c := b.dms.conversation(convID)
+	if c == nil || !containsUser(c.Members, user) {
+		return Conversation{}, ErrConversationNotFound
+	}
+	return *c, nil
+}
+
+// Conversations returns the inbox of user, most recent activity first.
+func (b *BBS) Conversations(user string) []ConversationSummary {
+	b.dms.mu.Lock()
+	defer b.dms.mu.Unlock()
+
+	in := b.dms.inbox(user)
+	out := make([]ConversationSummary, 0, len(in.Conversations))
+	for id, lastRead := range in.Conversations {
+		c := b.dms.conversation(id)
+		// Conversations show up once someone has written something.
+		if c == nil || len(c.Messages) == 0 {
+			continue
+		}
+		n := len(c.Messages)
+		out = append(out, ConversationSummary{
+			ID:      id,
+			Members: c.Members,
+			Last:    c.Messages[n-1],
+			Unread:  max(n-lastRead, 0),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Last.CreatedAt.Equal(out[j].Last.CreatedAt) {
+			return out[i].Last.CreatedAt.After(out[j].Last.CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// UnreadMessages counts unread direct messages across all conversations of user.
+func (b *BBS) UnreadMessages(user string) int {
+	total := 0
+	for _, s := range b.Conversations(user) {
+		total += s.Unread
+	}
+	return total
+}
+
+// MarkConversationRead records that user has read every message in a conversation.
+func (b *BBS) MarkConversationRead(user, convID string) error {
+	b.dms.mu.Lock()
+	defer b.dms.mu.Unlock()
+
+	c := b.dms.conversation(convID)
+	if c == nil || !containsUser(c.Members, user) {
+		return ErrConversationNotFound
+	}
+	in := b.dms.inbox(user)
+	if last, ok := in.Conversations[convID]; ok && last >= len(c.Messages) {
+		return nil
+	}
+	in.Conversations[convID] = len(c.Messages)
+	return b.dms.saveInbox(user)
+}
@@ -0,0 +1,96 @@
+package bbs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirectMessages(t *testing.T) {
+	b := New(fixedNow)
+
+	if _, err := b.StartConversation("alice", []string{"alice", " "}); !errors.Is(err, ErrNoRecipients) {
+		t.Fatalf("expected ErrNoRecipients, got %v", err)
+	}
+	id, err := b.StartConversation("alice", []string{"@bob"})
+	if err != nil {
+		t.Fatalf("StartConversation: %v", err)
+	}
+	if again, _ := b.StartConversation("bob", []string{"alice"}); again != id {
+		t.Fatalf("expected the same members to share a conversation")
+	}
+	if got := b.Conversations("bob"); len(got) != 0 {
+		t.Fatalf("empty conversations should stay hidden: %+v", got)
+	}
+
+	if _, err := b.SendMessage("alice", id, "  "); !errors.Is(err, ErrEmptyMessage) {
+		t.Fatalf("expected ErrEmptyMessage, got %v", err)
+	}
+	if _, err := b.SendMessage("mallory", id, "hi"); !errors.Is(err, ErrConversationNotFound) {
+		t.Fatalf("expected non-members to be rejected, got %v", err)
+	}
+	_, _ = b.SendMessage("alice", id, "hi bob")
+	_, _ = b.SendMessage("alice", id, "you there?")
+
+	if got := b.UnreadMessages("bob"); got != 2 {
+		t.Fatalf("expected 2 unread for bob, got %d", got)
+	}
+	if got := b.UnreadMessages("alice"); got != 0 {
+		t.Fatalf("senders should have read their messages, got %d", got)
+	}
+	inbox := b.Conversations("bob")
+	if len(inbox) != 1 || inbox[0].Last.Content != "you there?" || inbox[0].With("bob")[0] != "alice" {
+		t.Fatalf("unexpected inbox: %+v", inbox)
+	}
+
+	if err := b.MarkConversationRead("bob", id); err != nil {
+		t.Fatalf("MarkConversationRead: %v", err)
+	}
+	if got := b.UnreadMessages("bob"); got != 0 {
+		t.Fatalf("expected no unread after reading, got %d", got)
+	}
+	if _, err := b.Conversation("mallory", id); !errors.Is(err, ErrConversationNotFound) {
+		t.Fatalf("expected non-members to be rejected, got %v", err)
+	}
+}
+
+func TestDirectMessagesRequireKnownUsers(t *testing.T) {
+	b := NewWithBoards(fixedNow, nil, nil, nil, WithUsers([]string{"alice", "bob", "carol"}))
+	if _, err := b.StartConversation("alice", []string{"bob", "ghost"}); !errors.Is(err, ErrUnknownUser) {
+		t.Fatalf("expected ErrUnknownUser, got %v", err)
+	}
+	id, err := b.StartConversation("alice", []string{"bob", "carol"})
+	if err != nil {
+		t.Fatalf("group conversation: %v", err)
+	}
+	_, _ = b.SendMessage("carol", id, "hello both")
+	if b.UnreadMessages("alice") != 1 || b.UnreadMessages("bob") != 1 {
+		t.Fatalf("expected every member to see the message")
+	}
+}
+
+func TestMessageFileEncryptsConversations(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	store := MessageFile{Dir: dir, EncryptionKey: key}
+	b := NewWithBoards(fixedNow, nil, nil, nil, WithMessageStore(store))
+
+	id, _ := b.StartConversation("alice", []string{"bob"})
+	_, _ = b.SendMessage("alice", id, "top secret")
+
+	raw, err := os.ReadFile(filepath.Join(dir, "conversations", id+".json"))
+	if err != nil {
+		t.Fatalf("read conversation: %v", err)
+	}
+	if strings.Contains(string(raw), "top secret") {
+		t.Fatalf("conversation stored in plain text")
+	}
+
+	reloaded := NewWithBoards(fixedNow, nil, nil, nil, WithMessageStore(store))
+	inbox := reloaded.Conversations("bob")
+	if len(inbox) != 1 || inbox[0].Unread != 1 || inbox[0].Last.Content != "top secret" {
+		t.Fatalf("unexpected reloaded inbox: %+v", inbox)
+	}
+}
@@ -0,0 +1,179 @@
+package bbs
+
+// Role grants permissions beyond posting.
+type Role string
+
+const (
+	RoleUser      Role = ""
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// CanModerate reports whether the role may lock threads and freeze boards.
+func (r Role) CanModerate() bool {
+	return r == RoleModerator || r == RoleAdmin
+}
+
+// BoardState controls which writes a board accepts.
+type BoardState string
+
+const (
+	// BoardOpen accepts posts and comments.
+	BoardOpen BoardState = ""
+	// BoardReadOnly rejects new posts; existing threads stay open.
+	BoardReadOnly BoardState = "read-only"
+	// BoardArchived rejects new posts and comments.
+	BoardArchived BoardState = "archived"
+)
+
+// BoardStates lists the states in the order moderators cycle through them.
+var BoardStates = []BoardState{BoardOpen, BoardReadOnly, BoardArchived}
+
+// String returns a display label for the state.
+func (s BoardState) String() string {
+	if s == BoardOpen {
+		return "open"
+	}
+	return string(s)
+}
+
+// Valid reports whether s is a known board state.
+func (s BoardState) Valid() bool {
+	for _, known := range BoardStates {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRoles assigns roles by username.
+func WithRoles(roles map[string]Role) Option {
+	return func(b *BBS) {
+		b.roles = make(map[string]Role, len(roles))
+		for user, role := range roles {
+			b.roles[user] = role
+		}
+	}
+}
+
+// Role returns the role of a user.
+func (b *BBS) Role(username string) Role {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.roles[username]
+}
+
+// SetPostLocked locks or unlocks a thread. Only moderators may do this.
+func (b *BBS) SetPostLocked(boardName string, postID int, locked bool, actor string) (err error) {
+	if !b.Role(actor).CanModerate() {
+		return ErrForbidden
+	}
+	board, ok := b.board(boardName)
+	if !ok {
+		return ErrBoardNotFound
+	}
+
+	if err := b.lockBoard(board); err != nil {
+		return err
+	}
+	var saved pendingSave
+	defer board.unlockAndWait(&saved, &err)
+
+	for i := range board.posts {
+		if board.posts[i].ID != postID {
+			continue
+		}
+		board.posts[i].Locked = locked
+		saved = b.persist(board)
+		return nil
+	}
+	return ErrPostNotFound
+}
+
+// SetBoardState changes whether a board accepts writes. Only moderators may do this.
+func (b *BBS) SetBoardState(boardName string, state BoardState, actor string) error {
+	if !state.Valid() {
+		return ErrInvalidBoardState
+	}
+	if !b.Role(actor).CanModerate() {
+		return ErrForbidden
+	}
+
+	return b.updateBoardMeta(boardName, func(board *Board) { board.state = state })
+}
+
+// SetBoardPrivate hides a board from everyone but SSH users, such as
+// readers of the web mirror. API tokens act as the SSH user who issued
+// them, so the API still serves the board. Only moderators may do this.
+func (b *BBS) SetBoardPrivate(boardName string, private bool, actor string) error {
+	if !b.Role(actor).CanModerate() {
+		return ErrForbidden
+	}
+	return b.updateBoardMeta(boardName, func(board *Board) { board.private = private })
+}
+
+// updateBoardMeta applies change to a board under its lock and saves the
+// settings of every board.
+func (b *BBS) updateBoardMeta(boardName string, change func(*Board)) error {
+	board, ok := b.board(boardName)
+	if !ok {
+		return ErrBoardNotFound
+	}
+	board.mu.Lock()
+	change(board)
+	board.mu.Unlock()
+	return b.saveMeta()
+}
+
+// saveMeta writes the settings of every board, like saveBoards without
+// holding b.mu while the store is written.
+func (b *BBS) saveMeta() error {
+	ms, ok := b.store.(BoardMetaStore)
+	if !ok {
+		return nil
+	}
+	b.saveMu.Lock()
+	defer b.saveMu.Unlock()
+	b.mu.RLock()
+	meta := b.boardMeta()
+	b.mu.RUnlock()
+	return ms.SaveMeta(meta)
+}
+
+// boardMeta collects settings for all boards. Callers must hold b.mu.
+func (b *BBS) boardMeta() map[string]BoardMeta {
+	meta := make(map[string]BoardMeta)
+	for _, name := range b.order {
+		board := b.boards[name]
+		board.mu.RLock()
+		m := BoardMeta{State: board.state, Subscribers: board.subscribers, Private: board.private}
+		board.mu.RUnlock()
+		if m.State != BoardOpen || len(m.Subscribers) > 0 || m.Private {
+			meta[name] = m
+		}
+	}
+	return meta
+}
+
+func (b *BBS) loadMeta() {
+	ms, ok := b.store.(BoardMetaStore)
+	if !ok {
+		return
+	}
+	meta, err := ms.LoadMeta()
+	if err != nil {
+		return
+	}
+	for name, m := range meta {
+		board, ok := b.boards[name]
+		if !ok {
+			continue
+		}
+		if m.State.Valid() {
+			board.state = m.State
+		}
+		board.subscribers = m.Subscribers
+		board.private = m.Private
+	}
+}
@@ -0,0 +1,115 @@
+package bbs
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockedPostRejectsComments(t *testing.T) {
+	postStore := &memoryPostStore{data: map[string][]Post{}}
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, postStore, WithRoles(map[string]Role{"mod": RoleModerator}))
+	post, _ := b.AddPost("general", "alice", "topic", "body")
+
+	if err := b.SetPostLocked("general", post.ID, true, "alice"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden for regular user, got %v", err)
+	}
+	if err := b.SetPostLocked("general", post.ID, true, "mod"); err != nil {
+		t.Fatalf("SetPostLocked: %v", err)
+	}
+	if !postStore.data["general"][0].Locked {
+		t.Fatalf("expected lock to be persisted")
+	}
+	if _, err := b.AddComment("general", post.ID, "bob", "late", 0); !errors.Is(err, ErrPostLocked) {
+		t.Fatalf("expected ErrPostLocked, got %v", err)
+	}
+
+	if err := b.SetPostLocked("general", post.ID, false, "mod"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if _, err := b.AddComment("general", post.ID, "bob", "now ok", 0); err != nil {
+		t.Fatalf("AddComment after unlock: %v", err)
+	}
+}
+
+func TestBoardStates(t *testing.T) {
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, nil, WithRoles(map[string]Role{"root": RoleAdmin}))
+	post, _ := b.AddPost("general", "alice", "topic", "body")
+
+	if err := b.SetBoardState("general", BoardReadOnly, "alice"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if err := b.SetBoardState("general", BoardState("bogus"), "root"); !errors.Is(err, ErrInvalidBoardState) {
+		t.Fatalf("expected ErrInvalidBoardState, got %v", err)
+	}
+
+	if err := b.SetBoardState("general", BoardReadOnly, "root"); err != nil {
+		t.Fatalf("SetBoardState: %v", err)
+	}
+	if _, err := b.AddPost("general", "alice", "new", "x"); !errors.Is(err, ErrBoardReadOnly) {
+		t.Fatalf("expected ErrBoardReadOnly, got %v", err)
+	}
+	if _, err := b.AddComment("general", post.ID, "bob", "still open", 0); err != nil {
+		t.Fatalf("comments should be allowed on read-only boards: %v", err)
+	}
+
+	if err := b.SetBoardState("general", BoardArchived, "root"); err != nil {
+		t.Fatalf("SetBoardState: %v", err)
+	}
+	if _, err := b.AddComment("general", post.ID, "bob", "frozen", 0); !errors.Is(err, ErrBoardArchived) {
+		t.Fatalf("expected ErrBoardArchived, got %v", err)
+	}
+	if got := b.ListBoards()[0].State; got != BoardArchived {
+		t.Fatalf("expected archived summary, got %q", got)
+	}
+}
+
+func TestBoardStatePersistsThroughBoardFile(t *testing.T) {
+	file := BoardFile{Path: filepath.Join(t.TempDir(), "boards.json")}
+	roles := WithRoles(map[string]Role{"mod": RoleModerator})
+
+	b := NewWithBoards(fixedNow, []string{"general", "old"}, file, nil, roles)
+	if err := b.SetBoardState("old", BoardArchived, "mod"); err != nil {
+		t.Fatalf("SetBoardState: %v", err)
+	}
+	// Creating a board rewrites the list and must keep the settings.
+	if _, err := b.AddPost("new", "alice", "hi", ""); err != nil {
+		t.Fatalf("AddPost: %v", err)
+	}
+
+	names, err := file.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	reloaded := NewWithBoards(fixedNow, names, file, nil)
+	for _, s := range reloaded.ListBoards() {
+		want := BoardOpen
+		if s.Name == "old" {
+			want = BoardArchived
+		}
+		if s.State != want {
+			t.Fatalf("board %s: expected state %q, got %q", s.Name, want, s.State)
+		}
+	}
+}
+
+func TestBoardPrivatePersistsThroughBoardFile(t *testing.T) {
+	file := BoardFile{Path: filepath.Join(t.TempDir(), "boards.json")}
+	b := NewWithBoards(fixedNow, []string{"general", "staff"}, file, nil, WithRoles(map[string]Role{"mod": RoleModerator}))
+
+	if err := b.SetBoardPrivate("staff", true, "alice"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if err := b.SetBoardPrivate("staff", true, "mod"); err != nil {
+		t.Fatalf("SetBoardPrivate: %v", err)
+	}
+	if err := b.SetBoardState("staff", BoardReadOnly, "mod"); err != nil {
+		t.Fatalf("SetBoardState: %v", err)
+	}
+
+	reloaded := NewWithBoards(fixedNow, []string{"general", "staff"}, file, nil)
+	got := reloaded.ListBoards()
+	if got[0].Private || !got[1].Private || got[1].State != BoardReadOnly {
+		t.Fatalf("unexpected summaries %+v", got)
+	}
+}
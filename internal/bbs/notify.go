@@ -0,0 +1,263 @@
+package bbs
+
+import (
+	"sync"
+	"time"
+)
+
+// maxNotifications caps each inbox; the oldest entries are dropped first.
+const maxNotifications = 200
+
+// NotificationKind says why a notification was sent.
+type NotificationKind string
+
+const (
+	NotifyPost    NotificationKind = "post"    // new post on a followed board
+	NotifyComment NotificationKind = "comment" // new comment in a followed thread
+	NotifyReply   NotificationKind = "reply"   // comment on the user's post or reply to their comment
+	NotifyMention NotificationKind = "mention" // @mention in a post or comment
+)
+
+// Notification is one entry in a user's inbox.
+type Notification struct {
+	ID        int              `json:"id"`
+	Kind      NotificationKind `json:"kind"`
+	Board     string           `json:"board"`
+	PostID    int              `json:"post_id"`
+	CommentID int              `json:"comment_id,omitempty"`
+	Actor     string           `json:"actor"`
+	Title     string           `json:"title"` // post title at the time of the event
+	CreatedAt time.Time        `json:"created_at"`
+	Read      bool             `json:"read,omitempty"`
+}
+
+// NotificationStore persists notification inboxes per user.
+type NotificationStore interface {
+	Load(user string) ([]Notification, error)
+	Save(user string, notifications []Notification) error
+}
+
+// NotificationFile stores each inbox as one JSON file per user in Dir.
+// File format: {"notifications":[{"id":2,"kind":"reply",...}]}, newest first.
+type NotificationFile struct {
+	Dir           string
+	EncryptionKey []byte // 32 bytes for AES-256
+}
+
+type notificationFileData struct {
+	Notifications []Notification `json:"notifications"`
+}
+
+func (f NotificationFile) Load(user string) ([]Notification, error) {
+	var wrapper notificationFileData
+	err := userFile{Dir: f.Dir, EncryptionKey: f.EncryptionKey}.load(user, &wrapper)
+	return wrapper.Notifications, err
+}
+
+func (f NotificationFile) Save(user string, notifications []Notification) error {
+	return userFile{Dir: f.Dir, EncryptionKey: f.EncryptionKey}.save(user, notificationFileData{Notifications: notifications})
+}
+
+// WithNotificationStore persists notification inboxes through store.
+func WithNotificationStore(store NotificationStore) Option {
+	return func(b *BBS) {
+		b.inbox.store = store
+	}
+}
+
+// notifier caches inboxes per user, newest first.
+type notifier struct {
+	mu      sync.Mutex
+	store   NotificationStore
+	inboxes map[string][]Notification
+}
+
+// get returns the inbox for user, loading it on first use.
+// Callers must hold n.mu.
+func (n *notifier) get(user string) []Notification {
+	if list, ok := n.inboxes[user]; ok {
+		return list
+	}
+	var list []Notification
+	if n.store != nil {
+		if loaded, err := n.store.Load(user); err == nil {
+			list = loaded
+		}
+	}
+	if n.inboxes == nil {
+		n.inboxes = make(map[string][]Notification)
+	}
+	n.inboxes[user] = list
+	return list
+}
+
+// set replaces and persists the inbox for user. Callers must hold n.mu.
+func (n *notifier) set(user string, list []Notification) error {
+	n.inboxes[user] = list
+	if n.store == nil {
+		return nil
+	}
+	return n.store.Save(user, list)
+}
+
+// deliver prepends note to the inbox of user.
+func (n *notifier) deliver(user string, note Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	list := n.get(user)
+	note.ID = 1
+	if len(list) > 0 {
+		note.ID = list[0].ID + 1
+	}
+	next := make([]Notification, 0, len(list)+1)
+	next = append(next, note)
+	next = append(next, list...)
+	if len(next) > maxNotifications {
+		next = next[:maxNotifications]
+	}
+	return n.set(user, next)
+}
+
+// notify delivers note to each recipient. Delivery failures only lose the
+// notification, so they never fail the write that caused it.
+func (b *BBS) notify(recipients map[string]NotificationKind, note Notification) {
+	for user, kind := range recipients {
+		if user == note.Actor {
+			continue
+		}
+		note.Kind = kind
+		_ = b.inbox.deliver(user, note)
+	}
+}
+
+// Notifications returns the inbox of user, newest first.
+func (b *BBS) Notifications(user string) []Notification {
+	b.inbox.mu.Lock()
+	defer b.inbox.mu.Unlock()
+	return append([]Notification(nil), b.inbox.get(user)...)
+}
+
+// UnreadNotifications counts the unread entries in the inbox of user.
+func (b *BBS) UnreadNotifications(user string) int {
+	b.inbox.mu.Lock()
+	defer b.inbox.mu.Unlock()
+	count := 0
+	for _, n := range b.inbox.get(user) {
+		if !n.Read {
+			count++
+		}
+	}
+	return count
+}
+
+// MarkNotificationRead marks one notification read; id 0 marks all of them.
+func (b *BBS) MarkNotificationRead(user string, id int) error {
+	b.inbox.mu.Lock()
+	defer b.inbox.mu.Unlock()
+
+	list := b.inbox.get(user)
+	next := make([]Notification, len(list))
+	changed := false
+	for i, n := range list {
+		if !n.Read && (id == 0 || n.ID == id) {
+			n.Read = true
+			changed = true
+		}
+		next[i] = n
+	}
+	if !changed {
+		return nil
+	}
+	return b.inbox.set(user, next)
+}
+
+// SubscribedBy reports whether user follows the thread.
+func (p Post) SubscribedBy(user string) bool {
+	return containsUser(p.Subscribers, user)
+}
+
+// SetThreadSubscription makes user follow or stop following a thread.
+// Authors and commenters follow threads automatically.
+func (b *BBS) SetThreadSubscription(user, boardName string, postID int, on bool) (err error) {
+	board, ok := b.board(boardName)
+	if !ok {
+		return ErrBoardNotFound
+	}
+	if err := b.lockBoard(board); err != nil {
+		return err
+	}
+	var saved pendingSave
+	defer board.unlockAndWait(&saved, &err)
+
+	for i := range board.posts {
+		post := &board.posts[i]
+		if post.ID != postID {
+			continue
+		}
+		if containsUser(post.Subscribers, user) == on {
+			return nil
+		}
+		post.Subscribers = toggleUser(post.Subscribers, user, on)
+		saved = b.persist(board)
+		return nil
+	}
+	return ErrPostNotFound
+}
+
+// BoardSubscribed reports whether user follows new posts on a board.
+func (b *BBS) BoardSubscribed(user, boardName string) bool {
+	board, ok := b.board(boardName)
+	if !ok {
+		return false
+	}
+	board.mu.RLock()
+	defer board.mu.RUnlock()
+	return containsUser(board.subscribers, user)
+}
+
+// SetBoardSubscription makes user follow or stop following new posts on a board.
+func (b *BBS) SetBoardSubscription(user, boardName string, on bool) error {
+	board, ok := b.board(boardName)
+	if !ok {
+		return ErrBoardNotFound
+	}
+	board.mu.Lock()
+	changed := containsUser(board.subscribers, user) != on
+	if changed {
+		board.subscribers = toggleUser(board.subscribers, user, on)
+	}
+	board.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	return b.saveMeta()
+}
+
+func containsUser(users []string, user string) bool {
+	for _, u := range users {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleUser returns a copy of users with user added or removed, so that
+// earlier snapshots sharing the slice are left untouched.
+func toggleUser(users []string, user string, on bool) []string {
+	out := make([]string, 0, len(users)+1)
+	for _, u := range users {
+		if u != user {
+			out = append(out, u)
+		}
+	}
+	if on {
+		out = append(out, user)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
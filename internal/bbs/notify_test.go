@@ -0,0 +1,131 @@
+package bbs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stalledInbox holds every save until release is closed.
+type stalledInbox struct {
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (s stalledInbox) Load(string) ([]Notification, error) { return nil, nil }
+
+func (s stalledInbox) Save(string, []Notification) error {
+	s.saving <- struct{}{}
+	<-s.release
+	return nil
+}
+
+// stalledMeta holds every settings save until release is closed.
+type stalledMeta struct{ stalledInbox }
+
+func (s stalledMeta) Save([]string) error                     { return nil }
+func (s stalledMeta) LoadMeta() (map[string]BoardMeta, error) { return nil, nil }
+
+func (s stalledMeta) SaveMeta(map[string]BoardMeta) error {
+	return s.stalledInbox.Save("", nil)
+}
+
+func TestCommentsNotifyThreadSubscribers(t *testing.T) {
+	b := New(fixedNow)
+	post, _ := b.AddPost("general", "alice", "topic", "body")
+
+	first, err := b.AddComment("general", post.ID, "bob", "hi", 0)
+	if err != nil {
+		t.Fatalf("AddComment: %v", err)
+	}
+	if _, err := b.AddComment("general", post.ID, "carol", "re bob", first.ID); err != nil {
+		t.Fatalf("AddComment: %v", err)
+	}
+
+	alice := b.Notifications("alice")
+	if len(alice) != 2 || alice[0].Kind != NotifyReply || alice[0].Actor != "carol" || alice[1].Actor != "bob" {
+		t.Fatalf("unexpected notifications for author: %+v", alice)
+	}
+	bob := b.Notifications("bob")
+	if len(bob) != 1 || bob[0].Kind != NotifyReply || bob[0].CommentID != 2 || bob[0].Title != "topic" {
+		t.Fatalf("expected reply notification for bob: %+v", bob)
+	}
+	if got := b.Notifications("carol"); len(got) != 0 {
+		t.Fatalf("actors must not notify themselves: %+v", got)
+	}
+
+	// Unsubscribed users hear nothing new.
+	if err := b.SetThreadSubscription("alice", "general", post.ID, false); err != nil {
+		t.Fatalf("SetThreadSubscription: %v", err)
+	}
+	_, _ = b.AddComment("general", post.ID, "dave", "late", 0)
+	if got := b.UnreadNotifications("alice"); got != 2 {
+		t.Fatalf("expected 2 unread for alice, got %d", got)
+	}
+	if got := b.Notifications("carol"); len(got) != 1 || got[0].Kind != NotifyComment {
+		t.Fatalf("expected comment notification for carol: %+v", got)
+	}
+
+	if err := b.MarkNotificationRead("alice", alice[0].ID); err != nil {
+		t.Fatalf("MarkNotificationRead: %v", err)
+	}
+	if got := b.UnreadNotifications("alice"); got != 1 {
+		t.Fatalf("expected 1 unread, got %d", got)
+	}
+	_ = b.MarkNotificationRead("alice", 0)
+	if got := b.UnreadNotifications("alice"); got != 0 {
+		t.Fatalf("expected all read, got %d", got)
+	}
+}
+
+func TestBoardSubscriptionNotifiesNewPosts(t *testing.T) {
+	dir := t.TempDir()
+	file := BoardFile{Path: filepath.Join(dir, "boards.json")}
+	inbox := NotificationFile{Dir: filepath.Join(dir, "notifications")}
+	b := NewWithBoards(fixedNow, []string{"general"}, file, nil, WithNotificationStore(inbox))
+
+	if err := b.SetBoardSubscription("bob", "general", true); err != nil {
+		t.Fatalf("SetBoardSubscription: %v", err)
+	}
+	if !b.ListBoardsFor("bob")[0].Subscribed {
+		t.Fatalf("expected summary to show subscription")
+	}
+	_, _ = b.AddPost("general", "alice", "news", "")
+	_, _ = b.AddPost("general", "bob", "own post", "")
+
+	persisted, err := inbox.Load("bob")
+	if err != nil || len(persisted) != 1 || persisted[0].Kind != NotifyPost || persisted[0].Title != "news" {
+		t.Fatalf("unexpected persisted inbox %+v err %v", persisted, err)
+	}
+
+	reloaded := NewWithBoards(fixedNow, []string{"general"}, file, nil)
+	if !reloaded.BoardSubscribed("bob", "general") {
+		t.Fatalf("expected board subscription to persist")
+	}
+}
+
+func TestNotificationsSaveOffTheBoardLock(t *testing.T) {
+	stall := stalledInbox{saving: make(chan struct{}), release: make(chan struct{})}
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, nil, WithNotificationStore(stall))
+	post, _ := b.AddPost("general", "alice", "topic", "")
+
+	go func() { _, _ = b.AddComment("general", post.ID, "bob", "hi", 0) }()
+	<-stall.saving
+	within(t, "reading the board", time.Second, func() { _, _ = b.GetPost("general", post.ID) })
+	within(t, "following the thread", time.Second, func() { _ = b.SetThreadSubscription("carol", "general", post.ID, true) })
+	close(stall.release)
+}
+
+func TestBoardSettingsSaveOffTheBBSLock(t *testing.T) {
+	stall := stalledMeta{stalledInbox{saving: make(chan struct{}), release: make(chan struct{})}}
+	b := NewWithBoards(fixedNow, []string{"general"}, stall, nil)
+
+	go func() { _ = b.SetBoardSubscription("alice", "general", true) }()
+	<-stall.saving
+	within(t, "listing boards", time.Second, func() { _ = b.ListBoards() })
+	within(t, "posting", time.Second, func() { _, _ = b.AddPost("general", "bob", "topic", "") })
+	close(stall.release)
+	if !b.BoardSubscribed("alice", "general") {
+		t.Fatal("the subscription was not applied")
+	}
+}
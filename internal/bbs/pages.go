@@ -0,0 +1,202 @@
+package bbs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when a page request has no limit.
+	DefaultPageSize = 50
+	// MaxPageSize caps a single page.
+	MaxPageSize = 500
+)
+
+// ErrBadCursor signals a cursor that is malformed or belongs to another sort.
+var ErrBadCursor = errors.New("invalid page cursor")
+
+// PostHeader is what a post list needs to show a post: no body, no comments.
+type PostHeader struct {
+	ID            int
+	Title         string
+	Author        string
+	CreatedAt     time.Time
+	LastActivity  time.Time
+	Comments      int
+	LastCommentID int
+	Score         int
+	Reactions     int
+	Locked        bool
+	Tags          []string
+}
+
+// Header returns the listing header for p.
+func (p Post) Header() PostHeader {
+	return PostHeader{
+		ID:            p.ID,
+		Title:         p.Title,
+		Author:        p.Author,
+		CreatedAt:     p.CreatedAt,
+		LastActivity:  p.LastActivity(),
+		Comments:      len(p.Comments),
+		LastCommentID: lastCommentID(p),
+		Score:         p.Score(),
+		Reactions:     p.ReactionCount(),
+		Locked:        p.Locked,
+		Tags:          append([]string(nil), p.Tags...),
+	}
+}
+
+// PageOptions asks for one page of a sorted, filtered listing. After is the
+// Next cursor of the previous page, or empty for the first page.
+type PageOptions struct {
+	ListPostsOptions
+	After string
+	Limit int
+}
+
+// PostPage is one page of a listing.
+type PostPage struct {
+	Posts []PostHeader
+	Next  string // cursor for the following page; empty on the last page
+	Total int    // posts matching the filters across all pages
+}
+
+// cursor marks the last post of a page: its sort key and ID. Cursors stay
+// valid when posts are added or deleted, since they do not store positions.
+type cursor struct {
+	sort PostSort
+	key  int64
+	id   int
+}
+
+func (c cursor) String() string {
+	return fmt.Sprintf("%s:%d:%d", string(c.sort), c.key, c.id)
+}
+
+func parseCursor(s string, by PostSort) (cursor, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || PostSort(parts[0]) != by {
+		return cursor{}, ErrBadCursor
+	}
+	key, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return cursor{}, ErrBadCursor
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return cursor{}, ErrBadCursor
+	}
+	return cursor{sort: by, key: key, id: id}, nil
+}
+
+// ListPostPage returns one page of the board's post headers. Board order and
+// newest first walk the board from the cursor and stop once the page is full;
+// the other sorts order lightweight keys, never whole posts.
+func (b *BBS) ListPostPage(boardName string, opts PageOptions) (PostPage, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	start := cursor{sort: opts.Sort}
+	if opts.After != "" {
+		c, err := parseCursor(opts.After, opts.Sort)
+		if err != nil {
+			return PostPage{}, err
+		}
+		start = c
+	}
+
+	board, ok := b.board(boardName)
+	if !ok {
+		return PostPage{}, ErrBoardNotFound
+	}
+	b.rlockBoard(board)
+	defer board.mu.RUnlock()
+
+	switch opts.Sort {
+	case SortOldest, SortNewest:
+		return pageByID(board.posts, opts.ListPostsOptions, start, opts.After != "", limit), nil
+	default:
+		return pageByKey(board.posts, opts.ListPostsOptions, start, opts.After != "", limit), nil
+	}
+}
+
+// pageByID pages through posts, which are in ID order, forwards for
+// SortOldest and backwards for SortNewest.
+func pageByID(posts []Post, opts ListPostsOptions, after cursor, resume bool, limit int) PostPage {
+	var page PostPage
+	if opts.Filtered() {
+		for _, p := range posts {
+			if opts.match(p) {
+				page.Total++
+			}
+		}
+	} else {
+		page.Total = len(posts)
+	}
+
+	i, step := 0, 1
+	if opts.Sort == SortNewest {
+		i, step = len(posts)-1, -1
+	}
+	if resume {
+		// First post strictly after the cursor in listing order.
+		i = sort.Search(len(posts), func(j int) bool { return posts[j].ID > after.id })
+		if opts.Sort == SortNewest {
+			i = sort.Search(len(posts), func(j int) bool { return posts[j].ID >= after.id }) - 1
+		}
+	}
+	for ; i >= 0 && i < len(posts); i += step {
+		p := posts[i]
+		if !opts.match(p) {
+			continue
+		}
+		if len(page.Posts) == limit {
+			page.Next = cursor{sort: opts.Sort, id: page.Posts[limit-1].ID}.String()
+			break
+		}
+		page.Posts = append(page.Posts, p.Header())
+	}
+	return page
+}
+
+// pageByKey sorts the matching posts' keys and slices out one page.
+func pageByKey(posts []Post, opts ListPostsOptions, after cursor, resume bool, limit int) PostPage {
+	type ref struct {
+		key int64
+		idx int
+	}
+	refs := make([]ref, 0, len(posts))
+	for i, p := range posts {
+		if opts.match(p) {
+			refs = append(refs, ref{key: sortKey(p, opts.Sort), idx: i})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return before(refs[i].key, posts[refs[i].idx].ID, refs[j].key, posts[refs[j].idx].ID)
+	})
+
+	i := 0
+	if resume {
+		i = sort.Search(len(refs), func(j int) bool {
+			return before(after.key, after.id, refs[j].key, posts[refs[j].idx].ID)
+		})
+	}
+	page := PostPage{Total: len(refs)}
+	end := min(i+limit, len(refs))
+	for _, r := range refs[i:end] {
+		page.Posts = append(page.Posts, posts[r.idx].Header())
+	}
+	if end < len(refs) {
+		last := refs[end-1]
+		page.Next = cursor{sort: opts.Sort, key: last.key, id: posts[last.idx].ID}.String()
+	}
+	return page
+}
@@ -0,0 +1,148 @@
+package bbs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func pagingFixture(t *testing.T, n int) *BBS {
+	t.Helper()
+	now := fixedNow()
+	clock := func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	b := New(clock)
+	for i := 1; i <= n; i++ {
+		author := "alice"
+		if i%3 == 0 {
+			author = "bob"
+		}
+		if _, err := b.AddPost("general", author, fmt.Sprintf("post %d", i), "body"); err != nil {
+			t.Fatalf("AddPost: %v", err)
+		}
+	}
+	// Give a few posts comments and votes so the keyed sorts have ties to break.
+	for i := 1; i <= n; i += 4 {
+		for j := 0; j < i%3+1; j++ {
+			_, _ = b.AddComment("general", i, "carol", "reply", 0)
+		}
+		_, _ = b.Vote("general", i, 0, "dave", 1)
+	}
+	return b
+}
+
+// walk collects every page of a listing.
+func walk(t *testing.T, b *BBS, opts ListPostsOptions, limit int) []int {
+	t.Helper()
+	var ids []int
+	after := ""
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("paging does not terminate")
+		}
+		page, err := b.ListPostPage("general", PageOptions{ListPostsOptions: opts, After: after, Limit: limit})
+		if err != nil {
+			t.Fatalf("ListPostPage: %v", err)
+		}
+		if len(page.Posts) > limit {
+			t.Fatalf("page of %d exceeds limit %d", len(page.Posts), limit)
+		}
+		for _, h := range page.Posts {
+			ids = append(ids, h.ID)
+		}
+		if page.Next == "" {
+			return ids
+		}
+		after = page.Next
+	}
+}
+
+// fullListing orders the matching posts the slow way: every post, sorted
+// at once.
+func fullListing(b *BBS, opts ListPostsOptions) []int {
+	all, _ := b.ListPosts("general")
+	var posts []Post
+	for _, p := range all {
+		if opts.match(p) {
+			posts = append(posts, p)
+		}
+	}
+	if opts.Sort != SortOldest {
+		sort.SliceStable(posts, func(i, j int) bool {
+			return before(sortKey(posts[i], opts.Sort), posts[i].ID, sortKey(posts[j], opts.Sort), posts[j].ID)
+		})
+	}
+	var ids []int
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestListPostPageMatchesFullListing(t *testing.T) {
+	b := pagingFixture(t, 23)
+	for _, sort := range PostSorts {
+		for _, opts := range []ListPostsOptions{{Sort: sort}, {Sort: sort, Author: "bob"}, {Sort: sort, HasComments: true}} {
+			want := fullListing(b, opts)
+			if got := walk(t, b, opts, 5); !reflect.DeepEqual(got, want) {
+				t.Errorf("%+v: pages %v, full listing %v", opts, got, want)
+			}
+		}
+	}
+}
+
+func TestListPostPageHeadersAndTotal(t *testing.T) {
+	b := pagingFixture(t, 12)
+	page, err := b.ListPostPage("general", PageOptions{ListPostsOptions: ListPostsOptions{Author: "bob"}, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListPostPage: %v", err)
+	}
+	if page.Total != 4 || len(page.Posts) != 2 || page.Next == "" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	full, _ := b.GetPost("general", 1)
+	h := full.Header()
+	if h.Comments != len(full.Comments) || h.LastCommentID != full.Comments[len(full.Comments)-1].ID || h.Score != 1 {
+		t.Fatalf("unexpected header: %+v", h)
+	}
+}
+
+func TestListPostPageCursorSurvivesDeletes(t *testing.T) {
+	b := pagingFixture(t, 10)
+	opts := PageOptions{ListPostsOptions: ListPostsOptions{Sort: SortNewest}, Limit: 3}
+	first, _ := b.ListPostPage("general", opts)
+	// Delete the post the cursor points at and the one after it.
+	if err := b.DeletePost("general", 8, "alice"); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	if err := b.DeletePost("general", 7, "alice"); err != nil {
+		t.Fatalf("DeletePost: %v", err)
+	}
+	opts.After = first.Next
+	second, err := b.ListPostPage("general", opts)
+	if err != nil {
+		t.Fatalf("ListPostPage: %v", err)
+	}
+	if second.Posts[0].ID != 6 {
+		t.Fatalf("expected the page to resume at post 6, got %+v", second.Posts)
+	}
+}
+
+func TestListPostPageRejectsForeignCursors(t *testing.T) {
+	b := pagingFixture(t, 5)
+	page, _ := b.ListPostPage("general", PageOptions{Limit: 2})
+	opts := PageOptions{ListPostsOptions: ListPostsOptions{Sort: SortScore}, After: page.Next}
+	if _, err := b.ListPostPage("general", opts); err != ErrBadCursor {
+		t.Fatalf("expected ErrBadCursor for another sort's cursor, got %v", err)
+	}
+	if _, err := b.ListPostPage("general", PageOptions{After: "garbage"}); err != ErrBadCursor {
+		t.Fatalf("expected ErrBadCursor, got %v", err)
+	}
+	if _, err := b.ListPostPage("missing", PageOptions{}); err != ErrBoardNotFound {
+		t.Fatalf("expected ErrBoardNotFound, got %v", err)
+	}
+}
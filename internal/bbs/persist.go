@@ -27,41 +27,48 @@ type BoardListLoader interface {
 	Load() ([]string, error)
 }
 
+// BoardMeta holds per-board settings kept next to the board list.
+type BoardMeta struct {
+	State       BoardState `json:"state,omitempty"`
+	Subscribers []string   `json:"subscribers,omitempty"`
+	Private     bool       `json:"private,omitempty"` // hidden from the web mirror
+}
+
+// BoardMetaStore persists per-board settings. Board list stores may
+// optionally implement it.
+type BoardMetaStore interface {
+	LoadMeta() (map[string]BoardMeta, error)
+	SaveMeta(meta map[string]BoardMeta) error
+}
+
 // BoardFile stores board names as JSON on disk.
-// Format: {"boards":["general","tech"]}.
+// Format: {"boards":["general","tech"],"meta":{"tech":{"state":"archived"}}}.
 type BoardFile struct {
 	Path string
 }
 
-func (f BoardFile) Load() ([]string, error) {
-	if f.Path == "" {
-		return nil, nil
-	}
+type boardFileData struct {
+	Boards []string             `json:"boards"`
+	Meta   map[string]BoardMeta `json:"meta,omitempty"`
+}
+
+func (f BoardFile) read() (boardFileData, error) {
+	var wrapper boardFileData
 	data, err := os.ReadFile(f.Path)
 	if errors.Is(err, os.ErrNotExist) {
-		return nil, nil
+		return wrapper, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("read boards file: %w", err)
-	}
-	var wrapper struct {
-		Boards []string `json:"boards"`
+		return wrapper, fmt.Errorf("read boards file: %w", err)
 	}
 	if err := json.Unmarshal(data, &wrapper); err != nil {
-		return nil, fmt.Errorf("parse boards file: %w", err)
+		return wrapper, fmt.Errorf("parse boards file: %w", err)
 	}
-	return normalizeBoardNames(wrapper.Boards), nil
+	return wrapper, nil
 }
 
-func (f BoardFile) Save(names []string) error {
-	if f.Path == "" {
-		return nil
-	}
-	names = normalizeBoardNames(names)
-	payload := struct {
-		Boards []string `json:"boards"`
-	}{Boards: names}
-	data, err := json.MarshalIndent(payload, "", "  ")
+func (f BoardFile) write(wrapper boardFileData) error {
+	data, err := json.MarshalIndent(wrapper, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal boards: %w", err)
 	}
@@ -78,6 +85,55 @@ func (f BoardFile) Save(names []string) error {
 	return nil
 }
 
+func (f BoardFile) Load() ([]string, error) {
+	if f.Path == "" {
+		return nil, nil
+	}
+	wrapper, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	return normalizeBoardNames(wrapper.Boards), nil
+}
+
+func (f BoardFile) Save(names []string) error {
+	if f.Path == "" {
+		return nil
+	}
+	// Keep board settings that were saved earlier.
+	wrapper, err := f.read()
+	if err != nil {
+		return err
+	}
+	wrapper.Boards = normalizeBoardNames(names)
+	return f.write(wrapper)
+}
+
+// LoadMeta returns per-board settings; missing file yields none.
+func (f BoardFile) LoadMeta() (map[string]BoardMeta, error) {
+	if f.Path == "" {
+		return nil, nil
+	}
+	wrapper, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	return wrapper.Meta, nil
+}
+
+// SaveMeta replaces per-board settings, keeping the board list intact.
+func (f BoardFile) SaveMeta(meta map[string]BoardMeta) error {
+	if f.Path == "" {
+		return nil
+	}
+	wrapper, err := f.read()
+	if err != nil {
+		return err
+	}
+	wrapper.Meta = meta
+	return f.write(wrapper)
+}
+
 func normalizeBoardNames(names []string) []string {
 	seen := make(map[string]struct{}, len(names))
 	out := make([]string, 0, len(names))
@@ -0,0 +1,136 @@
+package bbs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// userFile stores one JSON document per user in Dir, optionally encrypted
+// with the same AES-GCM scheme as PostFile.
+type userFile struct {
+	Dir           string
+	EncryptionKey []byte
+}
+
+func (f userFile) path(user string) string {
+	// Usernames come from SSH clients; escape them so they stay inside Dir.
+	return filepath.Join(f.Dir, url.PathEscape(user)+".json")
+}
+
+// load decodes the user's document into v. A missing file leaves v untouched.
+func (f userFile) load(user string, v any) error {
+	if f.Dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(f.path(user))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read user file: %w", err)
+	}
+	data = maybeDecrypt(f.EncryptionKey, data)
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse user file: %w", err)
+	}
+	return nil
+}
+
+func (f userFile) save(user string, v any) error {
+	if f.Dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal user file: %w", err)
+	}
+	return writeSealed(f.path(user), f.EncryptionKey, data)
+}
+
+// writeSealed encrypts data when key is set and writes it atomically.
+func writeSealed(path string, key, data []byte) error {
+	if len(key) > 0 {
+		encrypted, err := encryptBytes(key, data)
+		if err != nil {
+			return fmt.Errorf("encrypt: %w", err)
+		}
+		data = encrypted
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("make dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename file: %w", err)
+	}
+	return nil
+}
+
+// WriteSealed writes data to path atomically, encrypted with key when it is
+// set, so stores outside this package can honour the same key.
+func WriteSealed(path string, key, data []byte) error {
+	return writeSealed(path, key, data)
+}
+
+// OpenSealed returns the contents of a file written by WriteSealed. Files
+// written before a key was set read as they are.
+func OpenSealed(key, data []byte) []byte {
+	return maybeDecrypt(key, data)
+}
+
+// maybeDecrypt decrypts data when key is set. If decryption fails the data
+// is returned as-is, so plain files written before encryption was enabled
+// still load.
+func maybeDecrypt(key, data []byte) []byte {
+	if len(key) == 0 {
+		return data
+	}
+	decrypted, err := decryptBytes(key, data)
+	if err != nil {
+		return data
+	}
+	return decrypted
+}
+
+func encryptBytes(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptBytes(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -1,13 +1,9 @@
 package bbs
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 )
@@ -44,15 +40,9 @@ func (f PostFile) Load(board string) ([]Post, error) {
 		return nil, fmt.Errorf("read posts file: %w", err)
 	}
 
-	// Try to decrypt if key is present
-	if len(f.EncryptionKey) > 0 {
-		decrypted, err := f.decrypt(data)
-		if err == nil {
-			data = decrypted
-		}
-		// If decryption fails, we assume it might be plain JSON (migration scenario)
-		// or it's just broken. We try to unmarshal whatever we have.
-	}
+	// Try to decrypt if key is present. If decryption fails, we assume it
+	// might be plain JSON (migration scenario) or it's just broken.
+	data = maybeDecrypt(f.EncryptionKey, data)
 
 	var wrapper struct {
 		Version int    `json:"version"`
@@ -69,11 +59,6 @@ func (f PostFile) Save(board string, posts []Post) error {
 	if f.Dir == "" {
 		return nil
 	}
-	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
-		return fmt.Errorf("make posts dir: %w", err)
-	}
-	path := f.path(board)
-	tmp := path + ".tmp"
 	payload := struct {
 		Version int    `json:"version"`
 		Board   string `json:"board"`
@@ -87,53 +72,47 @@ func (f PostFile) Save(board string, posts []Post) error {
 	if err != nil {
 		return fmt.Errorf("marshal posts: %w", err)
 	}
-
-	// Encrypt if key is present
-	if len(f.EncryptionKey) > 0 {
-		encrypted, err := f.encrypt(data)
-		if err != nil {
-			return fmt.Errorf("encrypt posts: %w", err)
-		}
-		data = encrypted
-	}
-
-	if err := os.WriteFile(tmp, data, 0o644); err != nil {
-		return fmt.Errorf("write posts temp file: %w", err)
-	}
-	if err := os.Rename(tmp, path); err != nil {
-		return fmt.Errorf("rename posts file: %w", err)
+	// Encrypted when a key is present.
+	if err := writeSealed(f.path(board), f.EncryptionKey, data); err != nil {
+		return fmt.Errorf("store posts: %w", err)
 	}
 	return nil
 }
 
-func (f PostFile) encrypt(plaintext []byte) ([]byte, error) {
-	block, err := aes.NewCipher(f.EncryptionKey)
-	if err != nil {
-		return nil, err
+func (f PostFile) indexPath(board string) string {
+	return filepath.Join(f.Dir, "index", board+".json")
+}
+
+// LoadIndex reads the summary saved next to the board's posts.
+func (f PostFile) LoadIndex(board string) (BoardIndex, bool, error) {
+	if f.Dir == "" {
+		return BoardIndex{}, false, nil
+	}
+	data, err := os.ReadFile(f.indexPath(board))
+	if errors.Is(err, os.ErrNotExist) {
+		return BoardIndex{}, false, nil
 	}
-	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return nil, err
+		return BoardIndex{}, false, fmt.Errorf("read index file: %w", err)
 	}
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
+	var idx BoardIndex
+	if err := json.Unmarshal(maybeDecrypt(f.EncryptionKey, data), &idx); err != nil {
+		return BoardIndex{}, false, fmt.Errorf("parse index file: %w", err)
 	}
-	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+	return idx, true, nil
 }
 
-func (f PostFile) decrypt(ciphertext []byte) ([]byte, error) {
-	block, err := aes.NewCipher(f.EncryptionKey)
-	if err != nil {
-		return nil, err
+// SaveIndex stores the board summary, encrypted like the posts.
+func (f PostFile) SaveIndex(board string, idx BoardIndex) error {
+	if f.Dir == "" {
+		return nil
 	}
-	gcm, err := cipher.NewGCM(block)
+	data, err := json.Marshal(idx)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("marshal index: %w", err)
 	}
-	if len(ciphertext) < gcm.NonceSize() {
-		return nil, errors.New("ciphertext too short")
+	if err := writeSealed(f.indexPath(board), f.EncryptionKey, data); err != nil {
+		return fmt.Errorf("store index: %w", err)
 	}
-	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
-	return gcm.Open(nil, nonce, ciphertext, nil)
+	return nil
 }
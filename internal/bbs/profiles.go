@@ -0,0 +1,225 @@
+package bbs
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxDisplayName = 32
+	maxBio         = 500
+	maxSignature   = 200
+	// signatureSeparator is the usual "-- " line between a message and its signature.
+	signatureSeparator = "\n\n-- \n"
+)
+
+// ErrProfileTooLong signals a profile field over its length limit.
+var ErrProfileTooLong = errors.New("profile field is too long")
+
+// Profile describes a user. Posts and Comments are counted from the boards
+// and are not stored.
+type Profile struct {
+	User            string    `json:"user"`
+	DisplayName     string    `json:"display_name,omitempty"`
+	Bio             string    `json:"bio,omitempty"`
+	Signature       string    `json:"signature,omitempty"`
+	AppendSignature bool      `json:"append_signature,omitempty"` // add Signature to new posts and comments
+	JoinedAt        time.Time `json:"joined_at"`
+	LastSeen        time.Time `json:"last_seen"`
+
+	Posts    int `json:"-"`
+	Comments int `json:"-"`
+}
+
+// Name returns the display name, or the username without one.
+func (p Profile) Name() string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.User
+}
+
+// ProfileEdit holds the fields a user may change on their own profile.
+type ProfileEdit struct {
+	DisplayName     string
+	Bio             string
+	Signature       string
+	AppendSignature bool
+}
+
+// ProfileStore persists profiles per user.
+type ProfileStore interface {
+	Load(user string) (Profile, error)
+	Save(user string, p Profile) error
+}
+
+// ProfileFile stores profiles as one JSON file per user in Dir.
+type ProfileFile struct {
+	Dir           string
+	EncryptionKey []byte // 32 bytes for AES-256
+}
+
+func (f ProfileFile) Load(user string) (Profile, error) {
+	var p Profile
+	err := userFile{Dir: f.Dir, EncryptionKey: f.EncryptionKey}.load(user, &p)
+	return p, err
+}
+
+func (f ProfileFile) Save(user string, p Profile) error {
+	return userFile{Dir: f.Dir, EncryptionKey: f.EncryptionKey}.save(user, p)
+}
+
+// WithProfileStore persists profiles through store.
+func WithProfileStore(store ProfileStore) Option {
+	return func(b *BBS) {
+		b.profiles.store = store
+	}
+}
+
+// profileBook caches profiles per user.
+type profileBook struct {
+	mu       sync.Mutex
+	store    ProfileStore
+	profiles map[string]Profile
+}
+
+// get returns the stored profile of user, loading it on first use.
+// Callers must hold k.mu.
+func (k *profileBook) get(user string) Profile {
+	if p, ok := k.profiles[user]; ok {
+		return p
+	}
+	p := Profile{User: user}
+	if k.store != nil {
+		if loaded, err := k.store.Load(user); err == nil && loaded.User == user {
+			p = loaded
+		}
+	}
+	if k.profiles == nil {
+		k.profiles = make(map[string]Profile)
+	}
+	k.profiles[user] = p
+	return p
+}
+
+// set replaces and persists the profile of user. Callers must hold k.mu.
+func (k *profileBook) set(user string, p Profile) error {
+	k.profiles[user] = p
+	if k.store == nil {
+		return nil
+	}
+	return k.store.Save(user, p)
+}
+
+// Profile returns the profile of user with activity counted from every board.
+// Users who never saved a profile get one with just their activity.
+func (b *BBS) Profile(user string) Profile {
+	b.profiles.mu.Lock()
+	p := b.profiles.get(user)
+	b.profiles.mu.Unlock()
+
+	var first time.Time
+	for _, s := range b.summaries() {
+		if a, ok := s.authors[user]; ok {
+			p.Posts += len(a.posts)
+			p.Comments += a.comments
+			first = earliest(first, a.first)
+		}
+	}
+	// Activity from before profiles existed still dates the account.
+	p.JoinedAt = earliest(p.JoinedAt, first)
+	return p
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// UpdateProfile replaces the editable fields of the user's profile.
+func (b *BBS) UpdateProfile(user string, edit ProfileEdit) error {
+	edit.DisplayName = strings.TrimSpace(edit.DisplayName)
+	edit.Bio = strings.TrimSpace(edit.Bio)
+	edit.Signature = strings.TrimSpace(edit.Signature)
+	if utf8.RuneCountInString(edit.DisplayName) > maxDisplayName ||
+		utf8.RuneCountInString(edit.Bio) > maxBio ||
+		utf8.RuneCountInString(edit.Signature) > maxSignature {
+		return ErrProfileTooLong
+	}
+
+	b.profiles.mu.Lock()
+	defer b.profiles.mu.Unlock()
+	p := b.profiles.get(user)
+	p.DisplayName = edit.DisplayName
+	p.Bio = edit.Bio
+	p.Signature = edit.Signature
+	p.AppendSignature = edit.AppendSignature
+	if p.JoinedAt.IsZero() {
+		p.JoinedAt = b.now()
+	}
+	return b.profiles.set(user, p)
+}
+
+// RecordSeen stamps the user's last visit, and their join date on the first.
+func (b *BBS) RecordSeen(user string) error {
+	b.profiles.mu.Lock()
+	defer b.profiles.mu.Unlock()
+	p := b.profiles.get(user)
+	p.LastSeen = b.now()
+	if p.JoinedAt.IsZero() {
+		p.JoinedAt = p.LastSeen
+	}
+	return b.profiles.set(user, p)
+}
+
+// signature returns the signature to store with the author's new posts and
+// comments: empty unless they asked for it to be added.
+func (b *BBS) signature(author string) string {
+	b.profiles.mu.Lock()
+	p := b.profiles.get(author)
+	b.profiles.mu.Unlock()
+	if !p.AppendSignature {
+		return ""
+	}
+	return p.Signature
+}
+
+// withSignature appends signature to content for display.
+func withSignature(content, signature string) string {
+	if signature == "" {
+		return content
+	}
+	return content + signatureSeparator + signature
+}
+
+// Signed returns the post's content followed by its signature, for display.
+func (p Post) Signed() string {
+	return withSignature(p.Content, p.Signature)
+}
+
+// Signed returns the comment's content followed by its signature, for
+// display.
+func (c Comment) Signed() string {
+	return withSignature(c.Content, c.Signature)
+}
+
+// ListPostsByAuthor returns up to limit posts by user from every board,
+// newest first. A limit of 0 returns them all.
+func (b *BBS) ListPostsByAuthor(user string, limit int) []PostRef {
+	var out []PostRef
+	for _, s := range b.summaries() {
+		if a, ok := s.authors[user]; ok {
+			out = append(out, a.posts...)
+		}
+	}
+	newestFirst(out)
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
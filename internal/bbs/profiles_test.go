@@ -0,0 +1,93 @@
+package bbs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileCountsActivity(t *testing.T) {
+	b := New(fixedNow)
+	first, _ := b.AddPost("general", "alice", "first", "")
+	_, _ = b.AddPost("tech", "alice", "second", "")
+	_, _ = b.AddPost("general", "bob", "other", "")
+	_, _ = b.AddComment("general", first.ID, "alice", "me again", 0)
+	_, _ = b.AddComment("general", first.ID, "bob", "hi", 0)
+
+	p := b.Profile("alice")
+	if p.User != "alice" || p.Posts != 2 || p.Comments != 1 {
+		t.Fatalf("unexpected counts: %+v", p)
+	}
+	if !p.JoinedAt.Equal(fixedNow()) {
+		t.Fatalf("join date should come from the first activity, got %v", p.JoinedAt)
+	}
+	if p.Name() != "alice" {
+		t.Fatalf("name should fall back to the username, got %q", p.Name())
+	}
+
+	recent := b.ListPostsByAuthor("alice", 1)
+	if len(recent) != 1 || recent[0].Author != "alice" {
+		t.Fatalf("unexpected recent posts: %+v", recent)
+	}
+	if all := b.ListPostsByAuthor("alice", 0); len(all) != 2 {
+		t.Fatalf("expected 2 posts, got %d", len(all))
+	}
+}
+
+func TestUpdateProfilePersists(t *testing.T) {
+	store := ProfileFile{Dir: t.TempDir()}
+	now := fixedNow()
+	clock := func() time.Time { return now }
+	b := NewWithBoards(clock, []string{"general"}, nil, nil, WithProfileStore(store))
+
+	if err := b.RecordSeen("alice"); err != nil {
+		t.Fatalf("RecordSeen: %v", err)
+	}
+	edit := ProfileEdit{DisplayName: " Alice A. ", Bio: "Gopher.", Signature: "-- alice"}
+	if err := b.UpdateProfile("alice", edit); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if err := b.UpdateProfile("alice", ProfileEdit{Bio: strings.Repeat("x", maxBio+1)}); !errors.Is(err, ErrProfileTooLong) {
+		t.Fatalf("expected ErrProfileTooLong, got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	_ = b.RecordSeen("alice")
+
+	reloaded := NewWithBoards(clock, []string{"general"}, nil, nil, WithProfileStore(store))
+	p := reloaded.Profile("alice")
+	if p.Name() != "Alice A." || p.Bio != "Gopher." || p.Signature != "-- alice" {
+		t.Fatalf("profile not persisted: %+v", p)
+	}
+	if !p.JoinedAt.Equal(fixedNow()) || !p.LastSeen.Equal(now) {
+		t.Fatalf("unexpected dates: joined %v, last seen %v", p.JoinedAt, p.LastSeen)
+	}
+}
+
+func TestSignatureIsAppended(t *testing.T) {
+	b := New(fixedNow)
+	_, _ = b.AddPost("general", "bob", "hello", "") // makes @bob a known user
+	_ = b.UpdateProfile("alice", ProfileEdit{Signature: "cheers, @bob"})
+	post, _ := b.AddPost("general", "alice", "no sig yet", "body")
+	if post.Signed() != "body" {
+		t.Fatalf("signature should only be added when enabled: %q", post.Signed())
+	}
+
+	_ = b.UpdateProfile("alice", ProfileEdit{Signature: "cheers, @bob", AppendSignature: true})
+	post, _ = b.AddPost("general", "alice", "signed", "body")
+	if post.Content != "body" || post.Signed() != "body\n\n-- \ncheers, @bob" {
+		t.Fatalf("the signature should be shown but not stored in the content: %q, %q", post.Content, post.Signed())
+	}
+	if len(post.Mentions) != 0 {
+		t.Fatalf("mentions in a signature should not notify: %v", post.Mentions)
+	}
+	edited, _ := b.EditPost("general", post.ID, "alice", "signed", post.Content)
+	if edited.Content != "body" || edited.Signed() != post.Signed() {
+		t.Fatalf("editing should not sign the post again: %q", edited.Signed())
+	}
+	c, _ := b.AddComment("general", post.ID, "alice", "reply", 0)
+	if c.Content != "reply" || !strings.HasSuffix(c.Signed(), "cheers, @bob") {
+		t.Fatalf("comment not signed: %q, %q", c.Content, c.Signed())
+	}
+}
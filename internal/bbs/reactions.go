@@ -0,0 +1,185 @@
+package bbs
+
+import "errors"
+
+// Reactions is the fixed set of emoji users can react with.
+var Reactions = []string{"👍", "🔥", "😂", "🎉", "👀", "🚀"}
+
+// ErrUnknownReaction signals an emoji outside the Reactions set.
+var ErrUnknownReaction = errors.New("unknown reaction")
+
+// Score sums the votes on the post.
+func (p Post) Score() int {
+	return sumVotes(p.Votes)
+}
+
+// ReactionCount returns the total number of reactions on the post.
+func (p Post) ReactionCount() int {
+	return countReactions(p.Reactions)
+}
+
+// Score sums the votes on the comment.
+func (c Comment) Score() int {
+	return sumVotes(c.Votes)
+}
+
+// ReactionCount returns the total number of reactions on the comment.
+func (c Comment) ReactionCount() int {
+	return countReactions(c.Reactions)
+}
+
+// Vote records a +1 or -1 vote by user on a post, or on one of its comments
+// when commentID is non-zero. Repeating the same vote, or voting 0, removes it.
+// It returns the new score.
+func (b *BBS) Vote(boardName string, postID, commentID int, user string, value int) (int, error) {
+	if value > 1 {
+		value = 1
+	}
+	if value < -1 {
+		value = -1
+	}
+	score := 0
+	err := b.updateTarget(boardName, postID, commentID, func(votes *map[string]int, _ *map[string][]string) {
+		next := copyVotes(*votes)
+		if value == 0 || next[user] == value {
+			delete(next, user)
+		} else {
+			next[user] = value
+		}
+		if len(next) == 0 {
+			next = nil
+		}
+		*votes = next
+		score = sumVotes(next)
+	})
+	return score, err
+}
+
+// React toggles an emoji reaction by user on a post, or on one of its comments
+// when commentID is non-zero. It reports whether the reaction is now set.
+func (b *BBS) React(boardName string, postID, commentID int, user, emoji string) (bool, error) {
+	if !knownReaction(emoji) {
+		return false, ErrUnknownReaction
+	}
+	set := false
+	err := b.updateTarget(boardName, postID, commentID, func(_ *map[string]int, reactions *map[string][]string) {
+		next := copyReactions(*reactions)
+		users := next[emoji]
+		set = true
+		for i, u := range users {
+			if u == user {
+				users = append(users[:i:i], users[i+1:]...)
+				set = false
+				break
+			}
+		}
+		if set {
+			users = append(append([]string(nil), users...), user)
+		}
+		if len(users) == 0 {
+			delete(next, emoji)
+		} else {
+			next[emoji] = users
+		}
+		if len(next) == 0 {
+			next = nil
+		}
+		*reactions = next
+	})
+	return set, err
+}
+
+// updateTarget applies fn to the vote and reaction maps of a post or comment
+// and persists the board. fn must replace the maps rather than mutate them,
+// since earlier copies of the post share them.
+func (b *BBS) updateTarget(boardName string, postID, commentID int, fn func(*map[string]int, *map[string][]string)) (err error) {
+	board, ok := b.board(boardName)
+	if !ok {
+		return ErrBoardNotFound
+	}
+
+	if err := b.lockBoard(board); err != nil {
+		return err
+	}
+	var saved pendingSave
+	defer board.unlockAndWait(&saved, &err)
+
+	if board.state == BoardArchived {
+		return ErrBoardArchived
+	}
+
+	idx := -1
+	for i := range board.posts {
+		if board.posts[i].ID == postID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return ErrPostNotFound
+	}
+	post := &board.posts[idx]
+
+	if commentID == 0 {
+		fn(&post.Votes, &post.Reactions)
+	} else {
+		ci := -1
+		for i := range post.Comments {
+			if post.Comments[i].ID == commentID {
+				ci = i
+				break
+			}
+		}
+		if ci < 0 {
+			return ErrCommentNotFound
+		}
+		// Copy the slice so earlier snapshots keep their comment values.
+		comments := append([]Comment(nil), post.Comments...)
+		fn(&comments[ci].Votes, &comments[ci].Reactions)
+		post.Comments = comments
+	}
+
+	saved = b.persist(board)
+	return nil
+}
+
+func knownReaction(emoji string) bool {
+	for _, r := range Reactions {
+		if r == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+func sumVotes(votes map[string]int) int {
+	total := 0
+	for _, v := range votes {
+		total += v
+	}
+	return total
+}
+
+func countReactions(reactions map[string][]string) int {
+	total := 0
+	for _, users := range reactions {
+		total += len(users)
+	}
+	return total
+}
+
+func copyVotes(votes map[string]int) map[string]int {
+	out := make(map[string]int, len(votes)+1)
+	for k, v := range votes {
+		out[k] = v
+	}
+	return out
+}
+
+func copyReactions(reactions map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(reactions)+1)
+	for k, v := range reactions {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package bbs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVoteToggleAndScore(t *testing.T) {
+	b := New(fixedNow)
+	post, _ := b.AddPost("general", "alice", "topic", "")
+	before, _ := b.GetPost("general", post.ID)
+
+	if score, err := b.Vote("general", post.ID, 0, "bob", 1); err != nil || score != 1 {
+		t.Fatalf("upvote: score %d err %v", score, err)
+	}
+	if score, _ := b.Vote("general", post.ID, 0, "carol", -1); score != 0 {
+		t.Fatalf("expected score 0, got %d", score)
+	}
+	// Voting the same way again removes the vote.
+	if score, _ := b.Vote("general", post.ID, 0, "bob", 1); score != -1 {
+		t.Fatalf("expected toggled score -1, got %d", score)
+	}
+	// Switching direction replaces the vote.
+	if score, _ := b.Vote("general", post.ID, 0, "carol", 1); score != 1 {
+		t.Fatalf("expected score 1, got %d", score)
+	}
+
+	got, _ := b.GetPost("general", post.ID)
+	if got.Score() != 1 || len(got.Votes) != 1 {
+		t.Fatalf("unexpected votes %v", got.Votes)
+	}
+	if before.Votes != nil {
+		t.Fatalf("earlier copy was mutated: %v", before.Votes)
+	}
+}
+
+func TestReactOnComment(t *testing.T) {
+	postStore := &memoryPostStore{data: map[string][]Post{}}
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, postStore)
+	post, _ := b.AddPost("general", "alice", "topic", "")
+	c, _ := b.AddComment("general", post.ID, "bob", "hi", 0)
+
+	if _, err := b.React("general", post.ID, c.ID, "alice", "nope"); !errors.Is(err, ErrUnknownReaction) {
+		t.Fatalf("expected ErrUnknownReaction, got %v", err)
+	}
+	if _, err := b.React("general", post.ID, 99, "alice", Reactions[0]); !errors.Is(err, ErrCommentNotFound) {
+		t.Fatalf("expected ErrCommentNotFound, got %v", err)
+	}
+
+	set, err := b.React("general", post.ID, c.ID, "alice", Reactions[0])
+	if err != nil || !set {
+		t.Fatalf("React: set %v err %v", set, err)
+	}
+	_, _ = b.React("general", post.ID, c.ID, "carol", Reactions[0])
+	if n := postStore.data["general"][0].Comments[0].ReactionCount(); n != 2 {
+		t.Fatalf("expected 2 persisted reactions, got %d", n)
+	}
+
+	set, _ = b.React("general", post.ID, c.ID, "alice", Reactions[0])
+	if set {
+		t.Fatalf("expected second reaction to toggle off")
+	}
+	comments, _ := b.ListComments("general", post.ID)
+	if users := comments[0].Reactions[Reactions[0]]; len(users) != 1 || users[0] != "carol" {
+		t.Fatalf("unexpected reactions %v", comments[0].Reactions)
+	}
+	if _, err := b.Vote("general", post.ID, c.ID, "alice", 1); err != nil {
+		t.Fatalf("Vote on comment: %v", err)
+	}
+	comments, _ = b.ListComments("general", post.ID)
+	if comments[0].Score() != 1 {
+		t.Fatalf("expected comment score 1, got %d", comments[0].Score())
+	}
+}
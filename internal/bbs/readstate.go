@@ -0,0 +1,246 @@
+package bbs
+
+import "sync"
+
+// ReadState records what a user has read.
+// Boards holds the highest post ID marked read per board; Threads holds the
+// last read comment ID per board and post. A post is unread when it is above
+// the board mark and has no thread entry; it has new comments when its last
+// comment ID is above its thread entry.
+type ReadState struct {
+	Boards  map[string]int         `json:"boards,omitempty"`
+	Threads map[string]map[int]int `json:"threads,omitempty"`
+}
+
+// ReadStateStore persists read state per user.
+type ReadStateStore interface {
+	Load(user string) (ReadState, error)
+	Save(user string, state ReadState) error
+}
+
+// ReadStateFile stores read state as one JSON file per user in Dir.
+type ReadStateFile struct {
+	Dir           string
+	EncryptionKey []byte // 32 bytes for AES-256
+}
+
+func (f ReadStateFile) Load(user string) (ReadState, error) {
+	var state ReadState
+	err := userFile{Dir: f.Dir, EncryptionKey: f.EncryptionKey}.load(user, &state)
+	return state, err
+}
+
+func (f ReadStateFile) Save(user string, state ReadState) error {
+	return userFile{Dir: f.Dir, EncryptionKey: f.EncryptionKey}.save(user, state)
+}
+
+// WithReadStore persists read state through store.
+func WithReadStore(store ReadStateStore) Option {
+	return func(b *BBS) {
+		b.reads.store = store
+	}
+}
+
+// readTracker caches read state per user. It has its own lock so that
+// marking posts read never contends with board writes.
+type readTracker struct {
+	mu     sync.Mutex
+	store  ReadStateStore
+	states map[string]*ReadState
+}
+
+// get returns the cached state for user, loading it on first use.
+// Callers must hold t.mu.
+func (t *readTracker) get(user string) *ReadState {
+	if st, ok := t.states[user]; ok {
+		return st
+	}
+	st := &ReadState{}
+	if t.store != nil {
+		if loaded, err := t.store.Load(user); err == nil {
+			*st = loaded
+		}
+	}
+	if st.Boards == nil {
+		st.Boards = make(map[string]int)
+	}
+	if st.Threads == nil {
+		st.Threads = make(map[string]map[int]int)
+	}
+	if t.states == nil {
+		t.states = make(map[string]*ReadState)
+	}
+	t.states[user] = st
+	return st
+}
+
+// save persists the state for user. Callers must hold t.mu.
+func (t *readTracker) save(user string) error {
+	if t.store == nil {
+		return nil
+	}
+	return t.store.Save(user, *t.states[user])
+}
+
+// snapshot returns a deep copy of the state for user.
+func (t *readTracker) snapshot(user string) ReadState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := t.get(user)
+	out := ReadState{
+		Boards:  make(map[string]int, len(st.Boards)),
+		Threads: make(map[string]map[int]int, len(st.Threads)),
+	}
+	for board, id := range st.Boards {
+		out.Boards[board] = id
+	}
+	for board, threads := range st.Threads {
+		cp := make(map[int]int, len(threads))
+		for id, last := range threads {
+			cp[id] = last
+		}
+		out.Threads[board] = cp
+	}
+	return out
+}
+
+// Unread reports whether p is new to the reader or has new comments.
+func (st ReadState) Unread(board string, p Post) bool {
+	return st.unread(board, p.ID, lastCommentID(p))
+}
+
+// UnreadHeader is Unread for a listing header.
+func (st ReadState) UnreadHeader(board string, h PostHeader) bool {
+	return st.unread(board, h.ID, h.LastCommentID)
+}
+
+func (st ReadState) unread(board string, postID, lastComment int) bool {
+	last, ok := st.Threads[board][postID]
+	if !ok {
+		return postID > st.Boards[board]
+	}
+	return lastComment > last
+}
+
+func lastCommentID(p Post) int {
+	if len(p.Comments) == 0 {
+		return 0
+	}
+	return p.Comments[len(p.Comments)-1].ID
+}
+
+// ReadState returns a copy of what user has read.
+func (b *BBS) ReadState(user string) ReadState {
+	return b.reads.snapshot(user)
+}
+
+// MarkRead records that user has read a post and its comments up to commentID.
+func (b *BBS) MarkRead(user, boardName string, postID, commentID int) error {
+	b.reads.mu.Lock()
+	defer b.reads.mu.Unlock()
+
+	st := b.reads.get(user)
+	if last, ok := st.Threads[boardName][postID]; ok && last >= commentID {
+		return nil
+	}
+	if st.Threads[boardName] == nil {
+		st.Threads[boardName] = make(map[int]int)
+	}
+	st.Threads[boardName][postID] = commentID
+	return b.reads.save(user)
+}
+
+// MarkBoardRead marks every post and comment on a board as read.
+func (b *BBS) MarkBoardRead(user, boardName string) error {
+	board, ok := b.board(boardName)
+	if !ok {
+		return ErrBoardNotFound
+	}
+	board.mu.RLock()
+	refs := board.threads()
+	board.mu.RUnlock()
+	threads := make(map[int]int, len(refs))
+	maxID := 0
+	for _, t := range refs {
+		threads[t.ID] = t.LastComment
+		maxID = max(maxID, t.ID)
+	}
+
+	b.reads.mu.Lock()
+	defer b.reads.mu.Unlock()
+	st := b.reads.get(user)
+	st.Boards[boardName] = maxID
+	st.Threads[boardName] = threads
+	return b.reads.save(user)
+}
+
+// ListBoardsFor returns board summaries with unread counts and
+// subscriptions for user.
+func (b *BBS) ListBoardsFor(user string) []BoardSummary {
+	summaries := b.ListBoards()
+	st := b.reads.snapshot(user)
+	for i := range summaries {
+		board, ok := b.board(summaries[i].Name)
+		if !ok {
+			continue
+		}
+		// Counted from the index, so unloaded boards stay unloaded.
+		board.mu.RLock()
+		for _, t := range board.threads() {
+			if st.unread(board.Name, t.ID, t.LastComment) {
+				summaries[i].Unread++
+			}
+		}
+		summaries[i].Subscribed = containsUser(board.subscribers, user)
+		board.mu.RUnlock()
+	}
+	return summaries
+}
+
+// NextUnread finds the first unread post after fromPost on fromBoard,
+// continuing through the following boards and wrapping around to the
+// posts before fromPost.
+func (b *BBS) NextUnread(user, fromBoard string, fromPost int) (BoardPost, bool) {
+	boards := b.boardList()
+	if len(boards) == 0 {
+		return BoardPost{}, false
+	}
+	start := 0
+	for i, board := range boards {
+		if board.Name == fromBoard {
+			start = i
+			break
+		}
+	}
+	st := b.reads.snapshot(user)
+
+	// The starting board is visited twice: first after fromPost, last up to it.
+	for step := 0; step <= len(boards); step++ {
+		board := boards[(start+step)%len(boards)]
+		first, last := step == 0, step == len(boards)
+		if last && board.Name != fromBoard {
+			break
+		}
+		board.mu.RLock()
+		threads := board.threads()
+		board.mu.RUnlock()
+		for _, t := range threads {
+			if board.Name == fromBoard {
+				if first && t.ID <= fromPost {
+					continue
+				}
+				if last && t.ID > fromPost {
+					continue
+				}
+			}
+			if !st.unread(board.Name, t.ID, t.LastComment) {
+				continue
+			}
+			// Only the board with the unread post is loaded.
+			if p, err := b.GetPost(board.Name, t.ID); err == nil {
+				return BoardPost{Board: board.Name, Post: p}, true
+			}
+		}
+	}
+	return BoardPost{}, false
+}
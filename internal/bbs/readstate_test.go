@@ -0,0 +1,81 @@
+package bbs
+
+import "testing"
+
+func TestUnreadCountsAndMarkRead(t *testing.T) {
+	b := New(fixedNow)
+	p1, _ := b.AddPost("general", "alice", "one", "")
+	_, _ = b.AddPost("general", "alice", "two", "")
+	_, _ = b.AddPost("tech", "bob", "three", "")
+
+	boards := b.ListBoardsFor("carol")
+	if boards[0].Unread != 2 || boards[1].Unread != 1 {
+		t.Fatalf("unexpected unread counts: %+v", boards)
+	}
+
+	if err := b.MarkRead("carol", "general", p1.ID, 0); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if got := b.ListBoardsFor("carol")[0].Unread; got != 1 {
+		t.Fatalf("expected 1 unread after reading, got %d", got)
+	}
+
+	// A new comment makes the read thread unread again.
+	_, _ = b.AddComment("general", p1.ID, "bob", "news", 0)
+	post, _ := b.GetPost("general", p1.ID)
+	if !b.ReadState("carol").Unread("general", post) {
+		t.Fatalf("expected thread with new comment to be unread")
+	}
+
+	if err := b.MarkBoardRead("carol", "general"); err != nil {
+		t.Fatalf("MarkBoardRead: %v", err)
+	}
+	if got := b.ListBoardsFor("carol")[0].Unread; got != 0 {
+		t.Fatalf("expected board read, got %d unread", got)
+	}
+	if got := b.ListBoardsFor("dave")[0].Unread; got != 2 {
+		t.Fatalf("read state leaked between users: %d", got)
+	}
+}
+
+func TestNextUnreadTraversesBoards(t *testing.T) {
+	b := New(fixedNow)
+	_, _ = b.AddPost("general", "alice", "one", "")
+	_, _ = b.AddPost("general", "alice", "two", "")
+	_, _ = b.AddPost("tech", "bob", "three", "")
+	_ = b.MarkRead("carol", "general", 2, 0)
+
+	next, ok := b.NextUnread("carol", "general", 2)
+	if !ok || next.Board != "tech" || next.ID != 1 {
+		t.Fatalf("expected tech #1, got %+v %v", next, ok)
+	}
+	_ = b.MarkRead("carol", "tech", 1, 0)
+
+	// Wraps around to the start of the first board.
+	next, ok = b.NextUnread("carol", "tech", 1)
+	if !ok || next.Board != "general" || next.ID != 1 {
+		t.Fatalf("expected general #1, got %+v %v", next, ok)
+	}
+	_ = b.MarkRead("carol", "general", 1, 0)
+
+	if next, ok := b.NextUnread("carol", "general", 1); ok {
+		t.Fatalf("expected nothing unread, got %+v", next)
+	}
+}
+
+func TestReadStateFilePersists(t *testing.T) {
+	store := ReadStateFile{Dir: t.TempDir(), EncryptionKey: make([]byte, 32)}
+	b := NewWithBoards(fixedNow, []string{"general"}, nil, nil, WithReadStore(store))
+	_, _ = b.AddPost("general", "alice", "one", "")
+	if err := b.MarkRead("../carol", "general", 1, 0); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+
+	loaded, err := store.Load("../carol")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, ok := loaded.Threads["general"][1]; !ok {
+		t.Fatalf("expected thread entry to persist, got %+v", loaded)
+	}
+}
@@ -0,0 +1,116 @@
+package bbs
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// BoardPost is a post together with the board it lives on.
+type BoardPost struct {
+	Board string
+	Post
+}
+
+// PostRef names a post on a board with the fields cross-board lists show.
+// Open the post with GetPost.
+type PostRef struct {
+	Board     string
+	ID        int
+	Title     string
+	Author    string
+	CreatedAt time.Time
+	UpdatedAt time.Time // see Post.UpdatedAt
+}
+
+// newestFirst sorts refs by creation date, newest first.
+func newestFirst(refs []PostRef) {
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].CreatedAt.After(refs[j].CreatedAt)
+	})
+}
+
+// TagCount is a tag and the number of posts carrying it.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// ParseTags splits user input on commas and whitespace and normalizes the result.
+func ParseTags(input string) []string {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	return normalizeTags(fields)
+}
+
+// normalizeTags case-folds, trims and de-duplicates tags, dropping a leading '#'.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		t = strings.TrimLeft(t, "#")
+		t = strings.ToLower(strings.Join(strings.Fields(t), "-"))
+		if t == "" {
+			continue
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// HasTag reports whether the post carries tag.
+func (p Post) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ListPostsByTag returns posts from every board carrying tag, newest first.
+// It reads the board summaries, so no posts are loaded.
+func (b *BBS) ListPostsByTag(tag string) []PostRef {
+	tags := normalizeTags([]string{tag})
+	if len(tags) == 0 {
+		return nil
+	}
+	tag = tags[0]
+
+	var out []PostRef
+	for _, s := range b.summaries() {
+		out = append(out, s.tags[tag]...)
+	}
+	newestFirst(out)
+	return out
+}
+
+// ListTags returns every tag in use with its post count, most popular first.
+func (b *BBS) ListTags() []TagCount {
+	counts := make(map[string]int)
+	for _, s := range b.summaries() {
+		for tag, refs := range s.tags {
+			counts[tag] += len(refs)
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for tag, n := range counts {
+		out = append(out, TagCount{Tag: tag, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out
+}
@@ -0,0 +1,46 @@
+package bbs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTagsNormalizes(t *testing.T) {
+	got := ParseTags(" Go, #SSH  go,, release notes")
+	want := []string{"go", "ssh", "release", "notes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseTags: got %v want %v", got, want)
+	}
+	if got := normalizeTags([]string{"  Release   Notes ", "#", ""}); !reflect.DeepEqual(got, []string{"release-notes"}) {
+		t.Fatalf("normalizeTags: got %v", got)
+	}
+}
+
+func TestListPostsByTagAcrossBoards(t *testing.T) {
+	now := fixedNow()
+	clock := func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	b := New(clock)
+	if _, err := b.AddPost("general", "alice", "one", "", "Go", "news"); err != nil {
+		t.Fatalf("AddPost: %v", err)
+	}
+	_, _ = b.AddPost("tech", "bob", "two", "", "#go")
+	_, _ = b.AddPost("tech", "bob", "three", "")
+
+	posts := b.ListPostsByTag(" GO ")
+	if len(posts) != 2 {
+		t.Fatalf("expected 2 tagged posts, got %d", len(posts))
+	}
+	if posts[0].Board != "tech" || posts[0].Title != "two" || posts[1].Board != "general" {
+		t.Fatalf("expected newest first across boards, got %+v", posts)
+	}
+
+	tags := b.ListTags()
+	want := []TagCount{{Tag: "go", Count: 2}, {Tag: "news", Count: 1}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Fatalf("ListTags: got %+v want %+v", tags, want)
+	}
+}
@@ -0,0 +1,230 @@
+package bbs
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// tokenPrefix marks API tokens so they are easy to spot in leaked text.
+	tokenPrefix      = "bbs_"
+	maxTokenName     = 40
+	maxTokensPerUser = 20
+)
+
+var (
+	// ErrTokenNotFound signals an unknown API token ID.
+	ErrTokenNotFound = errors.New("token not found")
+	// ErrTokenName signals an empty or overlong token name.
+	ErrTokenName = errors.New("token name must be 1-40 characters")
+	// ErrTooManyTokens signals a user at their token limit.
+	ErrTooManyTokens = errors.New("too many tokens; revoke one first")
+)
+
+// APIToken describes an API token that acts as User. Only a hash of the
+// secret is kept; the secret is shown once, by IssueToken.
+type APIToken struct {
+	ID        string    `json:"id"` // public, identifies the token in lists
+	User      string    `json:"user"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"` // hex SHA-256 of the secret
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TokenStore persists the API tokens of every user.
+type TokenStore interface {
+	Load() ([]APIToken, error)
+	Save(tokens []APIToken) error
+}
+
+// TokenFile stores API tokens as one JSON file.
+type TokenFile struct {
+	Path          string
+	EncryptionKey []byte // 32 bytes for AES-256
+}
+
+func (f TokenFile) Load() ([]APIToken, error) {
+	if f.Path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read tokens: %w", err)
+	}
+	var tokens []APIToken
+	if err := json.Unmarshal(maybeDecrypt(f.EncryptionKey, data), &tokens); err != nil {
+		return nil, fmt.Errorf("parse tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (f TokenFile) Save(tokens []APIToken) error {
+	if f.Path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal tokens: %w", err)
+	}
+	return writeSealed(f.Path, f.EncryptionKey, data)
+}
+
+// WithTokenStore persists API tokens through store.
+func WithTokenStore(store TokenStore) Option {
+	return func(b *BBS) {
+		b.tokens.store = store
+	}
+}
+
+// tokenBook holds every API token, loaded on first use.
+type tokenBook struct {
+	mu     sync.Mutex
+	store  TokenStore
+	loaded bool
+	tokens []APIToken
+}
+
+// load reads the tokens on first use. Callers must hold k.mu.
+func (k *tokenBook) load() error {
+	if k.loaded {
+		return nil
+	}
+	if k.store != nil {
+		tokens, err := k.store.Load()
+		if err != nil {
+			return err
+		}
+		k.tokens = tokens
+	}
+	k.loaded = true
+	return nil
+}
+
+func (k *tokenBook) save() error {
+	if k.store == nil {
+		return nil
+	}
+	return k.store.Save(k.tokens)
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueToken creates an API token named name that acts as user, and
+// returns its secret along with it. The secret cannot be recovered later.
+func (b *BBS) IssueToken(user, name string) (string, APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxTokenName {
+		return "", APIToken{}, ErrTokenName
+	}
+	// The ID is shown and logged, so it shares no bytes with the secret.
+	var raw [24]byte
+	var id [4]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", APIToken{}, fmt.Errorf("generate token: %w", err)
+	}
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", APIToken{}, fmt.Errorf("generate token id: %w", err)
+	}
+	secret := tokenPrefix + hex.EncodeToString(raw[:])
+	t := APIToken{
+		ID:        hex.EncodeToString(id[:]),
+		User:      user,
+		Name:      name,
+		Hash:      hashToken(secret),
+		CreatedAt: b.now(),
+	}
+
+	b.tokens.mu.Lock()
+	defer b.tokens.mu.Unlock()
+	if err := b.tokens.load(); err != nil {
+		return "", APIToken{}, err
+	}
+	n := 0
+	for _, other := range b.tokens.tokens {
+		if other.User == user {
+			n++
+		}
+	}
+	if n >= maxTokensPerUser {
+		return "", APIToken{}, ErrTooManyTokens
+	}
+	b.tokens.tokens = append(b.tokens.tokens, t)
+	if err := b.tokens.save(); err != nil {
+		b.tokens.tokens = b.tokens.tokens[:len(b.tokens.tokens)-1]
+		return "", APIToken{}, err
+	}
+	return secret, t, nil
+}
+
+// Tokens returns the API tokens of user, oldest first.
+func (b *BBS) Tokens(user string) []APIToken {
+	b.tokens.mu.Lock()
+	defer b.tokens.mu.Unlock()
+	if err := b.tokens.load(); err != nil {
+		return nil
+	}
+	var out []APIToken
+	for _, t := range b.tokens.tokens {
+		if t.User == user {
+			out = append(out, t)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// RevokeToken deletes the API token of user with the given ID.
+func (b *BBS) RevokeToken(user, id string) error {
+	b.tokens.mu.Lock()
+	defer b.tokens.mu.Unlock()
+	if err := b.tokens.load(); err != nil {
+		return err
+	}
+	for i, t := range b.tokens.tokens {
+		if t.User == user && t.ID == id {
+			old := b.tokens.tokens
+			b.tokens.tokens = append(old[:i:i], old[i+1:]...)
+			if err := b.tokens.save(); err != nil {
+				b.tokens.tokens = old
+				return err
+			}
+			return nil
+		}
+	}
+	return ErrTokenNotFound
+}
+
+// TokenUser returns the user an API token secret acts as.
+func (b *BBS) TokenUser(secret string) (string, bool) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return "", false
+	}
+	hash := hashToken(secret)
+	b.tokens.mu.Lock()
+	defer b.tokens.mu.Unlock()
+	if err := b.tokens.load(); err != nil {
+		return "", false
+	}
+	for _, t := range b.tokens.tokens {
+		if t.Hash == hash {
+			return t.User, true
+		}
+	}
+	return "", false
+}
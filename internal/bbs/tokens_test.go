@@ -0,0 +1,50 @@
+package bbs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokensAuthenticateUntilRevoked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	b := NewWithBoards(fixedNow, nil, nil, nil, WithTokenStore(TokenFile{Path: path}))
+
+	if _, _, err := b.IssueToken("alice", "  "); !errors.Is(err, ErrTokenName) {
+		t.Fatalf("expected ErrTokenName, got %v", err)
+	}
+	secret, tok, err := b.IssueToken("alice", "ci bot")
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+	if user, ok := b.TokenUser(secret); !ok || user != "alice" {
+		t.Fatalf("TokenUser = %q, %v", user, ok)
+	}
+	if _, ok := b.TokenUser(secret + "x"); ok {
+		t.Fatal("expected a wrong secret to be refused")
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), secret) {
+		t.Fatal("the secret must not be stored")
+	}
+	if strings.Contains(secret, tok.ID) {
+		t.Fatal("the token ID must not give away part of the secret")
+	}
+
+	// A fresh BBS reads the tokens back.
+	reloaded := NewWithBoards(fixedNow, nil, nil, nil, WithTokenStore(TokenFile{Path: path}))
+	if got := reloaded.Tokens("alice"); len(got) != 1 || got[0].Name != "ci bot" || got[0].ID != tok.ID {
+		t.Fatalf("Tokens = %+v", got)
+	}
+	if err := reloaded.RevokeToken("bob", tok.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Fatalf("expected bob not to revoke alice's token, got %v", err)
+	}
+	if err := reloaded.RevokeToken("alice", tok.ID); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	if _, ok := reloaded.TokenUser(secret); ok {
+		t.Fatal("expected the revoked token to be refused")
+	}
+}
@@ -0,0 +1,210 @@
+package bbs
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Ack says when a write returns relative to saving it.
+type Ack int
+
+const (
+	// AckSync returns once the write is on disk and reports save errors.
+	AckSync Ack = iota
+	// AckAsync returns as soon as the write is in memory. Save errors are
+	// reported by the next Flush.
+	AckAsync
+)
+
+// WithGroupCommit coalesces the writes to a board that arrive within window
+// of the first into a single save. The default, zero, saves as soon as the
+// writer is free; writes that arrive during a save still share the next one.
+func WithGroupCommit(window time.Duration, ack Ack) Option {
+	return func(b *BBS) {
+		b.saveWindow = window
+		b.ack = ack
+	}
+}
+
+// pendingSave is the outcome of a queued save. The zero value is a save
+// nobody waits for.
+type pendingSave chan error
+
+func (p pendingSave) wait() error {
+	if p == nil {
+		return nil
+	}
+	return <-p
+}
+
+// boardWriter saves one board in the background. Writes only mark the board
+// changed; the writer takes a snapshot when it saves, so any number of
+// writes queued meanwhile cost one save.
+type boardWriter struct {
+	board *Board
+	wake  chan struct{} // a write or flush is waiting
+	now   chan struct{} // a flush cuts the group commit window short
+
+	mu      sync.Mutex
+	queued  int          // writes not yet picked up by a save
+	waiters []chan error // synchronous writes waiting for their save
+	flushes []chan error
+	failed  error // asynchronous save errors since the last Flush
+
+	unsaved atomic.Int32 // writes not yet on disk, picked up or not
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// persist queues a save of board. Callers must hold board.mu for writing
+// and wait for the result only after unlocking; see unlockAndWait.
+func (b *BBS) persist(board *Board) pendingSave {
+	if b.posts == nil {
+		return nil
+	}
+	w := board.writer
+	if w == nil {
+		w = &boardWriter{board: board, wake: make(chan struct{}, 1), now: make(chan struct{}, 1)}
+		board.writer = w
+		go b.runWriter(w)
+	}
+	var done pendingSave
+	w.mu.Lock()
+	w.queued++
+	if b.ack == AckSync {
+		done = make(chan error, 1)
+		w.waiters = append(w.waiters, done)
+	}
+	w.mu.Unlock()
+	w.unsaved.Add(1)
+	signal(w.wake)
+	return done
+}
+
+func (b *BBS) runWriter(w *boardWriter) {
+	for range w.wake {
+		if b.saveWindow > 0 && w.openWindow() {
+			timer := time.NewTimer(b.saveWindow)
+			select {
+			case <-timer.C:
+			case <-w.now:
+			}
+			timer.Stop()
+		}
+
+		w.mu.Lock()
+		queued, waiters, flushes := w.queued, w.waiters, w.flushes
+		w.queued, w.waiters, w.flushes = 0, nil, nil
+		w.mu.Unlock()
+
+		var err error
+		if queued > 0 {
+			// Every write picked up above changed the board before it was
+			// queued, so this snapshot holds all of them.
+			board := w.board
+			board.mu.RLock()
+			posts := append([]Post(nil), board.posts...)
+			nextID := board.nextID
+			board.mu.RUnlock()
+			err = b.writePosts(board.Name, posts, nextID)
+			w.unsaved.Add(-int32(queued))
+		}
+
+		for _, done := range waiters {
+			done <- err
+		}
+		// Nobody waits for asynchronous writes, so their errors are kept for
+		// the next Flush.
+		w.mu.Lock()
+		if b.ack == AckAsync {
+			w.failed = errors.Join(w.failed, err)
+			err = w.failed
+			if len(flushes) > 0 {
+				w.failed = nil
+			}
+		}
+		w.mu.Unlock()
+		for _, done := range flushes {
+			done <- err
+		}
+	}
+}
+
+// openWindow reports whether to wait out a group commit window; a pending
+// Flush skips it. A signal on now left behind by a Flush whose save already
+// happened is dropped here, so it cannot cut the new window short.
+func (w *boardWriter) openWindow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.now:
+	default:
+	}
+	return len(w.flushes) == 0
+}
+
+// writePosts saves posts and the index that summarizes them.
+func (b *BBS) writePosts(name string, posts []Post, nextID int) error {
+	if err := b.posts.Save(name, posts); err != nil {
+		return err
+	}
+	if is, ok := b.posts.(IndexStore); ok {
+		if err := is.SaveIndex(name, indexPosts(nextID, posts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush waits until every write so far is on disk and reports any save
+// that failed since the last Flush. Call it before exiting.
+func (b *BBS) Flush() error {
+	var waits []pendingSave
+	for _, board := range b.boardList() {
+		board.mu.Lock()
+		w := board.writer
+		board.mu.Unlock()
+		if w == nil {
+			continue
+		}
+		done := make(chan error, 1)
+		w.mu.Lock()
+		w.flushes = append(w.flushes, done)
+		w.mu.Unlock()
+		signal(w.now)
+		signal(w.wake)
+		waits = append(waits, done)
+	}
+	var errs []error
+	for _, done := range waits {
+		errs = append(errs, done.wait())
+	}
+	return errors.Join(errs...)
+}
+
+// dirty reports whether the board has changes not yet on disk. Callers must
+// hold board.mu, so no new write can be queued meanwhile.
+func (board *Board) dirty() bool {
+	return board.writer != nil && board.writer.unsaved.Load() > 0
+}
+
+// unlockAndWait releases a board locked with lockBoard, then waits for the
+// save queued under the lock and reports its error through err unless err is
+// already set. Deferred right after lockBoard, it keeps disk I/O outside the
+// board lock:
+//
+//	var saved pendingSave
+//	defer board.unlockAndWait(&saved, &err)
+func (board *Board) unlockAndWait(saved *pendingSave, err *error) {
+	board.mu.Unlock()
+	if werr := saved.wait(); werr != nil && *err == nil {
+		*err = werr
+	}
+}
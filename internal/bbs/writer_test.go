@@ -0,0 +1,281 @@
+package bbs
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowStore keeps posts in memory and makes saves slow or blocking.
+type slowStore struct {
+	mu    sync.Mutex
+	posts map[string][]Post
+	saves atomic.Int32
+	delay time.Duration
+	// When gate is set, saves of gated boards signal entered and then wait
+	// for gate to close.
+	gated   string
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func newSlowStore(delay time.Duration) *slowStore {
+	return &slowStore{posts: make(map[string][]Post), delay: delay}
+}
+
+func (s *slowStore) Load(board string) ([]Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Post(nil), s.posts[board]...), nil
+}
+
+func (s *slowStore) Save(board string, posts []Post) error {
+	s.saves.Add(1)
+	if s.gate != nil && board == s.gated {
+		s.entered <- struct{}{}
+		<-s.gate
+	}
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posts[board] = posts
+	return nil
+}
+
+func within(t *testing.T, what string, d time.Duration, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(d):
+		t.Fatalf("%s blocked behind a save", what)
+	}
+}
+
+func TestSlowSaveDoesNotBlockOthers(t *testing.T) {
+	store := newSlowStore(0)
+	b := NewWithBoards(fixedNow, []string{"a", "b"}, nil, store)
+	if _, err := b.AddPost("a", "alice", "first", ""); err != nil {
+		t.Fatalf("AddPost: %v", err)
+	}
+
+	store.gated = "a"
+	store.entered = make(chan struct{}, 1)
+	store.gate = make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		_, err := b.AddComment("a", 1, "bob", "slow", 0)
+		result <- err
+	}()
+	<-store.entered
+
+	// The save of board a is stuck; everything else carries on.
+	within(t, "ListBoards", time.Second, func() { b.ListBoards() })
+	within(t, "AddPost on another board", time.Second, func() {
+		if _, err := b.AddPost("b", "carol", "other", ""); err != nil {
+			t.Errorf("AddPost: %v", err)
+		}
+	})
+	within(t, "reading the saving board", time.Second, func() {
+		if p, err := b.GetPost("a", 1); err != nil || len(p.Comments) != 1 {
+			t.Errorf("GetPost: %+v, %v", p, err)
+		}
+	})
+
+	select {
+	case err := <-result:
+		t.Fatalf("AddComment returned before its save finished: %v", err)
+	default:
+	}
+	close(store.gate)
+	if err := <-result; err != nil {
+		t.Fatalf("AddComment: %v", err)
+	}
+}
+
+func TestConcurrentWritesAreBatched(t *testing.T) {
+	store := newSlowStore(5 * time.Millisecond)
+	b := NewWithBoards(fixedNow, []string{"a"}, nil, store)
+	if _, err := b.AddPost("a", "alice", "first", ""); err != nil {
+		t.Fatalf("AddPost: %v", err)
+	}
+	store.saves.Store(0)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.AddComment("a", 1, "bob", "hi", 0); err != nil {
+				t.Errorf("AddComment: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every write is on disk once it returns, in fewer saves than writes.
+	saved, _ := store.Load("a")
+	if len(saved) != 1 || len(saved[0].Comments) != writers {
+		t.Fatalf("expected %d comments on disk, got %+v", writers, saved)
+	}
+	if n := store.saves.Load(); n >= writers {
+		t.Fatalf("expected batched saves, got %d for %d writes", n, writers)
+	}
+}
+
+// BenchmarkListBoards measures board listing while another board is being
+// written to a slow disk. Saves happen outside the BBS and board locks, so
+// both cases should take about the same time.
+func BenchmarkListBoards(bb *testing.B) {
+	for _, saving := range []bool{false, true} {
+		name := "idle"
+		if saving {
+			name = "while-saving"
+		}
+		bb.Run(name, func(bb *testing.B) {
+			store := newSlowStore(time.Millisecond)
+			b := NewWithBoards(fixedNow, []string{"a", "b", "c"}, nil, store)
+			_, _ = b.AddPost("a", "alice", "first", "")
+
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			if saving {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+							_, _ = b.AddComment("a", 1, "bob", "hi", 0)
+						}
+					}
+				}()
+			}
+
+			bb.ResetTimer()
+			bb.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					b.ListBoards()
+				}
+			})
+			bb.StopTimer()
+			close(stop)
+			wg.Wait()
+		})
+	}
+}
+
+// failingStore fails every save.
+type failingStore struct{ slowStore }
+
+func (s *failingStore) Save(string, []Post) error {
+	s.saves.Add(1)
+	return errors.New("disk full")
+}
+
+func TestGroupCommitWindowCoalescesWrites(t *testing.T) {
+	store := newSlowStore(0)
+	b := NewWithBoards(fixedNow, []string{"a"}, nil, store, WithGroupCommit(50*time.Millisecond, AckSync))
+	if _, err := b.AddPost("a", "alice", "first", ""); err != nil {
+		t.Fatalf("AddPost: %v", err)
+	}
+	store.saves.Store(0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := b.AddComment("a", 1, "bob", "hi", 0); err != nil {
+				t.Errorf("AddComment: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := store.saves.Load(); n > 2 {
+		t.Fatalf("expected the window to coalesce 10 writes, got %d saves", n)
+	}
+	if saved, _ := store.Load("a"); len(saved[0].Comments) != 10 {
+		t.Fatalf("expected 10 comments on disk, got %d", len(saved[0].Comments))
+	}
+}
+
+func TestAsyncAckReturnsBeforeSaveAndFlushWaits(t *testing.T) {
+	store := newSlowStore(0)
+	b := NewWithBoards(fixedNow, []string{"a"}, nil, store, WithGroupCommit(time.Hour, AckAsync))
+
+	// With an hour-long window only Flush gets the post to disk.
+	within(t, "an asynchronous AddPost", time.Second, func() {
+		if _, err := b.AddPost("a", "alice", "first", ""); err != nil {
+			t.Errorf("AddPost: %v", err)
+		}
+	})
+	if saved, _ := store.Load("a"); len(saved) != 0 {
+		t.Fatalf("expected nothing on disk before Flush, got %+v", saved)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if saved, _ := store.Load("a"); len(saved) != 1 {
+		t.Fatalf("expected the post on disk after Flush, got %+v", saved)
+	}
+}
+
+func TestStaleFlushSignalDoesNotCutTheNextWindow(t *testing.T) {
+	w := &boardWriter{now: make(chan struct{}, 1)}
+	// A Flush picked up by a save that had already started leaves its
+	// signal behind.
+	signal(w.now)
+	if !w.openWindow() {
+		t.Fatal("expected a window with no Flush pending")
+	}
+	select {
+	case <-w.now:
+		t.Fatal("the stale signal would cut the window short")
+	default:
+	}
+	w.flushes = append(w.flushes, make(chan error, 1))
+	if w.openWindow() {
+		t.Fatal("a pending Flush should skip the window")
+	}
+
+	store := newSlowStore(0)
+	b := NewWithBoards(fixedNow, []string{"a"}, nil, store, WithGroupCommit(time.Hour, AckAsync))
+	_, _ = b.AddPost("a", "alice", "first", "")
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	_, _ = b.AddPost("a", "alice", "second", "")
+	time.Sleep(20 * time.Millisecond)
+	if saved, _ := store.Load("a"); len(saved) != 1 {
+		t.Fatalf("expected the write after Flush to wait for its window, got %d posts on disk", len(saved))
+	}
+}
+
+func TestAsyncSaveErrorsAreReportedByFlush(t *testing.T) {
+	store := &failingStore{slowStore: *newSlowStore(0)}
+	b := NewWithBoards(fixedNow, []string{"a"}, nil, store, WithGroupCommit(0, AckAsync))
+	if _, err := b.AddPost("a", "alice", "first", ""); err != nil {
+		t.Fatalf("asynchronous AddPost should not fail: %v", err)
+	}
+	if err := b.Flush(); err == nil {
+		t.Fatalf("expected Flush to report the failed save")
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatalf("expected the error to be reported once, got %v", err)
+	}
+
+	sync := NewWithBoards(fixedNow, []string{"a"}, nil, &failingStore{slowStore: *newSlowStore(0)})
+	if _, err := sync.AddPost("a", "alice", "first", ""); err == nil {
+		t.Fatalf("expected a synchronous AddPost to report the failed save")
+	}
+}
@@ -0,0 +1,444 @@
+// Package chat provides real-time chat rooms shared by every connected session.
+package chat
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultRoom is where sessions land when they open chat.
+	DefaultRoom = "lobby"
+	// defaultHistory is how many recent messages each room keeps.
+	defaultHistory = 100
+	// clientBuffer is how many messages a client may fall behind before
+	// new ones are dropped for it.
+	clientBuffer  = 64
+	maxNickLength = 20
+)
+
+var (
+	// ErrEmptyMessage signals a message without text.
+	ErrEmptyMessage = errors.New("message is empty")
+	// ErrInvalidNick signals an empty or overlong nickname.
+	ErrInvalidNick = errors.New("nickname must be 1-20 characters without spaces")
+	// ErrNickTaken signals a nickname already used in the room.
+	ErrNickTaken = errors.New("nickname is already in use")
+	// ErrInvalidRoom signals an empty or malformed room name.
+	ErrInvalidRoom = errors.New("invalid room name")
+	// ErrClosed signals use of a client that has left.
+	ErrClosed = errors.New("chat client has left")
+	// ErrUnknownCommand signals an unsupported /command.
+	ErrUnknownCommand = errors.New("unknown command; try /help")
+)
+
+// Kind says how a message is displayed.
+type Kind string
+
+const (
+	KindText   Kind = "text"   // nick: text
+	KindAction Kind = "action" // * nick text (from /me)
+	KindSystem Kind = "system" // joins, leaves, nick changes and command output
+)
+
+// Message is one line in a room.
+type Message struct {
+	Kind Kind      `json:"kind"`
+	Room string    `json:"room"`
+	User string    `json:"user,omitempty"` // account name of the sender
+	Nick string    `json:"nick,omitempty"` // display name at the time
+	Text string    `json:"text"`
+	At   time.Time `json:"at"`
+}
+
+// Store persists recent room history. It is optional.
+type Store interface {
+	Load(room string) ([]Message, error)
+	Save(room string, history []Message) error
+}
+
+// Option configures a Hub.
+type Option func(*Hub)
+
+// WithHistory sets how many recent messages each room keeps.
+func WithHistory(n int) Option {
+	return func(h *Hub) {
+		if n > 0 {
+			h.history = n
+		}
+	}
+}
+
+// WithStore persists room history through store.
+func WithStore(store Store) Option {
+	return func(h *Hub) {
+		h.store = store
+	}
+}
+
+// WithClock overrides time.Now, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(h *Hub) {
+		h.now = now
+	}
+}
+
+// Hub owns all rooms.
+type Hub struct {
+	mu      sync.Mutex
+	rooms   map[string]*room
+	history int
+	store   Store
+	now     func() time.Time
+	saves   sync.WaitGroup // running room savers
+}
+
+// NewHub returns an empty hub.
+func NewHub(opts ...Option) *Hub {
+	h := &Hub{
+		rooms:   make(map[string]*room),
+		history: defaultHistory,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// room keeps a ring buffer of recent messages and its current members.
+type room struct {
+	name    string
+	mu      sync.Mutex
+	ring    []Message
+	start   int // index of the oldest message once the ring is full
+	clients map[*Client]struct{}
+	dirty   bool // history changed since the saver last took it
+	saving  bool // a saver goroutine is running
+}
+
+func (r *room) append(msg Message, size int) {
+	if len(r.ring) < size {
+		r.ring = append(r.ring, msg)
+		return
+	}
+	r.ring[r.start] = msg
+	r.start = (r.start + 1) % size
+}
+
+func (r *room) recent() []Message {
+	out := make([]Message, 0, len(r.ring))
+	out = append(out, r.ring[r.start:]...)
+	return append(out, r.ring[:r.start]...)
+}
+
+func (h *Hub) room(name string) *room {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if r, ok := h.rooms[name]; ok {
+		return r
+	}
+	r := &room{name: name, clients: make(map[*Client]struct{})}
+	if h.store != nil {
+		if history, err := h.store.Load(name); err == nil {
+			if len(history) > h.history {
+				history = history[len(history)-h.history:]
+			}
+			r.ring = history
+		}
+	}
+	h.rooms[name] = r
+	return r
+}
+
+// Rooms returns the names of rooms with someone in them, with member counts.
+func (h *Hub) Rooms() map[string]int {
+	h.mu.Lock()
+	rooms := make([]*room, 0, len(h.rooms))
+	for _, r := range h.rooms {
+		rooms = append(rooms, r)
+	}
+	h.mu.Unlock()
+
+	out := make(map[string]int, len(rooms))
+	for _, r := range rooms {
+		r.mu.Lock()
+		if n := len(r.clients); n > 0 {
+			out[r.name] = n
+		}
+		r.mu.Unlock()
+	}
+	return out
+}
+
+// broadcast delivers msg to every member. Slow members miss messages instead
+// of blocking the room. Only what people said is kept in history; joins,
+// leaves and nick changes are live-only.
+func (h *Hub) broadcast(r *room, msg Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if msg.Kind != KindSystem {
+		r.append(msg, h.history)
+		if h.store != nil {
+			r.dirty = true
+			if !r.saving {
+				r.saving = true
+				h.saves.Add(1)
+				go h.save(r)
+			}
+		}
+	}
+	for c := range r.clients {
+		c.deliver(msg)
+	}
+}
+
+// save writes r's history until it stops changing, so a slow disk delays
+// the file rather than the room. Messages that arrive during a write are
+// saved together by the next one.
+func (h *Hub) save(r *room) {
+	defer h.saves.Done()
+	for {
+		r.mu.Lock()
+		if !r.dirty {
+			r.saving = false
+			r.mu.Unlock()
+			return
+		}
+		r.dirty = false
+		history := r.recent()
+		r.mu.Unlock()
+		_ = h.store.Save(r.name, history)
+	}
+}
+
+// Flush waits until every room's history is saved.
+func (h *Hub) Flush() {
+	h.saves.Wait()
+}
+
+// normalizeRoom lower-cases a room name and strips a leading '#'.
+func normalizeRoom(name string) (string, error) {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	if name == "" || len(name) > 32 || strings.ContainsAny(name, " \t/\\") {
+		return "", ErrInvalidRoom
+	}
+	return name, nil
+}
+
+// Join enters a room as user. The client leaves automatically when ctx is done.
+func (h *Hub) Join(ctx context.Context, roomName, user string) (*Client, error) {
+	name, err := normalizeRoom(roomName)
+	if err != nil {
+		return nil, err
+	}
+	r := h.room(name)
+	c := &Client{
+		hub:  h,
+		room: r,
+		user: user,
+		ch:   make(chan Message, clientBuffer),
+		done: make(chan struct{}),
+	}
+
+	r.mu.Lock()
+	c.nick = uniqueNick(r, user)
+	r.clients[c] = struct{}{}
+	r.mu.Unlock()
+
+	h.broadcast(r, Message{Kind: KindSystem, Room: name, Text: c.nick + " joined", At: h.now()})
+
+	if ctx != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				c.Leave()
+			case <-c.done:
+			}
+		}()
+	}
+	return c, nil
+}
+
+// uniqueNick picks nick, or nick with a number appended if it is taken.
+// Callers must hold r.mu.
+func uniqueNick(r *room, nick string) string {
+	if utf8.RuneCountInString(nick) > maxNickLength {
+		nick = string([]rune(nick)[:maxNickLength])
+	}
+	candidate := nick
+	for i := 2; nickTaken(r, candidate, nil); i++ {
+		candidate = nick + "_" + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// nickTaken reports whether another client in r uses nick. Callers must hold r.mu.
+func nickTaken(r *room, nick string, except *Client) bool {
+	for c := range r.clients {
+		if c != except && strings.EqualFold(c.nick, nick) {
+			return true
+		}
+	}
+	return false
+}
+
+// Client is one session's membership in a room.
+type Client struct {
+	hub  *Hub
+	room *room
+	user string
+	nick string // guarded by room.mu
+	ch   chan Message
+	done chan struct{}
+	once sync.Once
+}
+
+// Messages delivers new room messages until the client leaves.
+func (c *Client) Messages() <-chan Message {
+	return c.ch
+}
+
+// Room returns the room name.
+func (c *Client) Room() string {
+	return c.room.name
+}
+
+// Nick returns the current display name.
+func (c *Client) Nick() string {
+	c.room.mu.Lock()
+	defer c.room.mu.Unlock()
+	return c.nick
+}
+
+// History returns the room's recent messages, oldest first.
+func (c *Client) History() []Message {
+	c.room.mu.Lock()
+	defer c.room.mu.Unlock()
+	return c.room.recent()
+}
+
+// Members returns the nicknames of everyone in the room, sorted.
+func (c *Client) Members() []string {
+	c.room.mu.Lock()
+	defer c.room.mu.Unlock()
+	return c.room.members()
+}
+
+// deliver queues msg without blocking. Callers must hold c.room.mu, which
+// Leave holds while it closes c.ch, so a client that has left is seen here
+// and never sent to.
+func (c *Client) deliver(msg Message) {
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+	select {
+	case c.ch <- msg:
+	default:
+	}
+}
+
+// Say sends a line typed by the user. Lines starting with '/' are commands:
+// /me <action>, /nick <name>, /who and /help. /join is left to the caller,
+// since it replaces the client; see ParseJoin.
+func (c *Client) Say(text string) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ErrEmptyMessage
+	}
+	now := c.hub.now()
+	if !strings.HasPrefix(text, "/") {
+		c.hub.broadcast(c.room, Message{Kind: KindText, Room: c.room.name, User: c.user, Nick: c.Nick(), Text: text, At: now})
+		return nil
+	}
+
+	cmd, arg, _ := strings.Cut(text[1:], " ")
+	arg = strings.TrimSpace(arg)
+	switch strings.ToLower(cmd) {
+	case "me":
+		if arg == "" {
+			return ErrEmptyMessage
+		}
+		c.hub.broadcast(c.room, Message{Kind: KindAction, Room: c.room.name, User: c.user, Nick: c.Nick(), Text: arg, At: now})
+	case "nick":
+		return c.setNick(arg)
+	case "who":
+		c.room.mu.Lock()
+		c.deliver(Message{Kind: KindSystem, Room: c.room.name, Text: "here: " + strings.Join(c.room.members(), ", "), At: now})
+		c.room.mu.Unlock()
+	case "help":
+		c.room.mu.Lock()
+		c.deliver(Message{Kind: KindSystem, Room: c.room.name, Text: helpText, At: now})
+		c.room.mu.Unlock()
+	default:
+		return ErrUnknownCommand
+	}
+	return nil
+}
+
+const helpText = "/me <action> • /nick <name> • /who • /join <room>"
+
+// members returns the sorted nicknames in r. Callers must hold r.mu.
+func (r *room) members() []string {
+	out := make([]string, 0, len(r.clients))
+	for m := range r.clients {
+		out = append(out, m.nick)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (c *Client) setNick(nick string) error {
+	if nick == "" || utf8.RuneCountInString(nick) > maxNickLength || strings.ContainsAny(nick, " \t") {
+		return ErrInvalidNick
+	}
+	c.room.mu.Lock()
+	if nickTaken(c.room, nick, c) {
+		c.room.mu.Unlock()
+		return ErrNickTaken
+	}
+	old := c.nick
+	c.nick = nick
+	c.room.mu.Unlock()
+
+	if old != nick {
+		c.hub.broadcast(c.room, Message{Kind: KindSystem, Room: c.room.name, Text: old + " is now known as " + nick, At: c.hub.now()})
+	}
+	return nil
+}
+
+// Leave removes the client from its room and closes Messages. It is safe to
+// call more than once.
+func (c *Client) Leave() {
+	c.once.Do(func() {
+		c.room.mu.Lock()
+		delete(c.room.clients, c)
+		nick := c.nick
+		close(c.done)
+		close(c.ch)
+		c.room.mu.Unlock()
+		c.hub.broadcast(c.room, Message{Kind: KindSystem, Room: c.room.name, Text: nick + " left", At: c.hub.now()})
+	})
+}
+
+// ParseJoin reports whether text is a /join command and returns the room.
+func ParseJoin(text string) (string, bool) {
+	cmd, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	if !strings.EqualFold(cmd, "/join") {
+		return "", false
+	}
+	return strings.TrimSpace(arg), true
+}
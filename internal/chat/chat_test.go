@@ -0,0 +1,301 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func fixedNow() time.Time {
+	return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+}
+
+// next returns the next message for c or fails the test.
+func next(t *testing.T, c *Client) Message {
+	t.Helper()
+	select {
+	case msg := <-c.Messages():
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a message")
+		return Message{}
+	}
+}
+
+func TestJoinSayAndPresence(t *testing.T) {
+	h := NewHub(WithClock(fixedNow))
+	alice, err := h.Join(context.Background(), "#Lobby", "alice")
+	if err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	if alice.Room() != "lobby" {
+		t.Fatalf("room name should be normalized, got %q", alice.Room())
+	}
+	next(t, alice) // alice joined
+
+	bob, _ := h.Join(context.Background(), "lobby", "bob")
+	if msg := next(t, alice); msg.Kind != KindSystem || msg.Text != "bob joined" {
+		t.Fatalf("expected join notice, got %+v", msg)
+	}
+	next(t, bob)
+
+	if got := alice.Members(); !reflect.DeepEqual(got, []string{"alice", "bob"}) {
+		t.Fatalf("unexpected members: %v", got)
+	}
+	if got := h.Rooms(); got["lobby"] != 2 {
+		t.Fatalf("expected 2 members in lobby, got %v", got)
+	}
+
+	if err := bob.Say("hello"); err != nil {
+		t.Fatalf("Say: %v", err)
+	}
+	for _, c := range []*Client{alice, bob} {
+		msg := next(t, c)
+		if msg.Kind != KindText || msg.Nick != "bob" || msg.User != "bob" || msg.Text != "hello" {
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	}
+
+	bob.Leave()
+	bob.Leave() // leaving twice is harmless
+	if msg := next(t, alice); msg.Text != "bob left" {
+		t.Fatalf("expected leave notice, got %+v", msg)
+	}
+	if _, ok := <-bob.Messages(); ok {
+		t.Fatal("messages should be closed after Leave")
+	}
+	if err := bob.Say("still here?"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	h := NewHub(WithClock(fixedNow))
+	alice, _ := h.Join(context.Background(), "lobby", "alice")
+	bob, _ := h.Join(context.Background(), "lobby", "bob")
+	next(t, alice)
+	next(t, alice)
+	next(t, bob)
+
+	if err := alice.Say("/me waves"); err != nil {
+		t.Fatalf("/me: %v", err)
+	}
+	if msg := next(t, bob); msg.Kind != KindAction || msg.Text != "waves" || msg.Nick != "alice" {
+		t.Fatalf("unexpected action: %+v", msg)
+	}
+	next(t, alice)
+
+	if err := alice.Say("/nick bob"); !errors.Is(err, ErrNickTaken) {
+		t.Fatalf("expected ErrNickTaken, got %v", err)
+	}
+	if err := alice.Say("/nick two words"); !errors.Is(err, ErrInvalidNick) {
+		t.Fatalf("expected ErrInvalidNick, got %v", err)
+	}
+	if err := alice.Say("/nick ally"); err != nil {
+		t.Fatalf("/nick: %v", err)
+	}
+	if msg := next(t, bob); msg.Text != "alice is now known as ally" {
+		t.Fatalf("unexpected nick notice: %+v", msg)
+	}
+	next(t, alice)
+	if alice.Nick() != "ally" {
+		t.Fatalf("nick not changed: %q", alice.Nick())
+	}
+
+	if err := alice.Say("/who"); err != nil {
+		t.Fatalf("/who: %v", err)
+	}
+	if msg := next(t, alice); msg.Text != "here: ally, bob" {
+		t.Fatalf("unexpected /who output: %+v", msg)
+	}
+	if len(bob.Messages()) != 0 {
+		t.Fatal("/who output should only go to the asker")
+	}
+
+	if err := alice.Say("/dance"); !errors.Is(err, ErrUnknownCommand) {
+		t.Fatalf("expected ErrUnknownCommand, got %v", err)
+	}
+	if err := alice.Say("   "); !errors.Is(err, ErrEmptyMessage) {
+		t.Fatalf("expected ErrEmptyMessage, got %v", err)
+	}
+	if room, ok := ParseJoin("/join #dev"); !ok || room != "#dev" {
+		t.Fatalf("ParseJoin = %q, %v", room, ok)
+	}
+	if _, ok := ParseJoin("/joint"); ok {
+		t.Fatal("/joint is not /join")
+	}
+}
+
+func TestDuplicateUsersGetDistinctNicks(t *testing.T) {
+	h := NewHub()
+	first, _ := h.Join(context.Background(), "lobby", "alice")
+	second, _ := h.Join(context.Background(), "lobby", "alice")
+	if first.Nick() != "alice" || second.Nick() != "alice_2" {
+		t.Fatalf("unexpected nicks %q and %q", first.Nick(), second.Nick())
+	}
+}
+
+func TestLongNicksAreCutByRune(t *testing.T) {
+	h := NewHub()
+	name := strings.Repeat("가", 25)
+	c, _ := h.Join(context.Background(), "lobby", name)
+	if got := c.Nick(); !utf8.ValidString(got) || got != strings.Repeat("가", maxNickLength) {
+		t.Fatalf("unexpected nick %q", got)
+	}
+	if err := c.Say("/nick " + strings.Repeat("나", maxNickLength)); err != nil {
+		t.Fatalf("a %d-rune Hangul nick should be allowed: %v", maxNickLength, err)
+	}
+}
+
+func TestLeaveRacesCommands(t *testing.T) {
+	h := NewHub()
+	for i := 0; i < 200; i++ {
+		c, _ := h.Join(context.Background(), "lobby", "alice")
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = c.Say("/who")
+			_ = c.Say("/help")
+		}()
+		go func() {
+			defer wg.Done()
+			c.Leave()
+		}()
+		wg.Wait()
+	}
+}
+
+// slowStore blocks every Save until release is closed.
+type slowStore struct {
+	release chan struct{}
+	mu      sync.Mutex
+	saved   []Message
+}
+
+func (s *slowStore) Load(string) ([]Message, error) { return nil, nil }
+
+func (s *slowStore) Save(_ string, history []Message) error {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = history
+	return nil
+}
+
+func TestSlowStoreDoesNotBlockTheRoom(t *testing.T) {
+	store := &slowStore{release: make(chan struct{})}
+	h := NewHub(WithStore(store))
+	c, _ := h.Join(context.Background(), "lobby", "alice")
+
+	said := make(chan struct{})
+	go func() {
+		defer close(said)
+		for _, text := range []string{"one", "two", "three"} {
+			_ = c.Say(text)
+		}
+	}()
+	select {
+	case <-said:
+	case <-time.After(time.Second):
+		t.Fatal("Say waited for the store")
+	}
+
+	close(store.release)
+	h.Flush()
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.saved) != 3 || store.saved[2].Text != "three" {
+		t.Fatalf("expected the latest history to be saved, got %+v", store.saved)
+	}
+}
+
+func TestHistoryIsARingBuffer(t *testing.T) {
+	h := NewHub(WithHistory(3))
+	c, _ := h.Join(context.Background(), "lobby", "alice")
+	for _, text := range []string{"one", "two", "three", "four", "five"} {
+		if err := c.Say(text); err != nil {
+			t.Fatalf("Say: %v", err)
+		}
+	}
+	var got []string
+	for _, msg := range c.History() {
+		got = append(got, msg.Text)
+	}
+	if want := []string{"three", "four", "five"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("history = %v, want %v", got, want)
+	}
+}
+
+func TestContextEndsMembership(t *testing.T) {
+	h := NewHub()
+	watcher, _ := h.Join(context.Background(), "lobby", "bob")
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := h.Join(ctx, "lobby", "alice"); err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	next(t, watcher)
+	next(t, watcher)
+
+	cancel()
+	if msg := next(t, watcher); msg.Text != "alice left" {
+		t.Fatalf("expected alice to leave, got %+v", msg)
+	}
+	if got := watcher.Members(); !reflect.DeepEqual(got, []string{"bob"}) {
+		t.Fatalf("unexpected members: %v", got)
+	}
+}
+
+func TestInvalidRoom(t *testing.T) {
+	h := NewHub()
+	for _, name := range []string{"", "#", "two words", "a/b"} {
+		if _, err := h.Join(context.Background(), name, "alice"); !errors.Is(err, ErrInvalidRoom) {
+			t.Fatalf("Join(%q): expected ErrInvalidRoom, got %v", name, err)
+		}
+	}
+}
+
+func TestFileStorePersistsHistory(t *testing.T) {
+	store := FileStore{Dir: t.TempDir()}
+	h := NewHub(WithStore(store))
+	c, _ := h.Join(context.Background(), "lobby", "alice")
+	_ = c.Say("hello")
+	_ = c.Say("/me waves")
+	c.Leave()
+	h.Flush()
+
+	reloaded := NewHub(WithStore(store))
+	c2, _ := reloaded.Join(context.Background(), "lobby", "bob")
+	history := c2.History()
+	if len(history) != 2 || history[0].Text != "hello" || history[1].Kind != KindAction {
+		t.Fatalf("unexpected reloaded history: %+v", history)
+	}
+}
+
+func TestFileStoreEncryptsHistory(t *testing.T) {
+	dir := t.TempDir()
+	store := FileStore{Dir: dir, EncryptionKey: []byte("0123456789abcdef0123456789abcdef")}
+	h := NewHub(WithStore(store))
+	c, _ := h.Join(context.Background(), "lobby", "alice")
+	_ = c.Say("secret plans")
+	c.Leave()
+	h.Flush()
+
+	data, err := os.ReadFile(store.path("lobby"))
+	if err != nil {
+		t.Fatalf("read room file: %v", err)
+	}
+	if strings.Contains(string(data), "secret plans") {
+		t.Fatal("the room file holds the message in plain text")
+	}
+	history, err := store.Load("lobby")
+	if err != nil || len(history) != 1 || history[0].Text != "secret plans" {
+		t.Fatalf("Load: %+v, %v", history, err)
+	}
+}
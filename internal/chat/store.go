@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"ag/internal/bbs"
+)
+
+// FileStore keeps each room's recent history in Dir/<room>.json, encrypted
+// like the board's posts when EncryptionKey is set.
+type FileStore struct {
+	Dir           string
+	EncryptionKey []byte // 32 bytes for AES-256
+}
+
+type roomFile struct {
+	Messages []Message `json:"messages"`
+}
+
+func (f FileStore) path(room string) string {
+	return filepath.Join(f.Dir, url.PathEscape(room)+".json")
+}
+
+// Load returns the saved history of room. A missing file yields no messages.
+func (f FileStore) Load(room string) ([]Message, error) {
+	data, err := os.ReadFile(f.path(room))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read room file: %w", err)
+	}
+	data = bbs.OpenSealed(f.EncryptionKey, data)
+	var rf roomFile
+	if err := json.Unmarshal(data, &rf); err != nil {
+		return nil, fmt.Errorf("parse room file: %w", err)
+	}
+	return rf.Messages, nil
+}
+
+// Save replaces the saved history of room atomically.
+func (f FileStore) Save(room string, history []Message) error {
+	data, err := json.MarshalIndent(roomFile{Messages: history}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal room file: %w", err)
+	}
+	return bbs.WriteSealed(f.path(room), f.EncryptionKey, data)
+}
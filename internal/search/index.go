@@ -0,0 +1,472 @@
+// Package search keeps an inverted index over posts and comments on every
+// board and answers ranked queries against it.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"ag/internal/bbs"
+)
+
+// Field weights: a match in the title counts more than one in a comment.
+const (
+	titleWeight   = 3.0
+	bodyWeight    = 1.0
+	commentWeight = 0.5
+	// phraseBonus is added per matched phrase, on top of its words' scores.
+	phraseBonus  = 2.0
+	snippetRunes = 140
+)
+
+// Document is a post as the index reads it.
+type Document struct {
+	Board     string
+	PostID    int
+	Title     string
+	Body      string
+	Comments  []string
+	Author    string
+	Tags      []string
+	CreatedAt time.Time
+}
+
+// FromPost builds the document for a post on board.
+func FromPost(board string, p bbs.Post) Document {
+	comments := make([]string, len(p.Comments))
+	for i, c := range p.Comments {
+		comments[i] = c.Content
+	}
+	return Document{
+		Board:     board,
+		PostID:    p.ID,
+		Title:     p.Title,
+		Body:      p.Content,
+		Comments:  comments,
+		Author:    p.Author,
+		Tags:      p.Tags,
+		CreatedAt: p.CreatedAt,
+	}
+}
+
+// Result is one matching post.
+type Result struct {
+	Board     string
+	PostID    int
+	Title     string
+	Author    string
+	CreatedAt time.Time
+	Score     float64
+	Snippet   string   // text around the first match
+	Terms     []string // the words that matched, for highlighting
+}
+
+type docKey struct {
+	board string
+	id    int
+}
+
+// entry is what the index keeps of a post: the fields results and filters
+// use and the terms it is listed under. The text is read from the source
+// when a query needs it.
+type entry struct {
+	Board     string
+	PostID    int
+	Title     string
+	Author    string
+	Tags      []string
+	CreatedAt time.Time
+	terms     []string
+	doc       *Document // the text, kept only when the index has no source
+}
+
+// Index is an inverted index of posts. It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[docKey]*entry
+	postings map[string]map[docKey]float64 // term -> weighted frequency per post
+	// source reads a post's text for phrases and snippets. Without one,
+	// as in a fresh NewIndex, documents keep their text in memory.
+	source func(board string, postID int) (Document, bool)
+	// removed holds posts deleted while Watch backfills, so the backfill's
+	// older copy does not bring them back. It is nil otherwise.
+	removed map[docKey]struct{}
+}
+
+// NewIndex returns an empty index. Until Watch gives it a BBS to read
+// from, it keeps the text of added documents in memory.
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[docKey]*entry),
+		postings: make(map[string]map[docKey]float64),
+	}
+}
+
+// Watch indexes every post of b and keeps the index current as posts and
+// comments are added or deleted. From then on only terms and post fields
+// are kept in memory; text is read back from b when a query needs it.
+// Reading every board takes a while on big archives, so it may run in its
+// own goroutine; posts written meanwhile are indexed as they arrive and
+// never replaced by the older copy read here. Boards read here are not
+// added to b's cache.
+func (ix *Index) Watch(b *bbs.BBS) {
+	// Listen first so nothing written while the existing posts load is missed.
+	ix.mu.Lock()
+	ix.source = func(board string, postID int) (Document, bool) {
+		p, err := b.GetPost(board, postID)
+		if err != nil {
+			return Document{}, false
+		}
+		return FromPost(board, p), true
+	}
+	ix.removed = make(map[docKey]struct{})
+	ix.mu.Unlock()
+	defer func() {
+		ix.mu.Lock()
+		ix.removed = nil
+		ix.mu.Unlock()
+	}()
+	b.Listen(func(e bbs.Event) {
+		switch e.Kind {
+		case bbs.EventDelete:
+			ix.Remove(e.Board, e.PostID)
+		default:
+			ix.Add(FromPost(e.Board, e.Post))
+		}
+	})
+	for _, board := range b.ListBoards() {
+		var docs []Document
+		if err := b.EachPost(board.Name, func(p bbs.Post) {
+			docs = append(docs, FromPost(board.Name, p))
+		}); err != nil {
+			continue
+		}
+		for _, doc := range docs {
+			ix.add(doc, false)
+		}
+	}
+}
+
+// Len returns the number of indexed documents.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Add indexes doc, replacing any earlier version of the same post.
+func (ix *Index) Add(doc Document) {
+	ix.add(doc, true)
+}
+
+// add indexes doc. Without replace a post that is already indexed, or was
+// removed during the backfill, is kept as it is, which is how the backfill
+// in Watch avoids overwriting newer versions.
+func (ix *Index) add(doc Document, replace bool) {
+	weights := make(map[string]float64)
+	for _, t := range Tokenize(doc.Title) {
+		weights[t] += titleWeight
+	}
+	for _, t := range Tokenize(doc.Body) {
+		weights[t] += bodyWeight
+	}
+	for _, c := range doc.Comments {
+		for _, t := range Tokenize(c) {
+			weights[t] += commentWeight
+		}
+	}
+	e := &entry{
+		Board:     doc.Board,
+		PostID:    doc.PostID,
+		Title:     doc.Title,
+		Author:    doc.Author,
+		Tags:      doc.Tags,
+		CreatedAt: doc.CreatedAt,
+		terms:     make([]string, 0, len(weights)),
+	}
+	for t := range weights {
+		e.terms = append(e.terms, t)
+	}
+
+	key := docKey{doc.Board, doc.PostID}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if !replace {
+		if _, ok := ix.docs[key]; ok {
+			return
+		}
+		if _, ok := ix.removed[key]; ok {
+			return
+		}
+	}
+	if ix.source == nil {
+		e.doc = &doc
+	}
+	ix.remove(key)
+	ix.docs[key] = e
+	for t, w := range weights {
+		if ix.postings[t] == nil {
+			ix.postings[t] = make(map[docKey]float64)
+		}
+		ix.postings[t][key] = w
+	}
+}
+
+// Remove drops a post from the index.
+func (ix *Index) Remove(board string, postID int) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	key := docKey{board, postID}
+	ix.remove(key)
+	if ix.removed != nil {
+		ix.removed[key] = struct{}{}
+	}
+}
+
+// remove drops a document. Callers must hold ix.mu.
+func (ix *Index) remove(key docKey) {
+	e, ok := ix.docs[key]
+	if !ok {
+		return
+	}
+	for _, t := range e.terms {
+		delete(ix.postings[t], key)
+		if len(ix.postings[t]) == 0 {
+			delete(ix.postings, t)
+		}
+	}
+	delete(ix.docs, key)
+}
+
+// hit is a result with its text, once read.
+type hit struct {
+	Result
+	doc *Document
+}
+
+// Search returns posts matching q, best first. A limit of 0 returns all.
+func (ix *Index) Search(q Query, limit int) []Result {
+	if q.Empty() {
+		return nil
+	}
+	ix.mu.RLock()
+	var hits []hit
+	for key, e := range ix.candidates(q.Terms) {
+		if !q.matches(e) {
+			continue
+		}
+		score := 0.0
+		for _, t := range q.Terms {
+			score += ix.termScore(t, key)
+		}
+		score += phraseBonus * float64(len(q.Phrases))
+		hits = append(hits, hit{Result: Result{
+			Board:     e.Board,
+			PostID:    e.PostID,
+			Title:     e.Title,
+			Author:    e.Author,
+			CreatedAt: e.CreatedAt,
+			Score:     score,
+			Terms:     q.marks,
+		}, doc: e.doc})
+	}
+	source := ix.source
+	ix.mu.RUnlock()
+
+	// The text is read without ix.mu: the source takes board locks, and
+	// events update the index while holding them.
+	text := func(h *hit) bool {
+		if h.doc == nil && source != nil {
+			if doc, ok := source(h.Board, h.PostID); ok {
+				h.doc = &doc
+			}
+		}
+		return h.doc != nil
+	}
+	if len(q.Phrases) > 0 {
+		kept := hits[:0]
+		for _, h := range hits {
+			if text(&h) && hasPhrases(*h.doc, q.Phrases) {
+				kept = append(kept, h)
+			}
+		}
+		hits = kept
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		if a.Board != b.Board {
+			return a.Board < b.Board
+		}
+		return a.PostID > b.PostID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	out := make([]Result, len(hits))
+	for i := range hits {
+		if text(&hits[i]) {
+			hits[i].Snippet = snippet(*hits[i].doc, q.marks)
+		}
+		out[i] = hits[i].Result
+	}
+	return out
+}
+
+// candidates returns the documents containing every word, or every
+// document when the query only has filters. Callers must hold ix.mu.
+func (ix *Index) candidates(words []string) map[docKey]*entry {
+	out := make(map[docKey]*entry)
+	if len(words) == 0 {
+		for key, e := range ix.docs {
+			out[key] = e
+		}
+		return out
+	}
+	// Start from the rarest word to keep the intersection small.
+	rarest := words[0]
+	for _, w := range words[1:] {
+		if len(ix.postings[w]) < len(ix.postings[rarest]) {
+			rarest = w
+		}
+	}
+	for key := range ix.postings[rarest] {
+		all := true
+		for _, w := range words {
+			if _, ok := ix.postings[w][key]; !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			out[key] = ix.docs[key]
+		}
+	}
+	return out
+}
+
+// termScore is a BM25-style score: term frequency saturates, rare terms
+// weigh more. Callers must hold ix.mu.
+func (ix *Index) termScore(term string, key docKey) float64 {
+	const k = 1.2
+	tf := ix.postings[term][key]
+	df := float64(len(ix.postings[term]))
+	idf := math.Log(1 + (float64(len(ix.docs))-df+0.5)/(df+0.5))
+	return idf * tf * (k + 1) / (tf + k)
+}
+
+// matches applies the filters of q to e. Phrases need the text and are
+// checked by Search.
+func (q Query) matches(e *entry) bool {
+	if q.Author != "" && !strings.EqualFold(e.Author, q.Author) {
+		return false
+	}
+	if q.Board != "" && !strings.EqualFold(e.Board, q.Board) {
+		return false
+	}
+	if q.Tag != "" && !hasTag(e.Tags, q.Tag) {
+		return false
+	}
+	if !q.After.IsZero() && e.CreatedAt.Before(q.After) {
+		return false
+	}
+	if !q.Before.IsZero() && !e.CreatedAt.Before(q.Before) {
+		return false
+	}
+	return true
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPhrases reports whether doc has every phrase, each within one field
+// and starting at a word boundary. The last word may run on, so particles
+// and plurals still match.
+func hasPhrases(doc Document, phrases []string) bool {
+	fields := []string{normalize(doc.Title), normalize(doc.Body)}
+	for _, c := range doc.Comments {
+		fields = append(fields, normalize(c))
+	}
+	for _, phrase := range phrases {
+		found := false
+		for _, f := range fields {
+			if strings.Contains(" "+f, " "+phrase) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// snippet returns a short piece of the body, or a comment, around the first
+// word stem that matches. Without a match it returns the start of the body.
+func snippet(doc Document, words []string) string {
+	texts := append([]string{doc.Body}, doc.Comments...)
+	for _, text := range texts {
+		flat := strings.Join(strings.Fields(text), " ")
+		lower := strings.ToLower(flat)
+		for _, w := range words {
+			if i := wordIndex(lower, w); i >= 0 {
+				return excerpt(flat, utf8.RuneCountInString(lower[:i]))
+			}
+		}
+	}
+	return excerpt(strings.Join(strings.Fields(doc.Body), " "), 0)
+}
+
+// wordIndex returns the byte offset of the first place w starts a word in
+// text, or -1.
+func wordIndex(text, w string) int {
+	for from := 0; from < len(text); {
+		i := strings.Index(text[from:], w)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		// Korean compounds are written without spaces, so any syllable will do.
+		prev, _ := utf8.DecodeLastRuneInString(text[:i])
+		if i == 0 || isCJK(prev) || !unicode.IsLetter(prev) && !unicode.IsNumber(prev) {
+			return i
+		}
+		from = i + len(w)
+	}
+	return -1
+}
+
+// excerpt cuts about snippetRunes runes of text starting a little before
+// the rune at pos.
+func excerpt(text string, pos int) string {
+	runes := []rune(text)
+	pos = min(pos, len(runes))
+	start := max(pos-snippetRunes/3, 0)
+	end := min(start+snippetRunes, len(runes))
+	out := string(runes[start:end])
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(runes) {
+		out += "…"
+	}
+	return out
+}
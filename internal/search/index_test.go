@@ -0,0 +1,180 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+var day = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func mustParse(t *testing.T, input string) Query {
+	t.Helper()
+	q, err := ParseQuery(input)
+	if err != nil {
+		t.Fatalf("ParseQuery(%q): %v", input, err)
+	}
+	return q
+}
+
+func ids(results []Result) []int {
+	out := make([]int, len(results))
+	for i, r := range results {
+		out[i] = r.PostID
+	}
+	return out
+}
+
+func sampleIndex() *Index {
+	ix := NewIndex()
+	ix.Add(Document{Board: "general", PostID: 1, Title: "Release notes", Body: "The release is out. Notes follow.", Author: "alice", Tags: []string{"go"}, CreatedAt: day})
+	ix.Add(Document{Board: "general", PostID: 2, Title: "Lunch", Body: "Notes on the release of lunch plans", Author: "bob", CreatedAt: day.AddDate(0, 0, 1)})
+	ix.Add(Document{Board: "tech", PostID: 1, Title: "Compilers", Body: "Nothing here", Comments: []string{"see the release notes"}, Author: "carol", Tags: []string{"go", "tools"}, CreatedAt: day.AddDate(0, 0, 2)})
+	return ix
+}
+
+func TestSearchRanksTitleMatchesFirst(t *testing.T) {
+	ix := sampleIndex()
+	results := ix.Search(mustParse(t, "release notes"), 0)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Board != "general" || results[0].PostID != 1 {
+		t.Fatalf("title match should rank first, got %+v", results[0])
+	}
+	if last := results[2]; last.Board != "tech" {
+		t.Fatalf("comment-only match should rank last, got %+v", last)
+	}
+	if !strings.Contains(results[2].Snippet, "release notes") {
+		t.Fatalf("snippet should come from the comment: %q", results[2].Snippet)
+	}
+}
+
+func TestPhraseQueries(t *testing.T) {
+	ix := sampleIndex()
+	results := ix.Search(mustParse(t, `"release notes"`), 0)
+	if len(results) != 2 {
+		t.Fatalf("expected the title and the comment to match the phrase, got %+v", results)
+	}
+	for _, r := range results {
+		if r.Board == "general" && r.PostID == 2 {
+			t.Fatal("words out of order must not match a phrase")
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	ix := sampleIndex()
+	cases := map[string][]int{
+		"release author:bob":        {2},
+		"release board:tech":        {1},
+		"tag:tools":                 {1},
+		"tag:#go release":           {1, 1},
+		"on:2024-01-03":             {2},
+		"after:2024-01-03":          {1, 2},
+		"before:2024-01-03 release": {1},
+		"release author:nobody":     {},
+		"missingword board:general": {},
+	}
+	for input, want := range cases {
+		got := ids(ix.Search(mustParse(t, input), 0))
+		if len(got) != len(want) {
+			t.Errorf("%q: got %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`  "Hello,  World" Go by:@alice in:tech tag:#News since:2024-01-02 `)
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	if len(q.Phrases) != 1 || q.Phrases[0] != "hello world" {
+		t.Fatalf("unexpected phrases: %v", q.Phrases)
+	}
+	if strings.Join(q.Terms, " ") != "hello world go" {
+		t.Fatalf("unexpected terms: %v", q.Terms)
+	}
+	if q.Author != "alice" || q.Board != "tech" || q.Tag != "news" || !q.After.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected filters: %+v", q)
+	}
+
+	q, err = ParseQuery("go after:yesterday")
+	if err == nil {
+		t.Fatal("expected a bad date error")
+	}
+	if len(q.Terms) != 1 || !q.After.IsZero() {
+		t.Fatalf("the rest of the query should survive: %+v", q)
+	}
+	if q, _ := ParseQuery("   "); !q.Empty() {
+		t.Fatal("blank query should be empty")
+	}
+}
+
+func TestWatchKeepsIndexCurrent(t *testing.T) {
+	b := bbs.New(func() time.Time { return day })
+	old, _ := b.AddPost("general", "alice", "existing post", "about gophers")
+
+	ix := NewIndex()
+	ix.Watch(b)
+	if got := ix.Search(mustParse(t, "gophers"), 0); len(got) != 1 {
+		t.Fatalf("existing posts should be indexed, got %v", got)
+	}
+
+	post, _ := b.AddPost("tech", "bob", "new post", "about badgers")
+	if got := ix.Search(mustParse(t, "badgers"), 0); len(got) != 1 || got[0].PostID != post.ID {
+		t.Fatalf("new post not indexed: %v", got)
+	}
+	_, _ = b.AddComment("tech", post.ID, "carol", "wombats too", 0)
+	if got := ix.Search(mustParse(t, "wombats"), 0); len(got) != 1 {
+		t.Fatalf("comment not indexed: %v", got)
+	}
+	_ = b.DeletePost("general", old.ID, "alice")
+	if got := ix.Search(mustParse(t, "gophers"), 0); len(got) != 0 {
+		t.Fatalf("deleted post still found: %v", got)
+	}
+	if ix.Len() != 1 {
+		t.Fatalf("expected 1 document, got %d", ix.Len())
+	}
+}
+
+func TestBackfillDoesNotRestoreDeletedPosts(t *testing.T) {
+	ix := NewIndex()
+	ix.removed = make(map[docKey]struct{}) // as while Watch backfills
+	stale := Document{Board: "general", PostID: 1, Title: "deleted gophers", CreatedAt: day}
+
+	// The post is deleted after the backfill read it but before it was added.
+	ix.Remove("general", 1)
+	ix.add(stale, false)
+	if got := ix.Search(mustParse(t, "gophers"), 0); len(got) != 0 {
+		t.Fatalf("the backfill restored a deleted post: %v", got)
+	}
+	ix.add(Document{Board: "general", PostID: 2, Title: "live gophers", CreatedAt: day}, false)
+	if got := ix.Search(mustParse(t, "gophers"), 0); len(got) != 1 || got[0].PostID != 2 {
+		t.Fatalf("other posts should still be backfilled: %v", got)
+	}
+}
+
+func TestWatchReadsTextOnDemand(t *testing.T) {
+	store := bbs.PostFile{Dir: t.TempDir()}
+	seed := bbs.NewWithBoards(func() time.Time { return day }, []string{"general"}, nil, store)
+	_, _ = seed.AddPost("general", "alice", "zoo report", "the gophers dug a burrow")
+
+	b := bbs.NewWithBoards(func() time.Time { return day }, []string{"general"}, nil, store, bbs.WithBoardCache(100))
+	ix := NewIndex()
+	ix.Watch(b)
+	for key, e := range ix.docs {
+		if e.doc != nil {
+			t.Fatalf("%v: the index should not keep text once watching", key)
+		}
+	}
+	got := ix.Search(mustParse(t, `"dug a burrow"`), 0)
+	if len(got) != 1 || !strings.Contains(got[0].Snippet, "gophers dug") {
+		t.Fatalf("phrases and snippets should read the post back: %+v", got)
+	}
+	if got := ix.Search(mustParse(t, `"burrow dug"`), 0); len(got) != 0 {
+		t.Fatalf("words out of order must not match a phrase: %+v", got)
+	}
+}
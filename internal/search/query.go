@@ -0,0 +1,141 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrBadDate signals a date filter that is not YYYY-MM-DD.
+var ErrBadDate = errors.New("dates must look like 2006-01-02")
+
+// ParseDate reads a YYYY-MM-DD date filter as the start of that day in UTC,
+// the zone posts are dated in. Anything else is ErrBadDate.
+func ParseDate(value string) (time.Time, error) {
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, ErrBadDate
+	}
+	return day, nil
+}
+
+// Query is a parsed search. Terms and phrases must all match; filters
+// narrow the matches down.
+type Query struct {
+	Terms   []string // index terms, phrase words included
+	Phrases []string // normalized, see normalize
+	Author  string
+	Board   string
+	Tag     string
+	After   time.Time // inclusive
+	Before  time.Time // exclusive
+
+	marks []string // word stems to highlight
+}
+
+// Empty reports whether the query has nothing to search for or filter by.
+func (q Query) Empty() bool {
+	return len(q.Terms) == 0 && len(q.Phrases) == 0 && !q.filtered()
+}
+
+func (q Query) filtered() bool {
+	return q.Author != "" || q.Board != "" || q.Tag != "" || !q.After.IsZero() || !q.Before.IsZero()
+}
+
+// addText adds the terms of a query word or phrase.
+func (q *Query) addText(text string) {
+	terms, marks := queryTerms(text)
+	q.Terms = append(q.Terms, terms...)
+	q.marks = append(q.marks, marks...)
+}
+
+// ParseQuery reads a search such as
+//
+//	"release notes" go author:alice board:tech tag:go after:2024-01-01
+//
+// Quoted text is a phrase: its words must appear in that order, though a
+// word may carry an ending ("release note" finds "release notes", and
+// "검색 엔진" finds "검색 엔진은"). The filters are author:, board:, tag:, and
+// after:, before: and on: with YYYY-MM-DD dates. A bad date is reported as
+// an error, but the rest of the query is still returned.
+func ParseQuery(input string) (Query, error) {
+	var (
+		q    Query
+		errs []error
+	)
+	for _, field := range splitQuery(input) {
+		if strings.HasPrefix(field, `"`) {
+			if phrase := normalize(strings.Trim(field, `"`)); phrase != "" {
+				q.Phrases = append(q.Phrases, phrase)
+				q.addText(phrase)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(field, ":")
+		if ok && value != "" {
+			switch strings.ToLower(key) {
+			case "author", "by":
+				q.Author = strings.TrimPrefix(value, "@")
+				continue
+			case "board", "in":
+				q.Board = value
+				continue
+			case "tag":
+				q.Tag = strings.ToLower(strings.TrimPrefix(value, "#"))
+				continue
+			case "after", "since", "before", "on", "date":
+				day, err := ParseDate(value)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", key, err))
+					continue
+				}
+				switch strings.ToLower(key) {
+				case "after", "since":
+					q.After = day
+				case "before":
+					q.Before = day
+				default:
+					q.After, q.Before = day, day.AddDate(0, 0, 1)
+				}
+				continue
+			}
+		}
+		q.addText(field)
+	}
+	return q, errors.Join(errs...)
+}
+
+// splitQuery splits on whitespace, keeping quoted phrases together.
+func splitQuery(input string) []string {
+	var (
+		out     []string
+		current strings.Builder
+		quoted  bool
+	)
+	flush := func() {
+		if current.Len() > 0 {
+			out = append(out, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range input {
+		switch {
+		case r == '"':
+			if quoted {
+				current.WriteRune(r)
+				flush()
+			} else {
+				flush()
+				current.WriteRune(r)
+			}
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return out
+}
@@ -0,0 +1,205 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Korean attaches particles (조사) to the word they follow, so "검색은",
+// "검색을" and "검색에서" all mean 검색. Hangul is therefore indexed as
+// overlapping syllable pairs (bigrams), which match any word containing the
+// query no matter what follows it, and queries drop their particles before
+// being split the same way. Latin words are lower-cased and stemmed.
+
+// particles are common Korean particles and copula endings, longest first.
+var particles = []string{
+	"에서부터", "으로부터", "이었습니다",
+	"로부터", "에게서", "한테서", "에서는", "에서도", "으로는", "으로도", "에게는",
+	"까지는", "부터는", "이라고", "이라는", "입니다", "이에요", "이었다",
+	"라고", "라는", "처럼", "보다", "에서", "에게", "한테", "께서", "으로", "까지",
+	"부터", "마다", "조차", "마저", "밖에", "이나", "이랑", "하고", "라도", "이다",
+	"예요", "였다", "이요",
+	"은", "는", "이", "가", "을", "를", "의", "에", "와", "과", "도", "로", "만",
+	"나", "랑",
+}
+
+// segment is a run of letters and digits in one script.
+type segment struct {
+	text []rune
+	cjk  bool // Hangul, Han or Kana: split into bigrams
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Hangul, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// segments splits lower-cased text into words, also breaking where the
+// script changes, so "Go언어로" becomes "go" and "언어로".
+func segments(text string) []segment {
+	var (
+		out     []segment
+		current []rune
+		cjk     bool
+	)
+	flush := func() {
+		if len(current) > 0 {
+			out = append(out, segment{text: current, cjk: cjk})
+			current = nil
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			flush()
+			continue
+		}
+		if len(current) > 0 && isCJK(r) != cjk {
+			flush()
+		}
+		cjk = isCJK(r)
+		current = append(current, r)
+	}
+	flush()
+	return out
+}
+
+// Tokenize returns the index terms for a document's text.
+func Tokenize(text string) []string {
+	var (
+		out  []string
+		prev []rune // stem of the previous Hangul word, for bigrams across spaces
+	)
+	for _, seg := range segments(text) {
+		if !seg.cjk {
+			out = append(out, Stem(string(seg.text)))
+			prev = nil
+			continue
+		}
+		out = append(out, bigrams(seg.text)...)
+		// A one-syllable noun with a particle ("책을") is found by "책",
+		// "책이" and "책을", which queries reduce to "책". So is one behind
+		// a second particle: "국가는" is found by "국가", read as 국 + 가.
+		stem := stripParticle(seg.text, 1)
+		for _, word := range [][]rune{seg.text, stem} {
+			for _, s := range stems(word) {
+				if len(s) == 1 {
+					out = append(out, string(s))
+				}
+			}
+			if len(stem) == len(seg.text) {
+				break
+			}
+		}
+		// "검색 엔진" is also found by "검색엔진".
+		if len(prev) > 0 {
+			out = append(out, string([]rune{prev[len(prev)-1], seg.text[0]}))
+		}
+		prev = stem
+	}
+	return out
+}
+
+// queryTerms returns the terms to look up for a query's text, along with the
+// word stems to highlight in results.
+func queryTerms(text string) (terms, marks []string) {
+	for _, seg := range segments(text) {
+		if !seg.cjk {
+			stem := Stem(string(seg.text))
+			terms = append(terms, stem)
+			marks = append(marks, stem)
+			continue
+		}
+		// Strip down to a single syllable, so "책을" finds "책이". This
+		// also reads 국가 as 국 + 가, which Tokenize indexes to match.
+		stem := stripParticle(seg.text, 1)
+		terms = append(terms, bigrams(stem)...)
+		marks = append(marks, string(stem))
+	}
+	return terms, marks
+}
+
+// bigrams splits word into overlapping syllable pairs. A single syllable
+// is its own term.
+func bigrams(word []rune) []string {
+	if len(word) < 2 {
+		return []string{string(word)}
+	}
+	out := make([]string, 0, len(word)-1)
+	for i := 0; i+1 < len(word); i++ {
+		out = append(out, string(word[i:i+2]))
+	}
+	return out
+}
+
+// stems returns word without each particle it ends with.
+func stems(word []rune) [][]rune {
+	var out [][]rune
+	s := string(word)
+	for _, p := range particles {
+		if strings.HasSuffix(s, p) && len(p) < len(s) {
+			out = append(out, []rune(strings.TrimSuffix(s, p)))
+		}
+	}
+	return out
+}
+
+// stripParticle removes the longest particle word ends with, as long as at
+// least keep syllables remain.
+func stripParticle(word []rune, keep int) []rune {
+	for _, stem := range stems(word) {
+		if len(stem) >= keep {
+			return stem
+		}
+	}
+	return word
+}
+
+// Stem reduces an English word to a rough stem so that "release",
+// "releases" and "released" match each other. It is deliberately light:
+// plural and -ing/-ed endings and a final e.
+func Stem(word string) string {
+	if len(word) < 4 || !isASCIILetters(word) {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+	for _, suffix := range []string{"ing", "ed"} {
+		if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= 3 {
+			word = word[:len(word)-len(suffix)]
+			// running -> run, stopped -> stop
+			if n := len(word); word[n-1] == word[n-2] && !strings.ContainsRune("aeioulsz", rune(word[n-1])) {
+				word = word[:n-1]
+			}
+			break
+		}
+	}
+	if len(word) >= 5 && strings.HasSuffix(word, "e") {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+func isASCIILetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize lower-cases text and reduces everything but letters and digits
+// to single spaces, for phrase matching.
+func normalize(text string) string {
+	var words []string
+	for _, seg := range segments(text) {
+		words = append(words, string(seg.text))
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,108 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"release":  "releas",
+		"releases": "releas",
+		"released": "releas",
+		"notes":    "note",
+		"note":     "note",
+		"running":  "run",
+		"stopped":  "stop",
+		"queries":  "query",
+		"classes":  "class",
+		"status":   "status",
+		"go":       "go",
+		"v1.2":     "v1.2",
+	}
+	for in, want := range cases {
+		if got := Stem(in); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTokenizeSplitsScripts(t *testing.T) {
+	got := Tokenize("Go언어 3개")
+	want := []string{"go", "언어", "3", "개"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Tokenize = %v, want %v", got, want)
+	}
+}
+
+func TestKoreanQueriesIgnoreParticles(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(Document{Board: "general", PostID: 1, Title: "검색 기능 개선", Body: "이제 게시판에서 검색은 훨씬 빨라졌습니다."})
+	ix.Add(Document{Board: "general", PostID: 2, Title: "책을 샀다", Body: "국가는 데이터베이스를 관리한다"})
+	ix.Add(Document{Board: "general", PostID: 3, Title: "검색 엔진 비교"})
+	ix.Add(Document{Board: "general", PostID: 4, Title: "English only", Body: "nothing Korean here"})
+	ix.Add(Document{Board: "general", PostID: 5, Title: "새 책이 왔다"})
+	ix.Add(Document{Board: "general", PostID: 6, Title: "책 한 권", Body: "국은 식었다"})
+
+	cases := map[string][]int{
+		"검색":    {1, 3},
+		"검색을":   {1, 3},
+		"검색에서":  {1, 3},
+		"게시판":   {1},
+		"게시판으로": {1},
+		"책":     {2, 5, 6},
+		"책을":    {2, 5, 6},
+		"책이":    {2, 5, 6},
+		"책은":    {2, 5, 6},
+		// The price of one-syllable stems: 국가 also finds 국은.
+		"국가":         {2, 6},
+		"국가는":        {2},
+		"데이터베이스에서":   {2},
+		"검색엔진":       {3},
+		`"검색 엔진"`:    {3},
+		`"검색 기능은"`:   {},
+		`"기능 개선"`:    {1},
+		"검색 board:x": {},
+	}
+	for input, want := range cases {
+		q, _ := ParseQuery(input)
+		got := ids(ix.Search(q, 0))
+		if !sameIDs(got, want) {
+			t.Errorf("%q: got %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestEnglishQueriesAreStemmed(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(Document{Board: "general", PostID: 1, Title: "Releases", Body: "We released the release notes"})
+	for _, input := range []string{"release", "releasing", "note", `"release note"`} {
+		q, _ := ParseQuery(input)
+		if got := ix.Search(q, 0); len(got) != 1 {
+			t.Errorf("%q: expected a match, got %v", input, got)
+		}
+	}
+	q, _ := ParseQuery("release")
+	if got := ix.Search(q, 0)[0]; got.Snippet != "We released the release notes" || got.Terms[0] != "releas" {
+		t.Fatalf("unexpected snippet or terms: %+v", got)
+	}
+}
+
+func sameIDs(got, want []int) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[int]int)
+	for _, id := range got {
+		seen[id]++
+	}
+	for _, id := range want {
+		seen[id]--
+	}
+	for _, n := range seen {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
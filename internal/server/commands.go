@@ -0,0 +1,307 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+
+	"ag/internal/bbs"
+)
+
+// Exit statuses of non-interactive commands.
+const (
+	exitOK    = 0
+	exitError = 1 // the command failed, e.g. an unknown board or post
+	exitUsage = 2 // the command line was wrong
+)
+
+// maxCommandBody caps a post body read from stdin.
+const maxCommandBody = 1 << 20
+
+const commandUsage = `usage: ssh host <command> [--json]
+
+commands:
+  boards                          list boards with post and unread counts
+  ls <board> [--sort S] [-n N] [--after CURSOR]
+                                  list posts; S is oldest, newest, activity, comments or score
+  cat <board> <post id>           print a post and its comments
+  post <board> <title...> [--tags a,b]
+                                  write a post with the body read from stdin
+`
+
+var errUsage = errors.New("usage")
+
+// commandMiddleware answers sessions that run a command, such as
+// "ssh host ls general", without starting the TUI. Sessions without a
+// command fall through to the next handler.
+func commandMiddleware(board *bbs.BBS) wish.Middleware {
+	return func(next ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			args := s.Command()
+			if len(args) == 0 {
+				next(s)
+				return
+			}
+			c := command{board: board, user: sessionUser(s), in: s, out: s, errOut: s.Stderr()}
+			_ = s.Exit(c.run(args))
+		}
+	}
+}
+
+// command runs one non-interactive command for user.
+type command struct {
+	board  *bbs.BBS
+	user   string
+	in     io.Reader
+	out    io.Writer
+	errOut io.Writer
+	json   bool
+}
+
+func (c *command) run(args []string) int {
+	var err error
+	switch name, rest := args[0], args[1:]; name {
+	case "boards":
+		err = c.boards(rest)
+	case "ls":
+		err = c.ls(rest)
+	case "cat":
+		err = c.cat(rest)
+	case "post":
+		err = c.post(rest)
+	case "help", "--help", "-h":
+		fmt.Fprint(c.out, commandUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(c.errOut, "unknown command %q\n\n%s", name, commandUsage)
+		return exitUsage
+	}
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(c.errOut, "%v\n\n%s", err, commandUsage)
+		return exitUsage
+	default:
+		fmt.Fprintln(c.errOut, "error:", err)
+		return exitError
+	}
+}
+
+// parse parses flags anywhere among args up to a "--", adds --json, and
+// returns the positional arguments.
+func (c *command) parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&c.json, "json", false, "print JSON")
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		args = rest
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (c *command) writeJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (c *command) boards(args []string) error {
+	rest, err := c.parse(flag.NewFlagSet("boards", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return fmt.Errorf("%w: boards takes no arguments", errUsage)
+	}
+	boards := c.board.ListBoardsFor(c.user)
+	if c.json {
+		return c.writeJSON(boards)
+	}
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "BOARD\tPOSTS\tUNREAD\tSTATE")
+	for _, b := range boards {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", b.Name, b.PostCount, b.Unread, b.State)
+	}
+	return tw.Flush()
+}
+
+func parseSort(s string) (bbs.PostSort, bool) {
+	for _, sort := range bbs.PostSorts {
+		if sort.String() == s {
+			return sort, true
+		}
+	}
+	return "", false
+}
+
+func (c *command) ls(args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ContinueOnError)
+	sortName := fs.String("sort", "oldest", "")
+	limit := fs.Int("n", bbs.DefaultPageSize, "")
+	after := fs.String("after", "", "")
+	rest, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return fmt.Errorf("%w: ls <board>", errUsage)
+	}
+	sort, ok := parseSort(*sortName)
+	if !ok {
+		return fmt.Errorf("%w: unknown sort %q", errUsage, *sortName)
+	}
+	page, err := c.board.ListPostPage(rest[0], bbs.PageOptions{
+		ListPostsOptions: bbs.ListPostsOptions{Sort: sort},
+		After:            *after,
+		Limit:            *limit,
+	})
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(page)
+	}
+	reads := c.board.ReadState(c.user)
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tAUTHOR\tDATE\tCOMMENTS\tSCORE")
+	for _, p := range page.Posts {
+		title := p.Title
+		if reads.UnreadHeader(rest[0], p) {
+			title = "* " + title
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%+d\n", p.ID, title, p.Author, p.CreatedAt.Format("2006-01-02 15:04"), p.Comments, p.Score)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if page.Next != "" {
+		fmt.Fprintf(c.errOut, "%d of %d posts; next page: --after %s\n", len(page.Posts), page.Total, page.Next)
+	}
+	return nil
+}
+
+func (c *command) cat(args []string) error {
+	rest, err := c.parse(flag.NewFlagSet("cat", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 2 {
+		return fmt.Errorf("%w: cat <board> <post id>", errUsage)
+	}
+	id, err := strconv.Atoi(rest[1])
+	if err != nil {
+		return fmt.Errorf("%w: bad post id %q", errUsage, rest[1])
+	}
+	p, err := c.board.GetPost(rest[0], id)
+	if err != nil {
+		return err
+	}
+	// Reading a post here counts as reading it in the TUI.
+	_ = c.board.MarkRead(c.user, rest[0], p.ID, lastComment(p))
+	if c.json {
+		return c.writeJSON(p)
+	}
+
+	w := c.out
+	fmt.Fprintf(w, "# %s\n", p.Title)
+	fmt.Fprintf(w, "%s/%d by %s on %s, score %+d", rest[0], p.ID, p.Author, p.CreatedAt.Format(time.RFC3339), p.Score())
+	if p.Locked {
+		fmt.Fprint(w, ", locked")
+	}
+	fmt.Fprintln(w)
+	if len(p.Tags) > 0 {
+		fmt.Fprintf(w, "tags: #%s\n", strings.Join(p.Tags, " #"))
+	}
+	fmt.Fprintf(w, "\n%s\n", p.Signed())
+	if len(p.Attachments) > 0 {
+		fmt.Fprintln(w, "\nattachments:")
+		for _, att := range p.Attachments {
+			fmt.Fprintf(w, "  %s/%d/%s (%d bytes, %s)\n", rest[0], p.ID, att.Name, att.Size, att.Type)
+		}
+	}
+	for _, cm := range p.Comments {
+		indent := ""
+		if cm.ParentID > 0 {
+			indent = "  "
+		}
+		fmt.Fprintf(w, "\n%s--- #%d %s on %s, score %+d\n", indent, cm.ID, cm.Author, cm.CreatedAt.Format(time.RFC3339), cm.Score())
+		fmt.Fprintf(w, "%s%s\n", indent, strings.ReplaceAll(cm.Signed(), "\n", "\n"+indent))
+	}
+	return nil
+}
+
+func lastComment(p bbs.Post) int {
+	if n := len(p.Comments); n > 0 {
+		return p.Comments[n-1].ID
+	}
+	return 0
+}
+
+func (c *command) post(args []string) error {
+	fs := flag.NewFlagSet("post", flag.ContinueOnError)
+	tags := fs.String("tags", "", "")
+	rest, err := c.parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 2 {
+		return fmt.Errorf("%w: post <board> <title...> < body", errUsage)
+	}
+	// Posting would create a missing board; the TUI never does that.
+	if !c.boardExists(rest[0]) {
+		return bbs.ErrBoardNotFound
+	}
+	// ssh joins its arguments with spaces, so an unquoted title arrives
+	// split into words.
+	title := strings.Join(rest[1:], " ")
+	body, err := io.ReadAll(io.LimitReader(c.in, maxCommandBody+1))
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	if len(body) > maxCommandBody {
+		return fmt.Errorf("body is over %d bytes", maxCommandBody)
+	}
+	var tagList []string
+	if *tags != "" {
+		tagList = strings.Split(*tags, ",")
+	}
+	p, err := c.board.AddPost(rest[0], c.user, title, string(body), tagList...)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(p)
+	}
+	fmt.Fprintf(c.out, "posted %s/%d\n", rest[0], p.ID)
+	return nil
+}
+
+func (c *command) boardExists(name string) bool {
+	for _, b := range c.board.ListBoards() {
+		if b.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+// runCommand runs args as user with stdin and returns the exit status and
+// both outputs.
+func runCommand(board *bbs.BBS, user, stdin string, args ...string) (int, string, string) {
+	var out, errOut bytes.Buffer
+	c := command{board: board, user: user, in: strings.NewReader(stdin), out: &out, errOut: &errOut}
+	return c.run(args), out.String(), errOut.String()
+}
+
+func TestCommandsReadAndWritePosts(t *testing.T) {
+	board := bbs.NewWithBoards(func() time.Time { return time.Unix(0, 0).UTC() }, []string{"general", "tech"}, nil, nil)
+
+	code, out, errOut := runCommand(board, "alice", "Hello from a script.\n", "post", "general", "release", "notes", "--tags", "go,release")
+	if code != exitOK || out != "posted general/1\n" {
+		t.Fatalf("post: %d %q %q", code, out, errOut)
+	}
+	p, _ := board.GetPost("general", 1)
+	if p.Title != "release notes" || p.Author != "alice" || p.Content != "Hello from a script." || len(p.Tags) != 2 {
+		t.Fatalf("unexpected post %+v", p)
+	}
+
+	code, out, _ = runCommand(board, "bob", "", "boards")
+	if code != exitOK || !strings.Contains(out, "general  1      1       open") {
+		t.Fatalf("boards: %d\n%s", code, out)
+	}
+	code, out, _ = runCommand(board, "bob", "", "ls", "general")
+	if code != exitOK || !strings.Contains(out, "* release notes") {
+		t.Fatalf("ls should mark the post unread for bob: %d\n%s", code, out)
+	}
+
+	code, out, _ = runCommand(board, "bob", "", "cat", "--json", "general", "1")
+	var got bbs.Post
+	if code != exitOK || json.Unmarshal([]byte(out), &got) != nil || got.Title != "release notes" {
+		t.Fatalf("cat --json: %d\n%s", code, out)
+	}
+	code, out, _ = runCommand(board, "bob", "", "ls", "general", "--json")
+	var page bbs.PostPage
+	if code != exitOK || json.Unmarshal([]byte(out), &page) != nil || page.Total != 1 {
+		t.Fatalf("ls --json: %d\n%s", code, out)
+	}
+	if code, out, _ = runCommand(board, "bob", "", "ls", "general"); strings.Contains(out, "*") {
+		t.Fatalf("cat should have marked the post read: %d\n%s", code, out)
+	}
+	if code, out, _ = runCommand(board, "bob", "", "cat", "general", "1"); !strings.Contains(out, "# release notes\ngeneral/1 by alice") {
+		t.Fatalf("cat: %d\n%s", code, out)
+	}
+}
+
+func TestCommandExitStatuses(t *testing.T) {
+	board := bbs.NewWithBoards(nil, []string{"general"}, nil, nil)
+	cases := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"help"}, exitOK},
+		{[]string{"rm", "-rf"}, exitUsage},
+		{[]string{"ls"}, exitUsage},
+		{[]string{"ls", "general", "--sort", "random"}, exitUsage},
+		{[]string{"cat", "general", "one"}, exitUsage},
+		{[]string{"ls", "nowhere"}, exitError},
+		{[]string{"cat", "general", "7"}, exitError},
+		{[]string{"post", "nowhere", "title"}, exitError},
+		{[]string{"post", "general", "--", "-1", "for", "dashes"}, exitOK},
+	}
+	for _, c := range cases {
+		if code, _, errOut := runCommand(board, "alice", "", c.args...); code != c.want {
+			t.Errorf("%v: expected exit %d, got %d (%s)", c.args, c.want, code, errOut)
+		}
+	}
+	if got := board.ListBoards(); len(got) != 1 {
+		t.Fatalf("posting must not create boards, got %+v", got)
+	}
+}
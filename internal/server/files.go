@@ -0,0 +1,368 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish/scp"
+	"github.com/pkg/sftp"
+
+	"ag/internal/bbs"
+)
+
+// Attachments are addressed as <board>/<post id>/<file name>, both over SCP
+// and SFTP:
+//
+//	scp -P 2323 cat.png host:general/12/
+//	scp -P 2323 host:general/12/cat.png .
+
+var errNotAFile = errors.New("expected <board>/<post id>/<file>")
+
+// filePath is a parsed attachment path. Missing parts are left zero, so the
+// same type names boards and posts when browsing over SFTP.
+type filePath struct {
+	board  string
+	postID int
+	name   string
+}
+
+func parseFilePath(p string) (filePath, error) {
+	p = strings.Trim(path.Clean("/"+strings.TrimPrefix(p, "~")), "/")
+	if p == "" {
+		return filePath{}, nil
+	}
+	parts := strings.SplitN(p, "/", 3)
+	fp := filePath{board: parts[0]}
+	if len(parts) > 1 {
+		id, err := strconv.Atoi(parts[1])
+		if err != nil || id <= 0 {
+			return filePath{}, fmt.Errorf("%w: bad post id %q", errNotAFile, parts[1])
+		}
+		fp.postID = id
+	}
+	if len(parts) > 2 {
+		fp.name = parts[2]
+	}
+	return fp, nil
+}
+
+// parseFile parses p and requires it to name a file.
+func parseFile(p string) (filePath, error) {
+	fp, err := parseFilePath(p)
+	if err == nil && fp.name == "" {
+		err = errNotAFile
+	}
+	return fp, err
+}
+
+// sessionUser is the name a session acts as. It is only trustworthy when
+// the server was started WithPasswordAuth.
+func sessionUser(s ssh.Session) string {
+	if s.User() == "" {
+		return "guest"
+	}
+	return s.User()
+}
+
+// fileHandler serves attachments to scp.
+type fileHandler struct {
+	board *bbs.BBS
+}
+
+var _ scp.Handler = fileHandler{}
+
+func (h fileHandler) Glob(_ ssh.Session, pattern string) ([]string, error) {
+	fp, err := parseFile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	post, err := h.board.GetPost(fp.board, fp.postID)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, att := range post.Attachments {
+		if ok, _ := path.Match(fp.name, att.Name); ok {
+			out = append(out, path.Join(fp.board, strconv.Itoa(fp.postID), att.Name))
+		}
+	}
+	return out, nil
+}
+
+func (h fileHandler) WalkDir(ssh.Session, string, fs.WalkDirFunc) error {
+	return errors.New("recursive copies are not supported")
+}
+
+func (h fileHandler) NewDirEntry(ssh.Session, string) (*scp.DirEntry, error) {
+	return nil, errors.New("recursive copies are not supported")
+}
+
+func (h fileHandler) NewFileEntry(_ ssh.Session, name string) (*scp.FileEntry, func() error, error) {
+	fp, err := parseFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	att, data, err := h.board.OpenAttachment(fp.board, fp.postID, fp.name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &scp.FileEntry{
+		Name:     att.Name,
+		Filepath: name,
+		Mode:     0o644,
+		Size:     att.Size,
+		Reader:   bytes.NewReader(data),
+		Mtime:    att.CreatedAt.Unix(),
+		Atime:    att.CreatedAt.Unix(),
+	}, nil, nil
+}
+
+func (h fileHandler) Mkdir(ssh.Session, *scp.DirEntry) error {
+	return errors.New("uploads go to an existing post: <board>/<post id>/")
+}
+
+func (h fileHandler) Write(s ssh.Session, entry *scp.FileEntry) (int64, error) {
+	fp, err := parseFile(entry.Filepath)
+	if err != nil {
+		return 0, err
+	}
+	// Copying to board/id/new.png arrives as board/id/new.png/<local name>;
+	// the name given on the command line wins.
+	if before, _, ok := strings.Cut(fp.name, "/"); ok {
+		fp.name = before
+	}
+	if limits, ok := h.board.AttachmentLimits(); ok && entry.Size > limits.MaxSize {
+		return 0, bbs.ErrAttachmentTooLarge
+	}
+	att, err := h.board.Attach(fp.board, fp.postID, sessionUser(s), fp.name, entry.Reader)
+	if err != nil {
+		return 0, err
+	}
+	return att.Size, nil
+}
+
+// sftpHandler serves the same tree over SFTP: boards and posts are
+// directories, attachments are files.
+type sftpHandler struct {
+	board *bbs.BBS
+	user  string
+}
+
+func sftpSubsystem(board *bbs.BBS) ssh.SubsystemHandler {
+	return func(s ssh.Session) {
+		h := sftpHandler{board: board, user: sessionUser(s)}
+		srv := sftp.NewRequestServer(s, sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h})
+		// Closing the server closes the session, so the exit status goes first.
+		defer srv.Close()
+		if err := srv.Serve(); err != nil && !errors.Is(err, io.EOF) {
+			fmt.Fprintln(s.Stderr(), "sftp:", err)
+			_ = s.Exit(1)
+			return
+		}
+		_ = s.Exit(0)
+	}
+}
+
+// sftpError maps BBS errors to the ones the SFTP server knows how to report.
+func sftpError(err error) error {
+	switch {
+	case errors.Is(err, bbs.ErrBoardNotFound), errors.Is(err, bbs.ErrPostNotFound), errors.Is(err, bbs.ErrAttachmentNotFound):
+		return os.ErrNotExist
+	case errors.Is(err, bbs.ErrForbidden):
+		return sftp.ErrSSHFxPermissionDenied
+	}
+	return err
+}
+
+func (h sftpHandler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	fp, err := parseFile(r.Filepath)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	_, data, err := h.board.OpenAttachment(fp.board, fp.postID, fp.name)
+	if err != nil {
+		return nil, sftpError(err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+func (h sftpHandler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	fp, err := parseFile(r.Filepath)
+	if err != nil {
+		return nil, sftp.ErrSSHFxPermissionDenied
+	}
+	limits, ok := h.board.AttachmentLimits()
+	if !ok {
+		return nil, bbs.ErrAttachmentsDisabled
+	}
+	// Refuse before the client sends anything.
+	post, err := h.board.GetPost(fp.board, fp.postID)
+	if err != nil {
+		return nil, sftpError(err)
+	}
+	if post.Author != h.user {
+		return nil, sftp.ErrSSHFxPermissionDenied
+	}
+	return &upload{h: h, to: fp, max: limits.MaxSize}, nil
+}
+
+// upload collects an SFTP upload and attaches it when the file is closed.
+type upload struct {
+	h   sftpHandler
+	to  filePath
+	max int64
+	buf []byte
+}
+
+func (u *upload) WriteAt(p []byte, off int64) (int, error) {
+	end := off + int64(len(p))
+	if end > u.max {
+		return 0, bbs.ErrAttachmentTooLarge
+	}
+	if end > int64(len(u.buf)) {
+		u.buf = append(u.buf, make([]byte, end-int64(len(u.buf)))...)
+	}
+	return copy(u.buf[off:], p), nil
+}
+
+func (u *upload) Close() error {
+	_, err := u.h.board.Attach(u.to.board, u.to.postID, u.h.user, u.to.name, bytes.NewReader(u.buf))
+	return sftpError(err)
+}
+
+func (h sftpHandler) Filecmd(r *sftp.Request) error {
+	if r.Method == "Setstat" {
+		// Clients set times and modes after uploading; there are none to keep.
+		return nil
+	}
+	return sftp.ErrSSHFxPermissionDenied
+}
+
+func (h sftpHandler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	fp, err := parseFilePath(r.Filepath)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+	switch r.Method {
+	case "List":
+		infos, err := h.list(fp)
+		if err != nil {
+			return nil, sftpError(err)
+		}
+		return listerAt(infos), nil
+	case "Stat":
+		info, err := h.stat(fp)
+		if err != nil {
+			return nil, sftpError(err)
+		}
+		return listerAt{info}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+func (h sftpHandler) list(fp filePath) ([]os.FileInfo, error) {
+	var out []os.FileInfo
+	switch {
+	case fp.board == "":
+		for _, s := range h.board.ListBoards() {
+			out = append(out, fileInfo{name: s.Name, dir: true})
+		}
+	case fp.postID == 0:
+		posts, err := h.board.ListPosts(fp.board)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range posts {
+			out = append(out, fileInfo{name: strconv.Itoa(p.ID), dir: true, mod: p.CreatedAt})
+		}
+	case fp.name == "":
+		post, err := h.board.GetPost(fp.board, fp.postID)
+		if err != nil {
+			return nil, err
+		}
+		for _, att := range post.Attachments {
+			out = append(out, attachmentInfo(att))
+		}
+	default:
+		info, err := h.stat(fp)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+func (h sftpHandler) stat(fp filePath) (os.FileInfo, error) {
+	switch {
+	case fp.board == "":
+		return fileInfo{name: "/", dir: true}, nil
+	case fp.postID == 0:
+		for _, s := range h.board.ListBoards() {
+			if s.Name == fp.board {
+				return fileInfo{name: fp.board, dir: true}, nil
+			}
+		}
+		return nil, bbs.ErrBoardNotFound
+	}
+	post, err := h.board.GetPost(fp.board, fp.postID)
+	if err != nil {
+		return nil, err
+	}
+	if fp.name == "" {
+		return fileInfo{name: strconv.Itoa(post.ID), dir: true, mod: post.CreatedAt}, nil
+	}
+	for _, att := range post.Attachments {
+		if att.Name == fp.name {
+			return attachmentInfo(att), nil
+		}
+	}
+	return nil, bbs.ErrAttachmentNotFound
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(out []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(out, l[offset:])
+	if n < len(out) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// fileInfo is a directory or attachment in the SFTP tree.
+type fileInfo struct {
+	name string
+	size int64
+	mod  time.Time
+	dir  bool
+}
+
+func attachmentInfo(att bbs.Attachment) fileInfo {
+	return fileInfo{name: att.Name, size: att.Size, mod: att.CreatedAt}
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return fi.mod }
+func (fi fileInfo) IsDir() bool        { return fi.dir }
+func (fi fileInfo) Sys() any           { return nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"ag/internal/bbs"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestParseFilePath(t *testing.T) {
+	cases := []struct {
+		in   string
+		want filePath
+		ok   bool
+	}{
+		{"general/12/cat.png", filePath{"general", 12, "cat.png"}, true},
+		{"/general/12/", filePath{"general", 12, ""}, true},
+		{"~/general", filePath{board: "general"}, true},
+		{"general/../../etc/passwd", filePath{}, false},
+		{"general/twelve/cat.png", filePath{}, false},
+		{"", filePath{}, true},
+	}
+	for _, c := range cases {
+		got, err := parseFilePath(c.in)
+		if (err == nil) != c.ok || got != c.want {
+			t.Errorf("parseFilePath(%q) = %+v, %v", c.in, got, err)
+		}
+	}
+}
+
+// sftpClient serves board over an in-memory pipe as user.
+func sftpClient(t *testing.T, board *bbs.BBS, user string) *sftp.Client {
+	t.Helper()
+	server, client := net.Pipe()
+	h := sftpHandler{board: board, user: user}
+	srv := sftp.NewRequestServer(server, sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h})
+	go func() { _ = srv.Serve() }()
+	c, err := sftp.NewClientPipe(client, client)
+	if err != nil {
+		t.Fatalf("sftp client: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+		_ = srv.Close()
+	})
+	return c
+}
+
+func TestSFTPUploadsAndDownloadsAttachments(t *testing.T) {
+	board := bbs.NewWithBoards(func() time.Time { return time.Unix(0, 0) }, []string{"general"}, nil, nil,
+		bbs.WithAttachments(bbs.BlobFile{Dir: t.TempDir()}, bbs.AttachmentLimits{}))
+	post, _ := board.AddPost("general", "alice", "topic", "body")
+
+	alice := sftpClient(t, board, "alice")
+	f, err := alice.Create("/general/1/cat.png")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := f.Write(pngHeader); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	p, _ := board.GetPost("general", post.ID)
+	if len(p.Attachments) != 1 || p.Attachments[0].Type != "image/png" {
+		t.Fatalf("expected the upload to be attached, got %+v", p.Attachments)
+	}
+
+	bob := sftpClient(t, board, "bob")
+	if _, err := bob.Create("/general/1/dog.png"); !os.IsPermission(err) {
+		t.Fatalf("expected bob's upload to be refused, got %v", err)
+	}
+	entries, err := bob.ReadDir("/general/1")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "cat.png" {
+		t.Fatalf("ReadDir: %v, %v", entries, err)
+	}
+	r, err := bob.Open("/general/1/cat.png")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(data, pngHeader) {
+		t.Fatalf("download: %q, %v", data, err)
+	}
+	if _, err := bob.Stat("/general/1/missing.png"); !os.IsNotExist(err) {
+		t.Fatalf("expected a missing file, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"net"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/ssh"
@@ -9,29 +10,99 @@ import (
 	"github.com/charmbracelet/wish/activeterm"
 	"github.com/charmbracelet/wish/bubbletea"
 	"github.com/charmbracelet/wish/logging"
+	"github.com/charmbracelet/wish/scp"
 
 	"ag/internal/bbs"
+	"ag/internal/chat"
+	"ag/internal/search"
 	"ag/internal/ui"
+	"ag/internal/webhook"
 )
 
+// options holds optional server features.
+type options struct {
+	chat       *chat.Hub
+	search     *search.Index
+	publicHost string
+	webhooks   *webhook.Dispatcher
+	password   func(user, password string) bool
+}
+
+// Option configures optional server features.
+type Option func(*options)
+
+// WithChat shares the chat rooms of hub between all sessions.
+func WithChat(hub *chat.Hub) Option {
+	return func(o *options) {
+		o.chat = hub
+	}
+}
+
+// WithSearch answers searches in every session from ix.
+func WithSearch(ix *search.Index) Option {
+	return func(o *options) {
+		o.search = ix
+	}
+}
+
+// WithPublicHost names the host that download instructions tell scp to
+// connect to. By default it is the address the session connected to.
+func WithPublicHost(host string) Option {
+	return func(o *options) {
+		o.publicHost = host
+	}
+}
+
+// WithPasswordAuth makes clients log in with a password that check
+// accepts for their SSH username. Without it anyone may connect as any
+// user, so roles must not be granted.
+func WithPasswordAuth(check func(user, password string) bool) Option {
+	return func(o *options) {
+		o.password = check
+	}
+}
+
+// WithWebhooks lets admins read the delivery log of d in their sessions.
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(o *options) {
+		o.webhooks = d
+	}
+}
+
 // New creates a new SSH server configured with the BBS application.
-func New(addr string, hostKeyPath string, board *bbs.BBS) (*ssh.Server, error) {
-	s, err := wish.NewServer(
+func New(addr string, hostKeyPath string, board *bbs.BBS, opts ...Option) (*ssh.Server, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	sessions := NewRegistry(nil)
+	serverOpts := []ssh.Option{
 		wish.WithAddress(addr),
 		wish.WithHostKeyPath(hostKeyPath),
+		// scp and commands run before activeterm, which turns away sessions
+		// without a terminal.
 		wish.WithMiddleware(
-			bubbletea.Middleware(teaHandler(board)),
+			bubbletea.Middleware(teaHandler(board, sessions, o)),
 			activeterm.Middleware(),
+			commandMiddleware(board),
+			scp.Middleware(fileHandler{board}, fileHandler{board}),
 			logging.Middleware(),
 		),
-	)
+		wish.WithSubsystem("sftp", sftpSubsystem(board)),
+	}
+	if o.password != nil {
+		serverOpts = append(serverOpts, wish.WithPasswordAuth(func(ctx ssh.Context, password string) bool {
+			return o.password(ctx.User(), password)
+		}))
+	}
+	s, err := wish.NewServer(serverOpts...)
 	if err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
-func teaHandler(board *bbs.BBS) func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
+func teaHandler(board *bbs.BBS, sessions *Registry, o options) func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 	return func(s ssh.Session) (tea.Model, []tea.ProgramOption) {
 		_, _, active := s.Pty()
 		if !active {
@@ -39,12 +110,41 @@ func teaHandler(board *bbs.BBS) func(s ssh.Session) (tea.Model, []tea.ProgramOpt
 			return nil, nil
 		}
 
-		username := s.User()
-		if username == "" {
-			username = "guest"
-		}
+		username := sessionUser(s)
+
+		// Live updates and presence end with the session.
+		events, unsubscribe := board.Subscribe()
+		id := sessions.Add(username, s.RemoteAddr().String(), s.Context().ClientVersion())
+		_ = board.RecordSeen(username)
+		go func() {
+			<-s.Context().Done()
+			unsubscribe()
+			sessions.Remove(id)
+			_ = board.RecordSeen(username)
+		}()
 
-		m := ui.NewModel(board, username)
+		m := ui.NewModel(board, username,
+			ui.WithEvents(events),
+			ui.WithContext(s.Context()),
+			ui.WithChat(o.chat),
+			ui.WithSearch(o.search),
+			ui.WithPresence(sessionPresence{reg: sessions, id: id}),
+			ui.WithFileHost(fileHost(s, o.publicHost)),
+			ui.WithWebhooks(o.webhooks),
+		)
 		return m, []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
 	}
 }
+
+// fileHost returns the host:port scp should use to reach this server from
+// the client of s.
+func fileHost(s ssh.Session, publicHost string) string {
+	host, port, err := net.SplitHostPort(s.LocalAddr().String())
+	if err != nil {
+		return ""
+	}
+	if publicHost != "" {
+		host = publicHost
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"ag/internal/bbs"
+)
+
+func TestPasswordAuth(t *testing.T) {
+	board := bbs.NewWithBoards(func() time.Time { return time.Unix(0, 0).UTC() }, []string{"general"}, nil, nil)
+	check := func(user, password string) bool { return user == "alice" && password == "secret" }
+	s, err := New("127.0.0.1:0", filepath.Join(t.TempDir(), "host_key"), board, WithPasswordAuth(check))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(func() { _ = s.Close() })
+
+	dial := func(user, password string) (*gossh.Client, error) {
+		return gossh.Dial("tcp", l.Addr().String(), &gossh.ClientConfig{
+			User:            user,
+			Auth:            []gossh.AuthMethod{gossh.Password(password)},
+			HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+			Timeout:         5 * time.Second,
+		})
+	}
+	for _, c := range [][2]string{{"alice", "wrong"}, {"mallory", "secret"}, {"alice", ""}} {
+		if client, err := dial(c[0], c[1]); err == nil {
+			client.Close()
+			t.Fatalf("expected %s/%q to be refused", c[0], c[1])
+		}
+	}
+
+	client, err := dial("alice", "secret")
+	if err != nil {
+		t.Fatalf("expected alice to log in: %v", err)
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	defer session.Close()
+	out, err := session.Output("boards")
+	if err != nil || !strings.Contains(string(out), "general") {
+		t.Fatalf("boards: %q, %v", out, err)
+	}
+}
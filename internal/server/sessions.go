@@ -0,0 +1,122 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"ag/internal/ui"
+)
+
+// SessionInfo describes one connected SSH session.
+type SessionInfo struct {
+	ID            int
+	User          string
+	RemoteAddr    string
+	ClientVersion string
+	Board         string // what the session is looking at; empty on menus
+	ConnectedAt   time.Time
+	LastActive    time.Time
+}
+
+// Registry tracks every connected session.
+type Registry struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	sessions map[int]*SessionInfo
+	next     int
+}
+
+// NewRegistry returns an empty registry. now defaults to time.Now.
+func NewRegistry(now func() time.Time) *Registry {
+	if now == nil {
+		now = time.Now
+	}
+	return &Registry{now: now, sessions: make(map[int]*SessionInfo)}
+}
+
+// Add records a new session and returns its ID.
+func (r *Registry) Add(user, remoteAddr, clientVersion string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.next++
+	now := r.now()
+	r.sessions[r.next] = &SessionInfo{
+		ID:            r.next,
+		User:          user,
+		RemoteAddr:    remoteAddr,
+		ClientVersion: clientVersion,
+		ConnectedAt:   now,
+		LastActive:    now,
+	}
+	return r.next
+}
+
+// Remove forgets a session.
+func (r *Registry) Remove(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+}
+
+// Touch records activity on a session and what it is looking at.
+func (r *Registry) Touch(id int, board string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[id]; ok {
+		s.LastActive = r.now()
+		s.Board = board
+	}
+}
+
+// List returns all sessions ordered by user, then by connect time.
+func (r *Registry) List() []SessionInfo {
+	r.mu.Lock()
+	out := make([]SessionInfo, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		out = append(out, *s)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].User != out[j].User {
+			return out[i].User < out[j].User
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Count returns the number of connected sessions.
+func (r *Registry) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sessions)
+}
+
+// sessionPresence is one session's view of the registry.
+type sessionPresence struct {
+	reg *Registry
+	id  int
+}
+
+func (p sessionPresence) Online() []ui.OnlineSession {
+	sessions := p.reg.List()
+	out := make([]ui.OnlineSession, 0, len(sessions))
+	for _, s := range sessions {
+		out = append(out, ui.OnlineSession{
+			User:          s.User,
+			Board:         s.Board,
+			RemoteAddr:    s.RemoteAddr,
+			ClientVersion: s.ClientVersion,
+			ConnectedAt:   s.ConnectedAt,
+			LastActive:    s.LastActive,
+			Self:          s.ID == p.id,
+		})
+	}
+	return out
+}
+
+func (p sessionPresence) Touch(board string) {
+	p.reg.Touch(p.id, board)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistryTracksSessions(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	reg := NewRegistry(func() time.Time { return now })
+
+	bob := reg.Add("bob", "10.0.0.2:5000", "SSH-2.0-OpenSSH_9.6")
+	alice := reg.Add("alice", "10.0.0.1:4000", "SSH-2.0-Go")
+	if reg.Count() != 2 {
+		t.Fatalf("expected 2 sessions, got %d", reg.Count())
+	}
+
+	now = now.Add(time.Minute)
+	reg.Touch(bob, "tech")
+	list := reg.List()
+	if len(list) != 2 || list[0].User != "alice" || list[1].User != "bob" {
+		t.Fatalf("sessions should be sorted by user: %+v", list)
+	}
+	if got := list[1]; got.Board != "tech" || !got.LastActive.Equal(now) || got.ConnectedAt.Equal(now) {
+		t.Fatalf("touch not recorded: %+v", got)
+	}
+	if got := list[0]; got.RemoteAddr != "10.0.0.1:4000" || got.ClientVersion != "SSH-2.0-Go" {
+		t.Fatalf("connection details missing: %+v", got)
+	}
+
+	reg.Remove(alice)
+	reg.Touch(alice, "general") // touching a gone session is harmless
+	if list := reg.List(); len(list) != 1 || list[0].User != "bob" {
+		t.Fatalf("expected only bob online, got %+v", list)
+	}
+}
+
+func TestSessionPresenceMarksSelf(t *testing.T) {
+	reg := NewRegistry(nil)
+	id := reg.Add("alice", "", "")
+	reg.Add("alice", "", "")
+
+	p := sessionPresence{reg: reg, id: id}
+	p.Touch("general")
+	online := p.Online()
+	if len(online) != 2 || !online[0].Self || online[1].Self {
+		t.Fatalf("only the first session should be self: %+v", online)
+	}
+	if online[0].Board != "general" {
+		t.Fatalf("expected board general, got %q", online[0].Board)
+	}
+}
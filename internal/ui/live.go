@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ag/internal/bbs"
+)
+
+var errPostGone = errors.New("this post was just deleted")
+
+// eventMsg carries a board event into Update.
+type eventMsg bbs.Event
+
+// waitForEvent blocks until the next event arrives. It returns nil once
+// the subscription is closed, which ends the loop.
+func waitForEvent(ch <-chan bbs.Event) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		e, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return eventMsg(e)
+	}
+}
+
+// applyEvent refreshes whatever the event touches while keeping the
+// cursor on the same post.
+func (m *Model) applyEvent(e bbs.Event) {
+	m.refreshBoards()
+	if m.boardIdx >= len(m.boards) {
+		m.boardIdx = max(len(m.boards)-1, 0)
+	}
+
+	switch m.state {
+	case viewResults:
+		m.reloadResults()
+	case viewSaved:
+		m.refreshSaved()
+	case viewNotifications:
+		m.refreshNotifications()
+	case viewSearch:
+		if !m.globalInput.Focused() {
+			m.runSearch()
+		}
+	}
+
+	if e.Board != m.activeBoard {
+		return
+	}
+	m.refreshPostsKeepCursor()
+
+	if (m.state == viewPost || m.state == viewComments) && e.PostID == m.activePost.ID {
+		if e.Kind == bbs.EventDelete {
+			m.err = errPostGone
+			return
+		}
+		p, err := m.board.GetPost(m.activeBoard, m.activePost.ID)
+		if err != nil {
+			return
+		}
+		m.activePost = p
+		m.comments = p.Comments
+		if m.commentIdx >= len(m.comments) {
+			m.commentIdx = max(len(m.comments)-1, 0)
+		}
+		// Someone else's comment shows up on screen, so it counts as read.
+		m.markActiveRead()
+	}
+}
+
+// refreshPostsKeepCursor reloads the page of the post list on screen and
+// moves the cursor to wherever the selected post ended up.
+func (m *Model) refreshPostsKeepCursor() {
+	selected := -1
+	if m.postIdx < len(m.posts) {
+		selected = m.posts[m.postIdx].ID
+	}
+	m.loadPage()
+	for len(m.posts) == 0 && m.page > 0 {
+		// The rest of the list was deleted; step back to a page with posts.
+		m.page--
+		m.loadPage()
+	}
+
+	for i, p := range m.posts {
+		if p.ID == selected {
+			m.postIdx = i
+			return
+		}
+	}
+	if m.postIdx >= len(m.posts) {
+		m.postIdx = max(len(m.posts)-1, 0)
+	}
+}
@@ -1,12 +1,20 @@
 package ui
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"ag/internal/bbs"
+	"ag/internal/chat"
+	"ag/internal/search"
+	"ag/internal/webhook"
 )
 
 type sessionState int
@@ -17,6 +25,25 @@ const (
 	viewPost
 	viewCompose
 	viewComments
+	viewTags
+	viewResults
+	viewSaved
+	viewNotifications
+	viewInbox
+	viewConversation
+	viewDMCompose
+	viewChat
+	viewOnline
+	viewProfile
+	viewProfileEdit
+	viewTokens
+	viewSearch
+	viewWebhooks
+)
+
+var (
+	errNothingUnread = errors.New("no unread posts")
+	errPostDeleted   = errors.New("this post was deleted; press x to remove the bookmark")
 )
 
 type Model struct {
@@ -27,7 +54,8 @@ type Model struct {
 
 	state       sessionState
 	boards      []bbs.BoardSummary
-	posts       []bbs.Post
+	posts       []bbs.PostHeader // the page of the post list on screen
+	readState   bbs.ReadState
 	activeBoard string
 	activePost  bbs.Post
 
@@ -38,10 +66,15 @@ type Model struct {
 	// Pagination
 	page         int
 	postsPerPage int
+	pageCursors  []string             // cursor that starts each page visited so far
+	nextCursor   string               // cursor for the page after this one, if any
+	postTotal    int                  // posts in the list across all pages
+	listOpts     bbs.ListPostsOptions // sort and filters for the post list
 
 	// Components
 	viewport  viewport.Model
 	textInput textinput.Model // For title
+	tagInput  textinput.Model // For post tags
 	textarea  textarea.Model  // For post content
 	composing bool
 
@@ -49,20 +82,169 @@ type Model struct {
 	searchMode  bool
 	searchInput textinput.Model
 	searchQuery string
+	filterMode  bool
+	filterInput textinput.Model
+	index       *search.Index // nil falls back to substring matching
+
+	// Global search
+	globalInput textinput.Model
+	found       []search.Result
+	foundIdx    int
 
 	// Comments
 	comments    []bbs.Comment
 	commentIdx  int
 	commentMode bool // true when composing a comment, false for post
+	editingPost bool // viewCompose edits activePost instead of adding a post
+
+	// Tags
+	tags   []bbs.TagCount
+	tagIdx int
+
+	// Cross-board post lists (tag results)
+	results      []bbs.PostRef
+	resultIdx    int
+	resultsTitle string
+	resultsQuery func() []bbs.PostRef // reloads results after changes
+	postOrigin   sessionState         // where viewPost returns to
+
+	// Bookmarks
+	saved    []bbs.SavedPost
+	savedIdx int
+
+	// Notifications
+	notifications []bbs.Notification
+	noteIdx       int
+	unreadNotes   int
+
+	// Direct messages
+	convs      []bbs.ConversationSummary
+	convIdx    int
+	activeConv bbs.Conversation // zero while composing a new conversation
+	dmTo       textinput.Model
+	unreadDMs  int
+
+	// Live updates from other sessions
+	events <-chan bbs.Event
+
+	// Chat
+	chat       *chat.Hub
+	ctx        context.Context // ends with the session
+	chatClient *chat.Client
+	chatLog    []chat.Message
+	chatInput  textinput.Model
+	chatView   viewport.Model
+
+	// Profiles
+	profile       bbs.Profile
+	profilePosts  []bbs.PostRef
+	profileIdx    int
+	profileOrigin sessionState // where viewProfile returns to
+	profileName   textinput.Model
+	profileSig    textinput.Model
+	profileAppend bool
+
+	// API tokens
+	tokens      []bbs.APIToken
+	tokenIdx    int
+	tokenName   textinput.Model // focused while naming a new token
+	tokenSecret string          // the token just issued, shown until leaving the screen
+
+	// Webhook delivery log, for admins
+	webhooks *webhook.Dispatcher
+	hookLog  []webhook.Attempt
+	hookIdx  int
+
+	// Attachments
+	fileHost string // "host:port" that scp reaches this server on; empty hides the commands
+
+	// Who's online
+	presence  Presence
+	online    []OnlineSession
+	onlineIdx int
 
 	err error
 }
 
-func NewModel(board *bbs.BBS, username string) Model {
+// OnlineSession describes a connected session on the Who's online screen.
+type OnlineSession struct {
+	User          string
+	Board         string
+	RemoteAddr    string
+	ClientVersion string
+	ConnectedAt   time.Time
+	LastActive    time.Time
+	Self          bool // the session asking
+}
+
+// Presence lists connected sessions and records this session's activity.
+// The server implements it.
+type Presence interface {
+	Online() []OnlineSession
+	Touch(board string)
+}
+
+// Option configures optional Model behaviour.
+type Option func(*Model)
+
+// WithEvents makes the model refresh itself as events arrive on ch.
+func WithEvents(ch <-chan bbs.Event) Option {
+	return func(m *Model) {
+		m.events = ch
+	}
+}
+
+// WithSearch answers searches from ix.
+func WithSearch(ix *search.Index) Option {
+	return func(m *Model) {
+		m.index = ix
+	}
+}
+
+// WithChat enables the chat rooms of hub.
+func WithChat(hub *chat.Hub) Option {
+	return func(m *Model) {
+		m.chat = hub
+	}
+}
+
+// WithContext ties the session's lifetime to ctx, so chat rooms are left
+// when the connection drops.
+func WithContext(ctx context.Context) Option {
+	return func(m *Model) {
+		m.ctx = ctx
+	}
+}
+
+// WithPresence enables the Who's online screen and reports activity to p.
+func WithPresence(p Presence) Option {
+	return func(m *Model) {
+		m.presence = p
+	}
+}
+
+// WithFileHost shows scp commands for attachments that connect to hostport.
+func WithFileHost(hostport string) Option {
+	return func(m *Model) {
+		m.fileHost = hostport
+	}
+}
+
+// WithWebhooks lets admins read the delivery log of d.
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(m *Model) {
+		m.webhooks = d
+	}
+}
+
+func NewModel(board *bbs.BBS, username string, opts ...Option) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Title"
 	ti.Focus()
 
+	tg := textinput.New()
+	tg.Placeholder = "comma separated, e.g. go, release"
+
 	vp := viewport.New(fixedViewportWidth, fixedViewportHeight)
 
 	ta := textarea.New()
@@ -73,41 +255,235 @@ func NewModel(board *bbs.BBS, username string) Model {
 	si := textinput.New()
 	si.Placeholder = "Search posts..."
 
+	fi := textinput.New()
+	fi.Placeholder = "author:name since:2024-01-31 until:2024-02-29 commented"
+	fi.Width = fixedViewportWidth - 14
+
+	gi := textinput.New()
+	gi.Placeholder = `words, "a phrase", author:name board:name tag:name after:2024-01-31`
+	gi.Width = fixedViewportWidth - 14
+
+	to := textinput.New()
+	to.Placeholder = "usernames, e.g. alice, bob"
+
+	pn := textinput.New()
+	pn.Placeholder = "How your name is shown"
+	pn.CharLimit = 32
+
+	ps := textinput.New()
+	ps.Placeholder = "A short line for the end of your posts"
+	ps.CharLimit = 200
+	ps.Width = fixedViewportWidth - 14
+
+	ci := textinput.New()
+	ci.Placeholder = "Say something, or /help"
+	ci.CharLimit = 500
+	ci.Width = chatLogWidth + chatMembersWidth - 4
+
+	tn := textinput.New()
+	tn.Placeholder = "What the token is for, e.g. ci bot"
+	tn.CharLimit = 40
+
 	m := Model{
 		board:        board,
 		username:     username,
 		state:        viewBoards,
 		textInput:    ti,
+		tagInput:     tg,
 		viewport:     vp,
 		textarea:     ta,
 		searchInput:  si,
+		filterInput:  fi,
+		globalInput:  gi,
+		dmTo:         to,
+		chatInput:    ci,
+		profileName:  pn,
+		profileSig:   ps,
+		tokenName:    tn,
+		chatView:     viewport.New(chatLogWidth, chatLogHeight),
+		ctx:          context.Background(),
 		postsPerPage: 10,
+		postOrigin:   viewPosts,
+	}
+	for _, opt := range opts {
+		opt(&m)
 	}
 	m.refreshBoards()
 	return m
 }
 
 func (m Model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, waitForEvent(m.events))
 }
 
 // --- Logic Helpers ---
 
 func (m *Model) refreshBoards() {
-	m.boards = m.board.ListBoards()
+	m.boards = m.board.ListBoardsFor(m.username)
+	m.unreadNotes = m.board.UnreadNotifications(m.username)
+	m.unreadDMs = m.board.UnreadMessages(m.username)
 	if m.activeBoard == "" && len(m.boards) > 0 {
 		m.activeBoard = m.boards[0].Name
 	}
 }
 
+// refreshPosts loads the first page of the post list.
 func (m *Model) refreshPosts() {
-	posts, err := m.board.ListPosts(m.activeBoard)
+	m.page = 0
+	m.pageCursors = []string{""}
+	m.loadPage()
+}
+
+// loadPage fetches the current page of the post list.
+func (m *Model) loadPage() {
+	if m.page >= len(m.pageCursors) {
+		m.page, m.pageCursors = 0, []string{""}
+	}
+	page, err := m.board.ListPostPage(m.activeBoard, bbs.PageOptions{
+		ListPostsOptions: m.pageOptions(),
+		After:            m.pageCursors[m.page],
+		Limit:            m.postsPerPage,
+	})
 	if err != nil {
-		m.posts = nil
+		m.posts, m.nextCursor, m.postTotal = nil, "", 0
 		return
 	}
-	m.posts = posts
-	m.page = 0
+	m.posts, m.nextCursor, m.postTotal = page.Posts, page.Next, page.Total
+	m.readState = m.board.ReadState(m.username)
+}
+
+// pageOptions adds the search query, if there is one, to the sort and
+// filters picked for the list.
+func (m Model) pageOptions() bbs.ListPostsOptions {
+	opts := m.listOpts
+	if m.searchQuery == "" {
+		return opts
+	}
+	if m.index == nil {
+		query := strings.ToLower(m.searchQuery)
+		opts.Match = func(p bbs.Post) bool {
+			return strings.Contains(strings.ToLower(p.Title), query) ||
+				strings.Contains(strings.ToLower(p.Content), query)
+		}
+		return opts
+	}
+
+	// Bad dates are ignored while the query is still being typed.
+	q, _ := search.ParseQuery(m.searchQuery)
+	q.Board = m.activeBoard
+	hits := make(map[int]bool)
+	for _, r := range m.index.Search(q, 0) {
+		hits[r.PostID] = true
+	}
+	opts.Match = func(p bbs.Post) bool { return hits[p.ID] }
+	return opts
+}
+
+// totalPages counts the pages of the post list; an empty list has one.
+func (m Model) totalPages() int {
+	return max((m.postTotal+m.postsPerPage-1)/m.postsPerPage, 1)
+}
+
+// nextPage and prevPage move through the post list. Cursors of pages
+// already seen are kept, so going back does not rescan the board.
+func (m *Model) nextPage() {
+	if m.nextCursor == "" {
+		return
+	}
+	m.pageCursors = append(m.pageCursors[:m.page+1], m.nextCursor)
+	m.page++
+	m.postIdx = 0
+	m.loadPage()
+}
+
+func (m *Model) prevPage() {
+	if m.page == 0 {
+		return
+	}
+	m.page--
+	m.postIdx = 0
+	m.loadPage()
+}
+
+// reloadActivePost refetches the open post after it changed.
+func (m *Model) reloadActivePost() {
+	p, err := m.board.GetPost(m.activeBoard, m.activePost.ID)
+	if err != nil {
+		return
+	}
+	m.activePost = p
+	m.comments = p.Comments
+	m.replacePost(p)
+	m.markActiveRead()
+}
+
+// markActiveRead records the open post and its comments as read.
+func (m *Model) markActiveRead() {
+	lastComment := 0
+	if n := len(m.activePost.Comments); n > 0 {
+		lastComment = m.activePost.Comments[n-1].ID
+	}
+	if err := m.board.MarkRead(m.username, m.activeBoard, m.activePost.ID, lastComment); err != nil {
+		m.err = err
+	}
+	m.readState = m.board.ReadState(m.username)
+	m.refreshBoards()
+}
+
+// jumpToNextUnread opens the next unread post after the current one,
+// moving across boards as needed.
+func (m *Model) jumpToNextUnread() {
+	// From a list start at the top of the board; from a post continue after it.
+	board, fromPost := m.activeBoard, 0
+	switch m.state {
+	case viewBoards:
+		if len(m.boards) > 0 {
+			board = m.boards[m.boardIdx].Name
+		}
+	case viewPost:
+		fromPost = m.activePost.ID
+	}
+	next, ok := m.board.NextUnread(m.username, board, fromPost)
+	if !ok {
+		m.err = errNothingUnread
+		return
+	}
+	m.openPost(next.Board, next.Post, viewPosts)
+	// Keep the list cursor on the opened post so "back" lands on it, if it
+	// is on the page; unread posts elsewhere leave the cursor alone.
+	for i, p := range m.posts {
+		if p.ID == next.ID {
+			m.postIdx = i
+			break
+		}
+	}
+}
+
+func (m Model) unreadTotal() int {
+	total := 0
+	for _, b := range m.boards {
+		total += b.Unread
+	}
+	return total
+}
+
+func (m Model) activeBoardState() bbs.BoardState {
+	for _, b := range m.boards {
+		if b.Name == m.activeBoard {
+			return b.State
+		}
+	}
+	return bbs.BoardOpen
+}
+
+// replacePost updates the cached copy of p without moving the cursor.
+func (m *Model) replacePost(p bbs.Post) {
+	for i := range m.posts {
+		if m.posts[i].ID == p.ID {
+			m.posts[i] = p.Header()
+			return
+		}
+	}
 }
 
 func (m *Model) goBack() {
@@ -115,21 +491,176 @@ func (m *Model) goBack() {
 	case viewPosts:
 		m.state = viewBoards
 	case viewPost:
-		m.state = viewPosts
+		m.state = m.postOrigin
+		switch m.state {
+		case viewResults:
+			m.reloadResults()
+		case viewSaved:
+			m.refreshSaved()
+		case viewNotifications:
+			m.refreshNotifications()
+		case viewProfile:
+			m.refreshProfile()
+		case viewSearch:
+			m.runSearch()
+		}
 	case viewCompose:
 		m.state = viewPosts
 		m.composing = false
 	case viewComments:
 		m.state = viewPost
+	case viewTags:
+		m.state = viewBoards
+	case viewResults:
+		m.state = viewTags
+	case viewSaved, viewNotifications, viewInbox, viewOnline, viewSearch, viewWebhooks:
+		m.state = viewBoards
+	case viewConversation:
+		m.refreshInbox()
+		m.state = viewInbox
+	case viewChat:
+		m.leaveChat()
+	case viewProfile:
+		m.state = m.profileOrigin
+	case viewTokens:
+		m.tokenSecret = ""
+		m.state = viewProfile
 	}
 }
 
+// showResults switches to a cross-board post list produced by query.
+func (m *Model) showResults(title string, query func() []bbs.PostRef) {
+	m.resultsTitle = title
+	m.resultsQuery = query
+	m.resultIdx = 0
+	m.reloadResults()
+	m.state = viewResults
+}
+
+func (m *Model) reloadResults() {
+	if m.resultsQuery == nil {
+		return
+	}
+	m.results = m.resultsQuery()
+	if m.resultIdx >= len(m.results) {
+		m.resultIdx = max(len(m.results)-1, 0)
+	}
+}
+
+func (m *Model) refreshSaved() {
+	m.saved = m.board.ListBookmarks(m.username)
+	if m.savedIdx >= len(m.saved) {
+		m.savedIdx = max(len(m.saved)-1, 0)
+	}
+}
+
+func (m *Model) refreshNotifications() {
+	m.notifications = m.board.Notifications(m.username)
+	m.unreadNotes = m.board.UnreadNotifications(m.username)
+	if m.noteIdx >= len(m.notifications) {
+		m.noteIdx = max(len(m.notifications)-1, 0)
+	}
+}
+
+func (m *Model) refreshInbox() {
+	m.convs = m.board.Conversations(m.username)
+	m.unreadDMs = m.board.UnreadMessages(m.username)
+	if m.convIdx >= len(m.convs) {
+		m.convIdx = max(len(m.convs)-1, 0)
+	}
+}
+
+// openConversation shows a conversation scrolled to the newest message
+// and marks it read.
+func (m *Model) openConversation(id string) {
+	c, err := m.board.Conversation(m.username, id)
+	if err != nil {
+		m.err = err
+		return
+	}
+	if err := m.board.MarkConversationRead(m.username, id); err != nil {
+		m.err = err
+	}
+	m.unreadDMs = m.board.UnreadMessages(m.username)
+	m.activeConv = c
+	m.state = viewConversation
+	m.viewport.SetContent(m.renderConversation())
+	m.viewport.GotoBottom()
+}
+
+// startDM opens the message composer. Without an active conversation it
+// asks for recipients first.
+func (m *Model) startDM(newConversation bool) {
+	if newConversation {
+		m.activeConv = bbs.Conversation{}
+		m.dmTo.Reset()
+		m.dmTo.Focus()
+		m.textarea.Blur()
+	} else {
+		m.dmTo.Blur()
+		m.textarea.Focus()
+	}
+	m.textarea.Reset()
+	m.composing = true
+	m.state = viewDMCompose
+}
+
+// boardSubscribed reports whether the user follows the active board.
+func (m Model) boardSubscribed() bool {
+	for _, b := range m.boards {
+		if b.Name == m.activeBoard {
+			return b.Subscribed
+		}
+	}
+	return false
+}
+
+// toggleBoardSubscription follows or unfollows a board.
+func (m *Model) toggleBoardSubscription(board string, on bool) {
+	if err := m.board.SetBoardSubscription(m.username, board, on); err != nil {
+		m.err = err
+	}
+	m.refreshBoards()
+}
+
+// openPost shows a post from any board, returning to origin afterwards.
+func (m *Model) openPost(board string, p bbs.Post, origin sessionState) {
+	if board != m.activeBoard {
+		m.activeBoard = board
+		m.listOpts = bbs.ListPostsOptions{Sort: m.listOpts.Sort}
+		m.refreshPosts()
+	}
+	m.activePost = p
+	m.postOrigin = origin
+	m.state = viewPost
+	m.viewport.SetContent(m.renderPostContent())
+	m.viewport.GotoTop()
+	m.markActiveRead()
+}
+
+// startEdit opens the composer on the active post.
+func (m *Model) startEdit() {
+	m.state = viewCompose
+	m.composing = true
+	m.commentMode = false
+	m.editingPost = true
+	m.textInput.SetValue(m.activePost.Title)
+	m.textInput.Focus()
+	m.tagInput.SetValue(strings.Join(m.activePost.Tags, ", "))
+	m.tagInput.Blur()
+	m.textarea.SetValue(m.activePost.Content)
+	m.textarea.Blur()
+}
+
 func (m *Model) startCompose() {
 	m.state = viewCompose
 	m.composing = true
 	m.commentMode = false
+	m.editingPost = false
 	m.textInput.Reset()
 	m.textInput.Focus()
+	m.tagInput.Reset()
+	m.tagInput.Blur()
 	m.textarea.Reset()
 	m.textarea.Blur()
 }
@@ -11,9 +11,11 @@ const (
 	fixedViewportHeight = 20
 
 	// Board list table column widths
-	boardNameColWidth  = 72
-	boardPostsColWidth = 15
-	boardTableWidth    = boardNameColWidth + boardPostsColWidth + 2 // +2 for spacing
+	boardNameColWidth   = 45
+	boardPostsColWidth  = 15
+	boardUnreadColWidth = 10
+	boardStateColWidth  = 13
+	boardTableWidth     = boardNameColWidth + boardPostsColWidth + boardUnreadColWidth + boardStateColWidth + 6 // +6 for spacing
 )
 
 var (
@@ -69,6 +71,11 @@ var (
 				Bold(true).
 				Padding(0, 1)
 
+	styleTableUnread = lipgloss.NewStyle().
+				Foreground(colYellow).
+				Bold(true).
+				Padding(0, 1)
+
 	// Post Detail Styles - Enhanced
 	styleDetailBox = lipgloss.NewStyle().
 			Border(lipgloss.ThickBorder()).
@@ -146,6 +153,18 @@ var (
 	styleDivider = lipgloss.NewStyle().
 			Foreground(colDim)
 
+	styleLocked = lipgloss.NewStyle().
+			Foreground(colErr).
+			Bold(true)
+
+	styleMention = lipgloss.NewStyle().
+			Foreground(colPurple).
+			Bold(true)
+
+	styleMatch = lipgloss.NewStyle().
+			Foreground(colYellow).
+			Bold(true)
+
 	styleBadge = lipgloss.NewStyle().
 			Foreground(colBlack).
 			Background(colGreen).
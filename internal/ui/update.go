@@ -2,8 +2,8 @@ package ui
 
 import (
 	"ag/internal/bbs"
+	"ag/internal/chat"
 	"fmt"
-	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -25,6 +25,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c":
 			return m, tea.Quit
 		}
+		// Errors are shown until the next key press.
+		m.err = nil
+		// Key presses count as activity; report where the key led.
+		defer func() { m.touch() }()
+	case eventMsg:
+		m.applyEvent(bbs.Event(msg))
+		return m, waitForEvent(m.events)
+	case chatMsg:
+		if msg.client != m.chatClient {
+			return m, nil
+		}
+		m.appendChat(msg.msg)
+		return m, waitForChat(msg.client)
 	}
 
 	// Handle global keys if not composing or if composing but specific keys
@@ -62,6 +75,48 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case viewComments:
 		m, cmd = m.updateComments(msg)
 		cmds = append(cmds, cmd)
+	case viewTags:
+		m, cmd = m.updateTags(msg)
+		cmds = append(cmds, cmd)
+	case viewResults:
+		m, cmd = m.updateResults(msg)
+		cmds = append(cmds, cmd)
+	case viewSaved:
+		m, cmd = m.updateSaved(msg)
+		cmds = append(cmds, cmd)
+	case viewNotifications:
+		m, cmd = m.updateNotifications(msg)
+		cmds = append(cmds, cmd)
+	case viewInbox:
+		m, cmd = m.updateInbox(msg)
+		cmds = append(cmds, cmd)
+	case viewConversation:
+		m, cmd = m.updateConversation(msg)
+		cmds = append(cmds, cmd)
+	case viewDMCompose:
+		m, cmd = m.updateDMCompose(msg)
+		cmds = append(cmds, cmd)
+	case viewChat:
+		m, cmd = m.updateChat(msg)
+		cmds = append(cmds, cmd)
+	case viewOnline:
+		m, cmd = m.updateOnline(msg)
+		cmds = append(cmds, cmd)
+	case viewProfile:
+		m, cmd = m.updateProfile(msg)
+		cmds = append(cmds, cmd)
+	case viewProfileEdit:
+		m, cmd = m.updateProfileEdit(msg)
+		cmds = append(cmds, cmd)
+	case viewTokens:
+		m, cmd = m.updateTokens(msg)
+		cmds = append(cmds, cmd)
+	case viewSearch:
+		m, cmd = m.updateSearch(msg)
+		cmds = append(cmds, cmd)
+	case viewWebhooks:
+		m, cmd = m.updateWebhooks(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	return m, tea.Batch(cmds...)
@@ -82,19 +137,103 @@ func (m Model) updateBoards(msg tea.Msg) (Model, tea.Cmd) {
 		case "enter", "right", "l":
 			if len(m.boards) > 0 {
 				m.activeBoard = m.boards[m.boardIdx].Name
-				m.refreshPosts()
+				// Filters belong to one board; the sort carries over.
+				m.listOpts = bbs.ListPostsOptions{Sort: m.listOpts.Sort}
 				// Reset search when entering a new board
 				m.searchMode = false
 				m.searchQuery = ""
 				m.searchInput.SetValue("")
+				m.refreshPosts()
 				m.state = viewPosts
 				m.postIdx = 0
 			}
+		case "u":
+			m.jumpToNextUnread()
+		case "t":
+			m.tags = m.board.ListTags()
+			m.tagIdx = 0
+			m.state = viewTags
+		case "s":
+			m.savedIdx = 0
+			m.refreshSaved()
+			m.state = viewSaved
+		case "n":
+			m.noteIdx = 0
+			m.refreshNotifications()
+			m.state = viewNotifications
+		case "i":
+			m.convIdx = 0
+			m.refreshInbox()
+			m.state = viewInbox
+		case "c":
+			return m, m.joinChat(chat.DefaultRoom)
+		case "o":
+			if m.presence == nil {
+				m.err = errPresenceDisabled
+				break
+			}
+			m.onlineIdx = 0
+			m.refreshOnline()
+			m.state = viewOnline
+		case "p":
+			m.openProfile(m.username, viewBoards)
+		case "/":
+			m.openSearch()
+		case "f":
+			if len(m.boards) > 0 {
+				b := m.boards[m.boardIdx]
+				m.toggleBoardSubscription(b.Name, !b.Subscribed)
+			}
+		case "m":
+			// Moderators cycle the board through open, read-only and archived.
+			if len(m.boards) > 0 {
+				b := m.boards[m.boardIdx]
+				if err := m.board.SetBoardState(b.Name, nextBoardState(b.State), m.username); err != nil {
+					m.err = err
+				}
+				m.refreshBoards()
+			}
+		case "P":
+			// Moderators hide the board from the web mirror, or show it again.
+			if len(m.boards) > 0 {
+				b := m.boards[m.boardIdx]
+				if err := m.board.SetBoardPrivate(b.Name, !b.Private, m.username); err != nil {
+					m.err = err
+				}
+				m.refreshBoards()
+			}
+		case "W":
+			m.openWebhooks()
 		}
 	}
 	return m, nil
 }
 
+func voteValue(key string) int {
+	if key == "-" {
+		return -1
+	}
+	return 1
+}
+
+// reactionForKey maps the keys 1-6 onto bbs.Reactions.
+func reactionForKey(key string) string {
+	i := int(key[0] - '1')
+	if i < 0 || i >= len(bbs.Reactions) {
+		return ""
+	}
+	return bbs.Reactions[i]
+}
+
+func nextBoardState(s bbs.BoardState) bbs.BoardState {
+	for i, st := range bbs.BoardStates {
+		if st == s {
+			return bbs.BoardStates[(i+1)%len(bbs.BoardStates)]
+		}
+	}
+	return bbs.BoardOpen
+}
+
 func (m Model) updatePosts(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 
@@ -107,6 +246,8 @@ func (m Model) updatePosts(msg tea.Msg) (Model, tea.Cmd) {
 				m.searchMode = false
 				m.searchQuery = ""
 				m.searchInput.SetValue("")
+				m.refreshPosts()
+				m.postIdx = 0
 				return m, nil
 			case "enter":
 				m.searchMode = false
@@ -114,29 +255,15 @@ func (m Model) updatePosts(msg tea.Msg) (Model, tea.Cmd) {
 			}
 		}
 		m.searchInput, cmd = m.searchInput.Update(msg)
-		m.searchQuery = m.searchInput.Value()
-		return m, cmd
-	}
-
-	// Calculate display posts for navigation bounds
-	displayPosts := m.posts
-	if m.searchQuery != "" {
-		filtered := []bbs.Post{}
-		query := strings.ToLower(m.searchQuery)
-		for _, p := range m.posts {
-			if strings.Contains(strings.ToLower(p.Title), query) ||
-				strings.Contains(strings.ToLower(p.Content), query) {
-				filtered = append(filtered, p)
-			}
+		if q := m.searchInput.Value(); q != m.searchQuery {
+			m.searchQuery = q
+			m.refreshPosts()
+			m.postIdx = 0
 		}
-		displayPosts = filtered
+		return m, cmd
 	}
-
-	// Pagination bounds
-	start := m.page * m.postsPerPage
-	end := start + m.postsPerPage
-	if end > len(displayPosts) {
-		end = len(displayPosts)
+	if m.filterMode {
+		return m.updateListFilter(msg)
 	}
 
 	switch msg := msg.(type) {
@@ -146,31 +273,57 @@ func (m Model) updatePosts(msg tea.Msg) (Model, tea.Cmd) {
 			m.searchMode = true
 			m.searchInput.Focus()
 			return m, nil
+		case "u":
+			m.jumpToNextUnread()
+			return m, nil
+		case "f":
+			m.toggleBoardSubscription(m.activeBoard, !m.boardSubscribed())
+			return m, nil
+		case "A":
+			if err := m.board.MarkBoardRead(m.username, m.activeBoard); err != nil {
+				m.err = err
+			}
+			m.readState = m.board.ReadState(m.username)
+			m.refreshBoards()
+			return m, nil
+		case "o":
+			opts := m.listOpts
+			opts.Sort = nextPostSort(opts.Sort)
+			m.setListOptions(opts)
+			return m, nil
+		case "F":
+			m.startListFilter()
+			return m, nil
+		case "C":
+			opts := m.listOpts
+			opts.HasComments = !opts.HasComments
+			m.setListOptions(opts)
+			return m, nil
+		case "x":
+			if m.listOpts.Filtered() {
+				m.setListOptions(bbs.ListPostsOptions{Sort: m.listOpts.Sort})
+			}
+			return m, nil
 		case "up", "k":
-			if m.postIdx > start {
+			if m.postIdx > 0 {
 				m.postIdx--
 			}
 		case "down", "j":
-			if m.postIdx < end-1 {
+			if m.postIdx < len(m.posts)-1 {
 				m.postIdx++
 			}
 		case "n":
-			totalPages := (len(displayPosts) + m.postsPerPage - 1) / m.postsPerPage
-			if m.page < totalPages-1 {
-				m.page++
-				m.postIdx = m.page * m.postsPerPage
-			}
+			m.nextPage()
 		case "p":
-			if m.page > 0 {
-				m.page--
-				m.postIdx = m.page * m.postsPerPage
-			}
+			m.prevPage()
 		case "enter", "right", "l":
-			if len(displayPosts) > 0 && m.postIdx < len(displayPosts) {
-				m.activePost = displayPosts[m.postIdx]
-				m.state = viewPost
-				m.viewport.SetContent(m.renderPostContent())
-				m.viewport.GotoTop()
+			if m.postIdx < len(m.posts) {
+				p, err := m.board.GetPost(m.activeBoard, m.posts[m.postIdx].ID)
+				if err != nil {
+					m.err = err
+					break
+				}
+				m.openPost(m.activeBoard, p, viewPosts)
 			}
 		case "w":
 			m.state = viewCompose
@@ -196,9 +349,13 @@ func (m Model) updatePostView(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc", "b", "left":
-			m.state = viewPosts
+			m.goBack()
 			return m, nil
 		case "r":
+			if m.activePost.Locked {
+				m.err = bbs.ErrPostLocked
+				return m, nil
+			}
 			m.state = viewCompose
 			m.composing = true
 			m.commentMode = true
@@ -206,10 +363,60 @@ func (m Model) updatePostView(msg tea.Msg) (Model, tea.Cmd) {
 			m.textarea.SetValue("")
 			m.textarea.Focus()
 			return m, nil
+		case "a":
+			m.openProfile(m.activePost.Author, viewPost)
+			return m, nil
 		case "c":
 			m.state = viewComments
+			m.comments = m.activePost.Comments
+			m.commentIdx = 0
 			m.viewport.GotoTop()
 			return m, nil
+		case "u":
+			m.jumpToNextUnread()
+			return m, nil
+		case "+", "=", "-":
+			if _, err := m.board.Vote(m.activeBoard, m.activePost.ID, 0, m.username, voteValue(msg.String())); err != nil {
+				m.err = err
+			}
+			m.reloadActivePost()
+			return m, nil
+		case "1", "2", "3", "4", "5", "6":
+			emoji := reactionForKey(msg.String())
+			if _, err := m.board.React(m.activeBoard, m.activePost.ID, 0, m.username, emoji); err != nil {
+				m.err = err
+			}
+			m.reloadActivePost()
+			return m, nil
+		case "f":
+			on := !m.activePost.SubscribedBy(m.username)
+			if err := m.board.SetThreadSubscription(m.username, m.activeBoard, m.activePost.ID, on); err != nil {
+				m.err = err
+			}
+			m.reloadActivePost()
+			return m, nil
+		case "s":
+			if _, err := m.board.ToggleBookmark(m.username, m.activeBoard, m.activePost.ID); err != nil {
+				m.err = err
+			}
+			return m, nil
+		case "L":
+			// Moderators lock or unlock the thread.
+			locked := !m.activePost.Locked
+			if err := m.board.SetPostLocked(m.activeBoard, m.activePost.ID, locked, m.username); err != nil {
+				m.err = err
+			} else {
+				m.activePost.Locked = locked
+				m.replacePost(m.activePost)
+			}
+			return m, nil
+		case "E":
+			if m.activePost.Author != m.username {
+				m.err = bbs.ErrForbidden
+				return m, nil
+			}
+			m.startEdit()
+			return m, nil
 		case "d":
 			// Delete post
 			err := m.board.DeletePost(m.activeBoard, m.activePost.ID, m.username)
@@ -218,7 +425,7 @@ func (m Model) updatePostView(msg tea.Msg) (Model, tea.Cmd) {
 			} else {
 				m.refreshPosts()
 				m.refreshBoards()
-				m.state = viewPosts
+				m.goBack()
 			}
 			return m, nil
 		}
@@ -235,11 +442,18 @@ func (m Model) updateCompose(msg tea.Msg) (Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "tab":
-			// Toggle between title and content
-			if m.textInput.Focused() {
+			// Cycle title -> tags -> content; comments only have content
+			if m.commentMode {
+				return m, nil
+			}
+			switch {
+			case m.textInput.Focused():
 				m.textInput.Blur()
+				m.tagInput.Focus()
+			case m.tagInput.Focused():
+				m.tagInput.Blur()
 				m.textarea.Focus()
-			} else {
+			default:
 				m.textarea.Blur()
 				m.textInput.Focus()
 			}
@@ -260,14 +474,7 @@ func (m Model) updateCompose(msg tea.Msg) (Model, tea.Cmd) {
 					m.err = err
 				} else {
 					// Refresh post to show new comment
-					m.refreshPosts()
-					// Find and update activePost
-					for _, p := range m.posts {
-						if p.ID == m.activePost.ID {
-							m.activePost = p
-							break
-						}
-					}
+					m.reloadActivePost()
 					m.state = viewPost
 					m.composing = false
 					m.commentMode = false
@@ -278,7 +485,19 @@ func (m Model) updateCompose(msg tea.Msg) (Model, tea.Cmd) {
 					m.err = fmt.Errorf("title cannot be empty")
 					return m, nil
 				}
-				_, err := m.board.AddPost(m.activeBoard, m.username, title, content)
+				tags := bbs.ParseTags(m.tagInput.Value())
+				if m.editingPost {
+					if _, err := m.board.EditPost(m.activeBoard, m.activePost.ID, m.username, title, content, tags...); err != nil {
+						m.err = err
+						return m, nil
+					}
+					m.reloadActivePost()
+					m.state = viewPost
+					m.composing = false
+					m.editingPost = false
+					return m, nil
+				}
+				_, err := m.board.AddPost(m.activeBoard, m.username, title, content, tags...)
 				if err != nil {
 					m.err = err
 				} else {
@@ -292,8 +511,9 @@ func (m Model) updateCompose(msg tea.Msg) (Model, tea.Cmd) {
 		case "esc":
 			m.composing = false
 			// Return to appropriate view
-			if m.commentMode {
+			if m.commentMode || m.editingPost {
 				m.commentMode = false
+				m.editingPost = false
 				m.state = viewPost
 			} else {
 				m.state = viewPosts
@@ -303,9 +523,12 @@ func (m Model) updateCompose(msg tea.Msg) (Model, tea.Cmd) {
 	}
 
 	// Update the focused component
-	if m.textInput.Focused() {
+	switch {
+	case m.textInput.Focused():
 		m.textInput, cmd = m.textInput.Update(msg)
-	} else {
+	case m.tagInput.Focused():
+		m.tagInput, cmd = m.tagInput.Update(msg)
+	default:
 		m.textarea, cmd = m.textarea.Update(msg)
 	}
 
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ag/internal/chat"
+)
+
+// maxChatLog bounds the lines kept on screen; the room keeps its own history.
+const maxChatLog = 300
+
+var errChatDisabled = errors.New("chat is not available on this server")
+
+// chatMsg carries a room message into Update. client tells messages of a
+// room the user has since left apart from the current one.
+type chatMsg struct {
+	client *chat.Client
+	msg    chat.Message
+}
+
+// waitForChat blocks until the next message for c. It returns nil once c
+// has left its room, which ends the loop.
+func waitForChat(c *chat.Client) tea.Cmd {
+	if c == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg, ok := <-c.Messages()
+		if !ok {
+			return nil
+		}
+		return chatMsg{client: c, msg: msg}
+	}
+}
+
+// joinChat enters room, leaving the current one first.
+func (m *Model) joinChat(room string) tea.Cmd {
+	if m.chat == nil {
+		m.err = errChatDisabled
+		return nil
+	}
+	c, err := m.chat.Join(m.ctx, room, m.username)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if m.chatClient != nil {
+		m.chatClient.Leave()
+	}
+	m.chatClient = c
+	m.chatLog = c.History()
+	m.chatInput.Reset()
+	m.chatInput.Focus()
+	// Typing q or b should not leave the room.
+	m.composing = true
+	m.state = viewChat
+	m.refreshChatView()
+	return waitForChat(c)
+}
+
+func (m *Model) leaveChat() {
+	if m.chatClient != nil {
+		m.chatClient.Leave()
+		m.chatClient = nil
+	}
+	m.chatLog = nil
+	m.chatInput.Blur()
+	m.composing = false
+	m.state = viewBoards
+}
+
+// appendChat adds msg to the log and keeps the newest line in view.
+func (m *Model) appendChat(msg chat.Message) {
+	m.chatLog = append(m.chatLog, msg)
+	if n := len(m.chatLog); n > maxChatLog {
+		m.chatLog = append([]chat.Message(nil), m.chatLog[n-maxChatLog:]...)
+	}
+	m.refreshChatView()
+}
+
+func (m *Model) refreshChatView() {
+	atBottom := m.chatView.AtBottom() || m.chatView.TotalLineCount() == 0
+	m.chatView.SetContent(m.renderChatLog())
+	if atBottom {
+		m.chatView.GotoBottom()
+	}
+}
+
+func (m Model) updateChat(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+			return m, nil
+		case "pgup", "pgdown":
+			m.chatView, cmd = m.chatView.Update(msg)
+			return m, cmd
+		case "enter":
+			text := m.chatInput.Value()
+			m.chatInput.Reset()
+			if room, ok := chat.ParseJoin(text); ok {
+				return m, m.joinChat(room)
+			}
+			if err := m.chatClient.Say(text); err != nil && !errors.Is(err, chat.ErrEmptyMessage) {
+				m.err = err
+			}
+			return m, nil
+		}
+	}
+
+	m.chatInput, cmd = m.chatInput.Update(msg)
+	return m, cmd
+}
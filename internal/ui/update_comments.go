@@ -1,6 +1,10 @@
 package ui
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ag/internal/bbs"
+)
 
 func (m Model) updateComments(msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -13,12 +17,37 @@ func (m Model) updateComments(msg tea.Msg) (Model, tea.Cmd) {
 			if len(m.comments) > 0 {
 				m.commentIdx = (m.commentIdx - 1 + len(m.comments)) % len(m.comments)
 			}
+		case "a":
+			if len(m.comments) > 0 {
+				m.openProfile(m.comments[m.commentIdx].Author, viewComments)
+			}
 		case "down", "j":
 			if len(m.comments) > 0 {
 				m.commentIdx = (m.commentIdx + 1) % len(m.comments)
 			}
+		case "+", "=", "-":
+			if len(m.comments) > 0 {
+				c := m.comments[m.commentIdx]
+				if _, err := m.board.Vote(m.activeBoard, m.activePost.ID, c.ID, m.username, voteValue(msg.String())); err != nil {
+					m.err = err
+				}
+				m.reloadActivePost()
+			}
+		case "1", "2", "3", "4", "5", "6":
+			if len(m.comments) > 0 {
+				c := m.comments[m.commentIdx]
+				emoji := reactionForKey(msg.String())
+				if _, err := m.board.React(m.activeBoard, m.activePost.ID, c.ID, m.username, emoji); err != nil {
+					m.err = err
+				}
+				m.reloadActivePost()
+			}
 		case "r":
 			// Reply - add comment to current post
+			if m.activePost.Locked {
+				m.err = bbs.ErrPostLocked
+				return m, nil
+			}
 			m.commentMode = true
 			m.state = viewCompose
 			m.composing = true
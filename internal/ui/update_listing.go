@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ag/internal/bbs"
+	"ag/internal/search"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// filterDate is the layout for since: and until: in the post list filter,
+// which are read in UTC like search dates; see search.ParseDate.
+const filterDate = "2006-01-02"
+
+var errBadFilter = errors.New("filters: author:name since:YYYY-MM-DD until:YYYY-MM-DD commented")
+
+// nextPostSort returns the sort after s in bbs.PostSorts.
+func nextPostSort(s bbs.PostSort) bbs.PostSort {
+	for i, st := range bbs.PostSorts {
+		if st == s {
+			return bbs.PostSorts[(i+1)%len(bbs.PostSorts)]
+		}
+	}
+	return bbs.SortOldest
+}
+
+// parseListFilter reads the filter line typed in the post list into opts,
+// keeping its sort. until: includes the whole day.
+func parseListFilter(text string, opts bbs.ListPostsOptions) (bbs.ListPostsOptions, error) {
+	out := bbs.ListPostsOptions{Sort: opts.Sort}
+	for _, field := range strings.Fields(text) {
+		key, value, _ := strings.Cut(field, ":")
+		switch strings.ToLower(key) {
+		case "author", "by":
+			out.Author = strings.TrimPrefix(value, "@")
+		case "since", "after":
+			t, err := search.ParseDate(value)
+			if err != nil {
+				return opts, errBadFilter
+			}
+			out.Since = t
+		case "until", "before":
+			t, err := search.ParseDate(value)
+			if err != nil {
+				return opts, errBadFilter
+			}
+			out.Until = t.AddDate(0, 0, 1)
+		case "commented", "has":
+			if value != "" && value != "comments" {
+				return opts, errBadFilter
+			}
+			out.HasComments = true
+		default:
+			return opts, errBadFilter
+		}
+	}
+	return out, nil
+}
+
+// formatListFilter is the inverse of parseListFilter, used to prefill the
+// filter line and to label the active filters.
+func formatListFilter(opts bbs.ListPostsOptions) string {
+	var parts []string
+	if opts.Author != "" {
+		parts = append(parts, "author:"+opts.Author)
+	}
+	if !opts.Since.IsZero() {
+		parts = append(parts, "since:"+opts.Since.Format(filterDate))
+	}
+	if !opts.Until.IsZero() {
+		parts = append(parts, "until:"+opts.Until.AddDate(0, 0, -1).Format(filterDate))
+	}
+	if opts.HasComments {
+		parts = append(parts, "commented")
+	}
+	return strings.Join(parts, " ")
+}
+
+// setListOptions reloads the post list with opts and moves to its top.
+func (m *Model) setListOptions(opts bbs.ListPostsOptions) {
+	m.listOpts = opts
+	m.refreshPosts()
+	m.postIdx = 0
+}
+
+func (m *Model) startListFilter() {
+	m.filterInput.SetValue(formatListFilter(m.listOpts))
+	m.filterInput.CursorEnd()
+	m.filterInput.Focus()
+	m.filterMode = true
+	m.composing = true
+}
+
+func (m *Model) stopListFilter() {
+	m.filterInput.Blur()
+	m.filterMode = false
+	m.composing = false
+}
+
+// updateListFilter handles keys while the filter line is open.
+func (m Model) updateListFilter(msg tea.Msg) (Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc":
+			m.stopListFilter()
+			return m, nil
+		case "enter":
+			opts, err := parseListFilter(m.filterInput.Value(), m.listOpts)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.stopListFilter()
+			m.setListOptions(opts)
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	return m, cmd
+}
+
+// listBadges labels the active sort and filters for the post table.
+func (m Model) listBadges() []string {
+	badges := []string{"sort: " + m.listOpts.Sort.String()}
+	if m.listOpts.Filtered() {
+		badges = append(badges, fmt.Sprintf("filter: %s", formatListFilter(m.listOpts)))
+	}
+	return badges
+}
@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+func TestListFilterDatesAreUTC(t *testing.T) {
+	// Dates must not shift with the server's zone, as search dates do not.
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+	time.Local = time.FixedZone("KST", 9*60*60)
+
+	opts, err := parseListFilter("author:@alice since:2024-01-02 until:2024-01-03", bbs.ListPostsOptions{Sort: bbs.SortNewest})
+	if err != nil {
+		t.Fatalf("parseListFilter: %v", err)
+	}
+	if !opts.Since.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) || !opts.Until.Equal(time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected dates: since %v, until %v", opts.Since, opts.Until)
+	}
+	if opts.Author != "alice" || opts.Sort != bbs.SortNewest {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	if got := formatListFilter(opts); got != "author:alice since:2024-01-02 until:2024-01-03" {
+		t.Fatalf("formatListFilter = %q", got)
+	}
+	if _, err := parseListFilter("after:yesterday", opts); err == nil {
+		t.Fatal("expected a bad date error")
+	}
+}
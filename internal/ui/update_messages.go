@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m Model) updateInbox(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+		case "up", "k":
+			if m.convIdx > 0 {
+				m.convIdx--
+			}
+		case "down", "j":
+			if m.convIdx < len(m.convs)-1 {
+				m.convIdx++
+			}
+		case "enter", "right", "l":
+			if m.convIdx < len(m.convs) {
+				m.openConversation(m.convs[m.convIdx].ID)
+			}
+		case "w":
+			m.startDM(true)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateConversation(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+			return m, nil
+		case "r", "w":
+			m.startDM(false)
+			return m, nil
+		}
+	}
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateDMCompose(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	newConversation := m.activeConv.ID == ""
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "tab":
+			if !newConversation {
+				return m, nil
+			}
+			if m.dmTo.Focused() {
+				m.dmTo.Blur()
+				m.textarea.Focus()
+			} else {
+				m.textarea.Blur()
+				m.dmTo.Focus()
+			}
+			return m, nil
+		case "ctrl+s":
+			id := m.activeConv.ID
+			if newConversation {
+				var err error
+				id, err = m.board.StartConversation(m.username, splitRecipients(m.dmTo.Value()))
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+			}
+			if _, err := m.board.SendMessage(m.username, id, m.textarea.Value()); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.composing = false
+			m.refreshInbox()
+			m.openConversation(id)
+			return m, nil
+		case "esc":
+			m.composing = false
+			if newConversation {
+				m.state = viewInbox
+			} else {
+				m.state = viewConversation
+			}
+			return m, nil
+		}
+	}
+
+	if m.dmTo.Focused() {
+		m.dmTo, cmd = m.dmTo.Update(msg)
+	} else {
+		m.textarea, cmd = m.textarea.Update(msg)
+	}
+	return m, cmd
+}
+
+func splitRecipients(input string) []string {
+	return strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m Model) updateNotifications(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+		case "up", "k":
+			if m.noteIdx > 0 {
+				m.noteIdx--
+			}
+		case "down", "j":
+			if m.noteIdx < len(m.notifications)-1 {
+				m.noteIdx++
+			}
+		case "a":
+			if err := m.board.MarkNotificationRead(m.username, 0); err != nil {
+				m.err = err
+			}
+			m.refreshNotifications()
+		case "enter", "right", "l":
+			if m.noteIdx < len(m.notifications) {
+				n := m.notifications[m.noteIdx]
+				if err := m.board.MarkNotificationRead(m.username, n.ID); err != nil {
+					m.err = err
+				}
+				p, err := m.board.GetPost(n.Board, n.PostID)
+				if err != nil {
+					m.err = err
+					m.refreshNotifications()
+					return m, nil
+				}
+				m.openPost(n.Board, p, viewNotifications)
+			}
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var errPresenceDisabled = errors.New("who's online is not available")
+
+// location says what the session is looking at, for Who's online.
+func (m Model) location() string {
+	switch m.state {
+	case viewPosts, viewPost, viewCompose, viewComments:
+		return m.activeBoard
+	case viewChat:
+		if m.chatClient != nil {
+			return "#" + m.chatClient.Room()
+		}
+	}
+	return ""
+}
+
+// touch reports activity to the presence registry.
+func (m Model) touch() {
+	if m.presence != nil {
+		m.presence.Touch(m.location())
+	}
+}
+
+func (m Model) onlineCount() int {
+	if m.presence == nil {
+		return 0
+	}
+	return len(m.presence.Online())
+}
+
+func (m *Model) refreshOnline() {
+	if m.presence == nil {
+		m.online = nil
+		return
+	}
+	m.online = m.presence.Online()
+	if m.onlineIdx >= len(m.online) {
+		m.onlineIdx = max(len(m.online)-1, 0)
+	}
+}
+
+func (m Model) updateOnline(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+		case "up", "k":
+			if m.onlineIdx > 0 {
+				m.onlineIdx--
+			}
+		case "down", "j":
+			if m.onlineIdx < len(m.online)-1 {
+				m.onlineIdx++
+			}
+		case "r":
+			m.refreshOnline()
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,145 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ag/internal/bbs"
+)
+
+// profilePostLimit is how many recent posts the profile screen lists.
+const profilePostLimit = 10
+
+// openProfile shows the profile of user, returning to origin afterwards.
+func (m *Model) openProfile(user string, origin sessionState) {
+	m.profile = bbs.Profile{User: user}
+	m.profileIdx = 0
+	m.profileOrigin = origin
+	m.refreshProfile()
+	m.state = viewProfile
+}
+
+func (m *Model) refreshProfile() {
+	m.profile = m.board.Profile(m.profile.User)
+	m.profilePosts = m.board.ListPostsByAuthor(m.profile.User, profilePostLimit)
+	if m.profileIdx >= len(m.profilePosts) {
+		m.profileIdx = max(len(m.profilePosts)-1, 0)
+	}
+}
+
+// isOnline reports whether user has a connected session.
+func (m Model) isOnline(user string) bool {
+	if m.presence == nil {
+		return false
+	}
+	for _, s := range m.presence.Online() {
+		if s.User == user {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) startProfileEdit() {
+	m.profileName.SetValue(m.profile.DisplayName)
+	m.profileName.Focus()
+	m.profileSig.SetValue(m.profile.Signature)
+	m.profileSig.Blur()
+	m.textarea.SetValue(m.profile.Bio)
+	m.textarea.Blur()
+	m.profileAppend = m.profile.AppendSignature
+	m.composing = true
+	m.state = viewProfileEdit
+}
+
+func (m Model) updateProfile(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+		case "up", "k":
+			if m.profileIdx > 0 {
+				m.profileIdx--
+			}
+		case "down", "j":
+			if m.profileIdx < len(m.profilePosts)-1 {
+				m.profileIdx++
+			}
+		case "enter", "right", "l":
+			if m.profileIdx < len(m.profilePosts) {
+				r := m.profilePosts[m.profileIdx]
+				p, err := m.board.GetPost(r.Board, r.ID)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.openPost(r.Board, p, viewProfile)
+			}
+		case "e":
+			if m.profile.User == m.username {
+				m.startProfileEdit()
+			}
+		case "t":
+			if m.profile.User == m.username {
+				m.openTokens()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateProfileEdit(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "tab":
+			// Name -> signature -> bio -> name.
+			switch {
+			case m.profileName.Focused():
+				m.profileName.Blur()
+				m.profileSig.Focus()
+			case m.profileSig.Focused():
+				m.profileSig.Blur()
+				m.textarea.Focus()
+			default:
+				m.textarea.Blur()
+				m.profileName.Focus()
+			}
+			return m, nil
+		case "ctrl+t":
+			m.profileAppend = !m.profileAppend
+			return m, nil
+		case "ctrl+s":
+			err := m.board.UpdateProfile(m.username, bbs.ProfileEdit{
+				DisplayName:     m.profileName.Value(),
+				Bio:             m.textarea.Value(),
+				Signature:       m.profileSig.Value(),
+				AppendSignature: m.profileAppend,
+			})
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.composing = false
+			m.refreshProfile()
+			m.state = viewProfile
+			return m, nil
+		case "esc":
+			m.composing = false
+			m.state = viewProfile
+			return m, nil
+		}
+	}
+
+	switch {
+	case m.profileName.Focused():
+		m.profileName, cmd = m.profileName.Update(msg)
+	case m.profileSig.Focused():
+		m.profileSig, cmd = m.profileSig.Update(msg)
+	default:
+		m.textarea, cmd = m.textarea.Update(msg)
+	}
+	return m, cmd
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m Model) updateSaved(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+		case "up", "k":
+			if m.savedIdx > 0 {
+				m.savedIdx--
+			}
+		case "down", "j":
+			if m.savedIdx < len(m.saved)-1 {
+				m.savedIdx++
+			}
+		case "enter", "right", "l":
+			if m.savedIdx < len(m.saved) {
+				s := m.saved[m.savedIdx]
+				if s.Missing {
+					m.err = errPostDeleted
+					return m, nil
+				}
+				m.openPost(s.Board, s.Post, viewSaved)
+			}
+		case "x", "d":
+			if m.savedIdx < len(m.saved) {
+				s := m.saved[m.savedIdx]
+				if err := m.board.RemoveBookmark(m.username, s.Board, s.PostID); err != nil {
+					m.err = err
+				}
+				m.refreshSaved()
+			}
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ag/internal/search"
+)
+
+// searchLimit caps the results of a global search.
+const searchLimit = 100
+
+var errSearchDisabled = errors.New("search is not available on this server")
+
+// openSearch shows the global search screen with the query field focused.
+func (m *Model) openSearch() {
+	if m.index == nil {
+		m.err = errSearchDisabled
+		return
+	}
+	m.globalInput.Focus()
+	m.composing = true
+	m.state = viewSearch
+}
+
+// runSearch reruns the current query, keeping the cursor in range.
+func (m *Model) runSearch() {
+	q, err := search.ParseQuery(m.globalInput.Value())
+	if err != nil {
+		m.err = err
+	}
+	m.found = m.index.Search(q, searchLimit)
+	if m.foundIdx >= len(m.found) {
+		m.foundIdx = max(len(m.found)-1, 0)
+	}
+}
+
+func (m Model) updateSearch(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.globalInput.Focused() {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "esc":
+				if len(m.found) == 0 {
+					m.composing = false
+					m.goBack()
+					return m, nil
+				}
+				m.globalInput.Blur()
+				m.composing = false
+				return m, nil
+			case "enter":
+				m.foundIdx = 0
+				m.runSearch()
+				m.globalInput.Blur()
+				m.composing = false
+				return m, nil
+			}
+		}
+		m.globalInput, cmd = m.globalInput.Update(msg)
+		return m, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+		case "/":
+			m.globalInput.Focus()
+			m.composing = true
+		case "up", "k":
+			if m.foundIdx > 0 {
+				m.foundIdx--
+			}
+		case "down", "j":
+			if m.foundIdx < len(m.found)-1 {
+				m.foundIdx++
+			}
+		case "enter", "right", "l":
+			if m.foundIdx < len(m.found) {
+				r := m.found[m.foundIdx]
+				p, err := m.board.GetPost(r.Board, r.PostID)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.openPost(r.Board, p, viewSearch)
+			}
+		}
+	}
+	return m, nil
+}
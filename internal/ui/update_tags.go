@@ -0,0 +1,63 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ag/internal/bbs"
+)
+
+func (m Model) updateTags(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.state = viewBoards
+		case "up", "k":
+			if len(m.tags) > 0 {
+				m.tagIdx = (m.tagIdx - 1 + len(m.tags)) % len(m.tags)
+			}
+		case "down", "j":
+			if len(m.tags) > 0 {
+				m.tagIdx = (m.tagIdx + 1) % len(m.tags)
+			}
+		case "enter", "right", "l":
+			if len(m.tags) > 0 {
+				tag := m.tags[m.tagIdx].Tag
+				board := m.board
+				m.showResults("#"+tag, func() []bbs.PostRef {
+					return board.ListPostsByTag(tag)
+				})
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateResults(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+		case "up", "k":
+			if m.resultIdx > 0 {
+				m.resultIdx--
+			}
+		case "down", "j":
+			if m.resultIdx < len(m.results)-1 {
+				m.resultIdx++
+			}
+		case "enter", "right", "l":
+			if m.resultIdx < len(m.results) {
+				r := m.results[m.resultIdx]
+				p, err := m.board.GetPost(r.Board, r.ID)
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.openPost(r.Board, p, viewResults)
+			}
+		}
+	}
+	return m, nil
+}
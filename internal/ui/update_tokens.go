@@ -0,0 +1,79 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openTokens lists the user's API tokens.
+func (m *Model) openTokens() {
+	m.tokenIdx = 0
+	m.tokenSecret = ""
+	m.refreshTokens()
+	m.state = viewTokens
+}
+
+func (m *Model) refreshTokens() {
+	m.tokens = m.board.Tokens(m.username)
+	if m.tokenIdx >= len(m.tokens) {
+		m.tokenIdx = max(len(m.tokens)-1, 0)
+	}
+}
+
+func (m Model) updateTokens(msg tea.Msg) (Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if m.tokenName.Focused() {
+		if ok {
+			switch key.String() {
+			case "enter":
+				secret, _, err := m.board.IssueToken(m.username, m.tokenName.Value())
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.tokenSecret = secret
+				m.tokenName.Blur()
+				m.composing = false
+				m.refreshTokens()
+				m.tokenIdx = len(m.tokens) - 1
+				return m, nil
+			case "esc":
+				m.tokenName.Blur()
+				m.composing = false
+				return m, nil
+			}
+		}
+		var cmd tea.Cmd
+		m.tokenName, cmd = m.tokenName.Update(msg)
+		return m, cmd
+	}
+
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "esc":
+		m.goBack()
+	case "up", "k":
+		if m.tokenIdx > 0 {
+			m.tokenIdx--
+		}
+	case "down", "j":
+		if m.tokenIdx < len(m.tokens)-1 {
+			m.tokenIdx++
+		}
+	case "n":
+		m.tokenSecret = ""
+		m.tokenName.SetValue("")
+		m.tokenName.Focus()
+		m.composing = true
+	case "x":
+		if m.tokenIdx < len(m.tokens) {
+			if err := m.board.RevokeToken(m.username, m.tokens[m.tokenIdx].ID); err != nil {
+				m.err = err
+			}
+			m.tokenSecret = ""
+			m.refreshTokens()
+		}
+	}
+	return m, nil
+}
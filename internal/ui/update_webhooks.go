@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ag/internal/bbs"
+)
+
+var errWebhooksDisabled = errors.New("no webhooks are configured")
+
+// openWebhooks shows the webhook delivery log to admins.
+func (m *Model) openWebhooks() {
+	if m.webhooks == nil {
+		m.err = errWebhooksDisabled
+		return
+	}
+	if m.board.Role(m.username) != bbs.RoleAdmin {
+		m.err = fmt.Errorf("%w: only admins can read the webhook log", bbs.ErrForbidden)
+		return
+	}
+	m.hookIdx = 0
+	m.refreshWebhooks()
+	m.state = viewWebhooks
+}
+
+func (m *Model) refreshWebhooks() {
+	m.hookLog = m.webhooks.Log()
+	if m.hookIdx >= len(m.hookLog) {
+		m.hookIdx = max(len(m.hookLog)-1, 0)
+	}
+}
+
+func (m Model) updateWebhooks(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.goBack()
+		case "up", "k":
+			if m.hookIdx > 0 {
+				m.hookIdx--
+			}
+		case "down", "j":
+			if m.hookIdx < len(m.hookLog)-1 {
+				m.hookIdx++
+			}
+		case "r":
+			m.refreshWebhooks()
+		}
+	}
+	return m, nil
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"ag/internal/bbs"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -18,11 +19,26 @@ func (m Model) neonBanner(title, subtitle string) string {
 /_/ |_|/___/\____/____/___/  
 `)
 
+	status := styleBannerMeta.Render(fmt.Sprintf("unread: %d", m.unreadTotal()))
+	if n := m.unreadTotal(); n > 0 {
+		status = styleBadge.Render(fmt.Sprintf("%d unread", n))
+	}
+	if m.unreadNotes > 0 {
+		status += " " + styleBadge.Render(fmt.Sprintf("%d notifications", m.unreadNotes))
+	}
+	if m.unreadDMs > 0 {
+		status += " " + styleBadge.Render(fmt.Sprintf("%d messages", m.unreadDMs))
+	}
+	if n := m.onlineCount(); n > 0 {
+		status += " " + styleBannerMeta.Render(fmt.Sprintf("%d online", n))
+	}
+
 	info := lipgloss.JoinVertical(
 		lipgloss.Left,
 		styleBannerTitle.Render(title),
 		styleBannerMeta.Render(subtitle),
 		styleDivider.Render(strings.Repeat("-", 34)),
+		status,
 	)
 
 	return lipgloss.JoinHorizontal(lipgloss.Left, art, info)
@@ -60,12 +76,42 @@ func (m Model) View() string {
 	switch m.state {
 	case viewBoards:
 		s = m.viewBoards()
-	case viewPosts, viewPost:
+	case viewPosts:
 		s = m.viewPosts()
+	case viewPost:
+		s = m.viewPostDetail()
 	case viewCompose:
 		s = m.viewCompose()
 	case viewComments:
 		s = m.viewComments()
+	case viewTags:
+		s = m.viewTags()
+	case viewResults:
+		s = m.viewResults()
+	case viewSaved:
+		s = m.viewSaved()
+	case viewNotifications:
+		s = m.viewNotifications()
+	case viewInbox:
+		s = m.viewInbox()
+	case viewConversation:
+		s = m.viewConversation()
+	case viewDMCompose:
+		s = m.viewDMCompose()
+	case viewChat:
+		s = m.viewChat()
+	case viewOnline:
+		s = m.viewOnline()
+	case viewProfile:
+		s = m.viewProfile()
+	case viewProfileEdit:
+		s = m.viewProfileEdit()
+	case viewTokens:
+		s = m.viewTokens()
+	case viewSearch:
+		s = m.viewSearch()
+	case viewWebhooks:
+		s = m.viewWebhooks()
 	}
 
 	if m.err != nil {
@@ -89,58 +135,65 @@ func (m Model) viewBoards() string {
 	body.WriteString(badgeLine(
 		fmt.Sprintf("%d boards", len(m.boards)),
 		"enter -> open",
+		"t -> tags",
 		"q -> quit",
 	))
 	body.WriteString("\n\n")
 
-	body.WriteString(fmt.Sprintf("%s  %s\n",
+	body.WriteString(fmt.Sprintf("%s  %s  %s  %s\n",
 		styleTableHead.Width(boardNameColWidth).Render("Board Name"),
 		styleTableHead.Width(boardPostsColWidth).Render("Posts"),
+		styleTableHead.Width(boardUnreadColWidth).Render("Unread"),
+		styleTableHead.Width(boardStateColWidth).Render("State"),
 	))
 	body.WriteString(styleDim.Render(strings.Repeat("-", boardTableWidth)))
 	body.WriteString("\n")
 
 	for i, b := range m.boards {
 		style := styleTableRow
+		if b.Unread > 0 {
+			style = styleTableUnread
+		}
 		if i == m.boardIdx {
 			style = styleTableSelected
 		}
+		name := b.Name
+		if b.Private {
+			name += "  (private)"
+		}
+		if b.Subscribed {
+			name += "  (following)"
+		}
 
-		body.WriteString(fmt.Sprintf("%s  %s\n",
-			style.Width(boardNameColWidth).Render(b.Name),
+		body.WriteString(fmt.Sprintf("%s  %s  %s  %s\n",
+			style.Width(boardNameColWidth).Render(name),
 			style.Width(boardPostsColWidth).Render(fmt.Sprintf("%d posts", b.PostCount)),
+			style.Width(boardUnreadColWidth).Render(fmt.Sprintf("%d new", b.Unread)),
+			style.Width(boardStateColWidth).Render(b.State.String()),
 		))
 	}
 
+	help := "j/k: navigate • enter: select • /: search • u: next unread • f: follow • q: quit"
+	if m.board.Role(m.username).CanModerate() {
+		help += " • m: cycle state • P: private"
+	}
+	if m.webhooks != nil && m.board.Role(m.username) == bbs.RoleAdmin {
+		help += " • W: webhooks"
+	}
+	help += "\nt: tags • s: saved • n: notifications • i: messages • c: chat • o: online • p: profile"
 	s += framedSection("Board Radar", body.String())
-	s += "\n" + styleHelp.Render("j/k: navigate • enter: select • q: quit")
+	s += "\n" + styleHelp.Render(help)
 	return s
 }
 
 func (m Model) viewPosts() string {
-	// Filter posts if search query exists
-	displayPosts := m.posts
-	if m.searchQuery != "" {
-		filtered := []bbs.Post{}
-		query := strings.ToLower(m.searchQuery)
-		for _, p := range m.posts {
-			if strings.Contains(strings.ToLower(p.Title), query) ||
-				strings.Contains(strings.ToLower(p.Content), query) {
-				filtered = append(filtered, p)
-			}
-		}
-		displayPosts = filtered
-	}
+	totalPages := m.totalPages()
 
-	totalPages := (len(displayPosts) + m.postsPerPage - 1) / m.postsPerPage
-	if totalPages == 0 {
-		totalPages = 1
+	subtitle := fmt.Sprintf("%d post(s) • page %d/%d", m.postTotal, m.page+1, totalPages)
+	if state := m.activeBoardState(); state != bbs.BoardOpen {
+		subtitle += " • " + state.String()
 	}
-
-	header := m.neonBanner(
-		"Board: "+m.activeBoard,
-		fmt.Sprintf("%d post(s) • page %d/%d", len(displayPosts), m.page+1, totalPages),
-	)
+	header := m.neonBanner("Board: "+m.activeBoard, subtitle)
 
 	s := header + "\n" + m.accentBar() + "\n\n"
 
@@ -148,143 +201,191 @@ func (m Model) viewPosts() string {
 	if m.searchMode {
 		s += styleMetaLabel.Render("Search: ") + m.searchInput.View() + "\n\n"
 	}
+	if m.filterMode {
+		s += styleMetaLabel.Render("Filter: ") + m.filterInput.View() + "\n\n"
+	}
 
 	if m.searchQuery != "" && !m.searchMode {
-		s += styleCommentMeta.Render(fmt.Sprintf("Found %d result(s) for \"%s\"", len(displayPosts), m.searchQuery)) + "\n\n"
+		s += styleCommentMeta.Render(fmt.Sprintf("Found %d result(s) for \"%s\"", m.postTotal, m.searchQuery)) + "\n\n"
 	}
 
-	if len(displayPosts) == 0 {
+	if len(m.posts) == 0 {
 		s += framedSection("Posts Stream", styleDim.Render("No posts found.")) + "\n"
 		return s
 	}
 
-	// Pagination Logic
-	start := m.page * m.postsPerPage
-	end := start + m.postsPerPage
-	if end > len(displayPosts) {
-		end = len(displayPosts)
-	}
-	pagePosts := displayPosts[start:end]
-
-	// If in viewPost state, show full screen detail
-	if m.state == viewPost {
-		p := m.activePost
-
-		// Set viewport to fixed dimensions
-		m.viewport.Height = fixedViewportHeight
-		m.viewport.Width = fixedViewportWidth
-
-		// Build ALL content (post + comments) for viewport
-		var viewportContent strings.Builder
-
-		// Post content
-		viewportContent.WriteString(m.renderPostContent())
-
-		// Add comments section
-		if len(p.Comments) > 0 {
-			viewportContent.WriteString("\n\n")
-			viewportContent.WriteString(styleCommentSeparator.Render("--- Comments ---"))
-			viewportContent.WriteString("\n\n")
-
-			for i, c := range p.Comments {
-				indent := ""
-				prefix := "*"
-				if c.ParentID > 0 {
-					indent = "  "
-					prefix = ">"
-				}
-
-				// Comment header
-				commentHeader := fmt.Sprintf("%s%s %s",
-					indent,
-					prefix,
-					styleCommentAuthor.Render(c.Author),
-				)
-
-				// Comment content
-				commentBody := indent + "  " + styleCommentContent.Render(c.Content)
-
-				viewportContent.WriteString(commentHeader + "\n")
-				viewportContent.WriteString(commentBody + "\n")
-
-				// Add spacing between comments
-				if i < len(p.Comments)-1 {
-					viewportContent.WriteString(styleDim.Render(indent+"  -----") + "\n")
-				}
-			}
-		}
-
-		// Set viewport content
-		m.viewport.SetContent(viewportContent.String())
-
-		// Post metadata
-		commentCount := len(p.Comments)
-		meta := fmt.Sprintf("%s %s | %s %s | %s %d",
-			styleMetaLabel.Render("Author:"),
-			styleMetaValue.Render(p.Author),
-			styleMetaLabel.Render("Date:"),
-			styleMetaValue.Render(p.CreatedAt.Format("2006-01-02 15:04")),
-			styleMetaLabel.Render("Comments:"),
-			commentCount,
-		)
-
-		// Build detail view
-		detail := fmt.Sprintf("%s\n\n%s\n\n%s",
-			styleTitle.Render(p.Title),
-			meta,
-			m.viewport.View(),
-		)
-
-		// Render with fixed height
-		s += styleSectionTitle.Render("[Reading Signal]")
-		s += "\n" + styleDetailBox.Render(detail)
-		s += "\n" + styleHelp.Render("j/k: navigate • r: reply • c: comments • d: delete • b: back • q: quit")
-		return s
-	}
-
 	// Normal view
 	var table strings.Builder
-	table.WriteString(badgeLine(
+	badges := append([]string{
 		fmt.Sprintf("page %d/%d", m.page+1, totalPages),
-		fmt.Sprintf("%d posts", len(displayPosts)),
-		"w -> write",
-	))
+		fmt.Sprintf("%d posts", m.postTotal),
+	}, m.listBadges()...)
+	table.WriteString(badgeLine(append(badges, "w -> write")...))
 	table.WriteString("\n\n")
 
-	table.WriteString(fmt.Sprintf("  %s  %s  %s  %s\n",
+	table.WriteString(fmt.Sprintf("  %s  %s  %s  %s  %s  %s\n",
 		styleTableHead.Width(6).Render("ID"),
-		styleTableHead.Width(40).Render("Title"),
-		styleTableHead.Width(15).Render("Author"),
-		styleTableHead.Width(20).Render("Date"),
+		styleTableHead.Width(34).Render("Title"),
+		styleTableHead.Width(12).Render("Author"),
+		styleTableHead.Width(16).Render("Date"),
+		styleTableHead.Width(7).Render("Score"),
+		styleTableHead.Width(7).Render("React"),
 	))
 	table.WriteString(styleDim.Render(strings.Repeat("=", 85)))
 	table.WriteString("\n")
 
-	for i, p := range pagePosts {
+	for i, p := range m.posts {
 		style := styleTableRow
 		indicator := " "
-		currentIdx := start + i
-		if currentIdx == m.postIdx {
+		if m.readState.UnreadHeader(m.activeBoard, p) {
+			style = styleTableUnread
+			indicator = "*"
+		}
+		if i == m.postIdx {
 			style = styleTableSelected
 			indicator = ">"
 		}
 
 		title := p.Title
-		if len(title) > 35 {
-			title = title[:35] + "..."
+		if len(title) > 28 {
+			title = title[:28] + "..."
+		}
+		if p.Locked {
+			title = "[L] " + title
 		}
 
-		table.WriteString(fmt.Sprintf("%s %s %s %s %s\n",
+		table.WriteString(fmt.Sprintf("%s %s %s %s %s %s %s\n",
 			style.Render(indicator),
 			style.Width(6).Render(fmt.Sprintf("%d", p.ID)),
-			style.Width(40).Render(title),
-			style.Width(15).Render(p.Author),
-			style.Width(20).Render(p.CreatedAt.Format("06-01-02 15:04")),
+			style.Width(34).Render(title),
+			style.Width(12).Render(p.Author),
+			style.Width(16).Render(p.CreatedAt.Format("06-01-02 15:04")),
+			style.Width(7).Render(fmt.Sprintf("%+d", p.Score)),
+			style.Width(7).Render(fmt.Sprintf("%d", p.Reactions)),
 		))
 	}
 
 	s += framedSection("Posts Stream", table.String())
-	s += "\n" + styleHelp.Render(fmt.Sprintf("Page %d of %d • /: search • j/k: move • n/p: page • enter: read • w: write • b: back • q: quit", m.page+1, totalPages))
+	s += "\n" + styleHelp.Render("/: search • o: sort • F: filter • C: commented • x: clear filters • n/p: page\nu: unread • A: mark read • f: follow • w: write • b: back • q: quit")
+	return s
+}
+
+func (m Model) viewPostDetail() string {
+	subtitle := fmt.Sprintf("post #%d", m.activePost.ID)
+	if state := m.activeBoardState(); state != bbs.BoardOpen {
+		subtitle += " • " + state.String()
+	}
+	header := m.neonBanner("Board: "+m.activeBoard, subtitle)
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	p := m.activePost
+
+	// Set viewport to fixed dimensions
+	m.viewport.Height = fixedViewportHeight
+	m.viewport.Width = fixedViewportWidth
+
+	// Build ALL content (post + comments) for viewport
+	var viewportContent strings.Builder
+
+	// Post content
+	viewportContent.WriteString(m.renderPostContent())
+	if files := m.viewAttachments(p); files != "" {
+		viewportContent.WriteString("\n\n" + files)
+	}
+
+	// Add comments section
+	if len(p.Comments) > 0 {
+		viewportContent.WriteString("\n\n")
+		viewportContent.WriteString(styleCommentSeparator.Render("--- Comments ---"))
+		viewportContent.WriteString("\n\n")
+
+		for i, c := range p.Comments {
+			indent := ""
+			prefix := "*"
+			if c.ParentID > 0 {
+				indent = "  "
+				prefix = ">"
+			}
+
+			// Comment header
+			feedback := fmt.Sprintf("(%+d)", c.Score())
+			if c.ReactionCount() > 0 {
+				feedback += " " + reactionSummary(c.Reactions)
+			}
+			commentHeader := fmt.Sprintf("%s%s %s %s",
+				indent,
+				prefix,
+				styleCommentAuthor.Render(c.Author),
+				styleCommentMeta.Render(feedback),
+			)
+
+			// Comment content
+			commentBody := indent + "  " + highlightMentions(c.Signed(), c.Mentions, styleCommentContent)
+
+			viewportContent.WriteString(commentHeader + "\n")
+			viewportContent.WriteString(commentBody + "\n")
+
+			// Add spacing between comments
+			if i < len(p.Comments)-1 {
+				viewportContent.WriteString(styleDim.Render(indent+"  -----") + "\n")
+			}
+		}
+	}
+
+	// Set viewport content
+	m.viewport.SetContent(viewportContent.String())
+
+	// Post metadata
+	commentCount := len(p.Comments)
+	meta := fmt.Sprintf("%s %s | %s %s | %s %d",
+		styleMetaLabel.Render("Author:"),
+		styleMetaValue.Render(p.Author),
+		styleMetaLabel.Render("Date:"),
+		styleMetaValue.Render(p.CreatedAt.Format("2006-01-02 15:04")),
+		styleMetaLabel.Render("Comments:"),
+		commentCount,
+	)
+	if !p.EditedAt.IsZero() {
+		meta += " | " + styleMetaLabel.Render("Edited:") + " " + styleMetaValue.Render(p.EditedAt.Format("2006-01-02 15:04"))
+	}
+
+	if p.Locked {
+		meta += " | " + styleLocked.Render("LOCKED")
+	}
+	if m.board.IsBookmarked(m.username, m.activeBoard, p.ID) {
+		meta += " | " + styleMetaValue.Render("★ saved")
+	}
+	if p.SubscribedBy(m.username) {
+		meta += " | " + styleMetaValue.Render("following")
+	}
+	if len(p.Tags) > 0 {
+		meta += " | " + styleMetaLabel.Render("Tags:") + " " + styleMetaValue.Render("#"+strings.Join(p.Tags, " #"))
+	}
+	meta += fmt.Sprintf("\n%s %s | %s %s",
+		styleMetaLabel.Render("Score:"),
+		styleMetaValue.Render(fmt.Sprintf("%+d", p.Score())),
+		styleMetaLabel.Render("Reactions:"),
+		styleMetaValue.Render(reactionSummary(p.Reactions)),
+	)
+
+	// Build detail view
+	detail := fmt.Sprintf("%s\n\n%s\n\n%s",
+		styleTitle.Render(p.Title),
+		meta,
+		m.viewport.View(),
+	)
+
+	// Render with fixed height
+	s += styleSectionTitle.Render("[Reading Signal]")
+	s += "\n" + styleDetailBox.Render(detail)
+	actions := "r: reply • +/-: vote • 1-6: react • s: save • f: follow • d: delete"
+	if p.Author == m.username {
+		actions += " • E: edit"
+	}
+	if m.board.Role(m.username).CanModerate() {
+		actions += " • L: lock"
+	}
+	s += "\n" + styleHelp.Render("j/k: scroll • u: next unread • c: comments • a: author • b: back • q: quit\n"+actions)
 	return s
 }
 
@@ -334,14 +435,20 @@ func (m Model) viewPost() string {
 func (m Model) viewCompose() string {
 	header := m.neonBanner("Compose", "Markdown supported • save with Ctrl+S")
 
-	form := fmt.Sprintf("%s\n%s\n\n%s\n%s",
-		styleMetaLabel.Render("Title:"),
-		m.textInput.View(),
+	form := fmt.Sprintf("%s\n%s\n\n", styleMetaLabel.Render("Title:"), m.textInput.View())
+	if !m.commentMode {
+		form += fmt.Sprintf("%s\n%s\n\n", styleMetaLabel.Render("Tags:"), m.tagInput.View())
+	}
+	form += fmt.Sprintf("%s\n%s",
 		styleMetaLabel.Render("Content (Markdown supported):"),
 		m.textarea.View(),
 	)
 
-	body := framedSection("New Transmission", form)
+	section := "New Transmission"
+	if m.editingPost {
+		section = "Edit Transmission"
+	}
+	body := framedSection(section, form)
 	help := styleHelp.Render("Tab: switch fields • Ctrl+S: submit • Esc: cancel")
 
 	return fmt.Sprintf("%s\n%s\n\n%s\n\n%s",
@@ -353,7 +460,7 @@ func (m Model) viewCompose() string {
 }
 
 func (m Model) renderPostContent() string {
-	content := m.activePost.Content
+	content := m.activePost.Signed()
 
 	// Try to render as Markdown
 	renderer, err := glamour.NewTermRenderer(
@@ -364,7 +471,7 @@ func (m Model) renderPostContent() string {
 	if err == nil {
 		rendered, err := renderer.Render(content)
 		if err == nil {
-			return rendered
+			return highlightRendered(rendered, m.activePost.Mentions)
 		}
 	}
 
@@ -387,10 +494,11 @@ func (m Model) viewComments() string {
 	commentLines.WriteString(styleDim.Render(fmt.Sprintf("Total: %d comment(s)", len(m.comments))) + "\n\n")
 
 	// Table header
-	commentLines.WriteString(fmt.Sprintf("  %s  %s  %s\n",
+	commentLines.WriteString(fmt.Sprintf("  %s  %s  %s  %s\n",
 		styleTableHead.Width(5).Render("#"),
 		styleTableHead.Width(15).Render("Author"),
-		styleTableHead.Width(53).Render("Content"),
+		styleTableHead.Width(8).Render("Score"),
+		styleTableHead.Width(45).Render("Content"),
 	))
 	commentLines.WriteString(styleDim.Render(strings.Repeat("=", 75)) + "\n")
 
@@ -410,25 +518,29 @@ func (m Model) viewComments() string {
 			indent = "  "
 		}
 
-		lines := strings.Split(c.Content, "\n")
+		lines := strings.Split(c.Signed(), "\n")
 		for li, line := range lines {
 			line = strings.TrimRight(line, "\r")
-			rendered := style.Width(50 - len(indent) - 2).Render(line)
-			if len(rendered) > 50 {
-				rendered = rendered[:47] + "..."
+			width := 42 - len(indent) - 2
+			if r := []rune(line); len(r) > width-2 {
+				line = string(r[:width-5]) + "..."
 			}
+			rendered := mentionCell(line, c.Mentions, style, width)
 			num := ""
 			author := ""
+			score := ""
 			currIndicator := " "
 			if li == 0 {
 				num = fmt.Sprintf("%d", i+1)
 				author = c.Author
+				score = fmt.Sprintf("%+d/%d", c.Score(), c.ReactionCount())
 				currIndicator = indicator
 			}
-			commentLines.WriteString(fmt.Sprintf("%s %s  %s  %s%s %s\n",
+			commentLines.WriteString(fmt.Sprintf("%s %s  %s  %s  %s%s %s\n",
 				style.Render(currIndicator),
 				style.Width(5).Render(num),
 				style.Width(15).Render(author),
+				style.Width(8).Render(score),
 				indent,
 				prefix,
 				rendered,
@@ -443,6 +555,69 @@ func (m Model) viewComments() string {
 
 	// Build final view
 	s += framedSection("Thread", m.viewport.View())
-	s += "\n" + styleHelp.Render("j/k: navigate • r: reply • b: back • q: quit")
+	s += "\n" + styleHelp.Render("j/k: navigate • r: reply • +/-: vote • 1-6: react • a: author • b: back • q: quit")
 	return s
 }
+
+// highlightMentions renders text in base with known @mentions in styleMention.
+func highlightMentions(text string, mentions []string, base lipgloss.Style) string {
+	const mark = "\x00"
+	marked := bbs.ReplaceMentions(text, mentions, func(s string) string {
+		return mark + s + mark
+	})
+	if marked == text {
+		return base.Render(text)
+	}
+	var out strings.Builder
+	for i, part := range strings.Split(marked, mark) {
+		if part == "" {
+			continue
+		}
+		if i%2 == 1 {
+			out.WriteString(styleMention.Render(part))
+		} else {
+			out.WriteString(base.Render(part))
+		}
+	}
+	return out.String()
+}
+
+// highlightRendered colors known @mentions in output that has already been
+// rendered, where ANSI codes make bbs.ReplaceMentions unreliable.
+func highlightRendered(rendered string, mentions []string) string {
+	for _, name := range mentions {
+		re := regexp.MustCompile(`@` + regexp.QuoteMeta(name) + `([^\p{L}\p{N}_]|$)`)
+		rendered = re.ReplaceAllStringFunc(rendered, func(match string) string {
+			tail := match[len(name)+1:]
+			return styleMention.Render("@"+name) + tail
+		})
+	}
+	return rendered
+}
+
+// mentionCell renders a table cell like style.Width(width) would, with
+// mentions highlighted.
+func mentionCell(text string, mentions []string, style lipgloss.Style, width int) string {
+	plain := style.Width(width).Render(text)
+	base := style.UnsetPadding().UnsetWidth()
+	highlighted := highlightMentions(text, mentions, base)
+	if highlighted == base.Render(text) {
+		return plain
+	}
+	fill := lipgloss.Width(plain) - lipgloss.Width(highlighted) - 1
+	return base.Render(" ") + highlighted + base.Render(strings.Repeat(" ", max(fill, 0)))
+}
+
+// reactionSummary renders reaction counts in the fixed emoji order.
+func reactionSummary(reactions map[string][]string) string {
+	parts := make([]string, 0, len(bbs.Reactions))
+	for _, emoji := range bbs.Reactions {
+		if n := len(reactions[emoji]); n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", emoji, n))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, "  ")
+}
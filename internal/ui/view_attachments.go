@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"ag/internal/bbs"
+)
+
+// viewAttachments lists the files attached to p with the scp commands that
+// download them. The author also sees how to attach more.
+func (m Model) viewAttachments(p bbs.Post) string {
+	_, enabled := m.board.AttachmentLimits()
+	own := enabled && p.Author == m.username
+	if len(p.Attachments) == 0 && !own {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString(styleCommentSeparator.Render("--- Attachments ---"))
+	s.WriteString("\n\n")
+	remote := fmt.Sprintf("%s/%d/", m.activeBoard, p.ID)
+	for _, att := range p.Attachments {
+		s.WriteString(fmt.Sprintf("%s %s\n",
+			styleMetaValue.Render(att.Name),
+			styleCommentMeta.Render(fmt.Sprintf("(%s, %s)", formatSize(att.Size), att.Type)),
+		))
+		if cmd := m.scpCommand(remote+att.Name, false); cmd != "" {
+			s.WriteString(styleDim.Render("  "+cmd) + "\n")
+		}
+	}
+	if own {
+		if len(p.Attachments) > 0 {
+			s.WriteString("\n")
+		}
+		limits, _ := m.board.AttachmentLimits()
+		hint := fmt.Sprintf("Attach files up to %s (%s):", formatSize(limits.MaxSize), strings.Join(limits.Types, ", "))
+		s.WriteString(styleCommentMeta.Render(hint) + "\n")
+		if cmd := m.scpCommand(remote, true); cmd != "" {
+			s.WriteString(styleDim.Render("  "+cmd) + "\n")
+		}
+	}
+	return s.String()
+}
+
+// scpCommand builds the scp command line that downloads path from this
+// server, or uploads FILE to it. It is empty when the server address is
+// unknown.
+func (m Model) scpCommand(path string, upload bool) string {
+	if m.fileHost == "" {
+		return ""
+	}
+	host, port, err := net.SplitHostPort(m.fileHost)
+	if err != nil {
+		host, port = m.fileHost, ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	cmd := "scp "
+	if port != "" && port != "22" {
+		cmd += "-P " + port + " "
+	}
+	remote := shellQuote(fmt.Sprintf("%s@%s:%s", m.username, host, path))
+	if upload {
+		return cmd + "FILE " + remote
+	}
+	return cmd + remote + " ."
+}
+
+// shellQuote quotes s for a POSIX shell when it contains anything but
+// letters, digits and a few safe punctuation marks.
+func shellQuote(s string) string {
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./-_[]", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe && s != "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
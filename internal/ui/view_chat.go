@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"ag/internal/chat"
+)
+
+const (
+	chatLogWidth     = 66
+	chatLogHeight    = 16
+	chatMembersWidth = 18
+)
+
+// renderChatLog lays out the chat log, one message per line.
+func (m Model) renderChatLog() string {
+	lines := make([]string, 0, len(m.chatLog))
+	for _, msg := range m.chatLog {
+		stamp := styleCommentMeta.Render(msg.At.Format("15:04"))
+		var line string
+		switch msg.Kind {
+		case chat.KindAction:
+			line = styleMention.Render("* "+msg.Nick) + " " + styleCommentContent.Render(msg.Text)
+		case chat.KindSystem:
+			line = styleDim.Render("-- " + msg.Text)
+		default:
+			nick := styleCommentAuthor.Render(msg.Nick)
+			if msg.User == m.username {
+				nick = styleMetaValue.Render(msg.Nick)
+			}
+			line = nick + styleDim.Render(":") + " " + styleCommentContent.Render(msg.Text)
+		}
+		lines = append(lines, lipgloss.NewStyle().Width(chatLogWidth).Render(stamp+" "+line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) viewChat() string {
+	if m.chatClient == nil {
+		return ""
+	}
+	members := m.chatClient.Members()
+	header := m.neonBanner("Chat: #"+m.chatClient.Room(), fmt.Sprintf("%d here • you are %s", len(members), m.chatClient.Nick()))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	var who strings.Builder
+	who.WriteString(styleMetaLabel.Render("Here") + "\n")
+	for _, nick := range members {
+		if r := []rune(nick); len(r) > chatMembersWidth-2 {
+			nick = string(r[:chatMembersWidth-3]) + "…"
+		}
+		who.WriteString(styleNormal.Render("• "+nick) + "\n")
+	}
+
+	log := lipgloss.NewStyle().Width(chatLogWidth).Height(chatLogHeight).Render(m.chatView.View())
+	panel := lipgloss.JoinHorizontal(lipgloss.Top,
+		log,
+		styleDivider.Render(strings.Repeat("│\n", chatLogHeight-1)+"│"),
+		lipgloss.NewStyle().Width(chatMembersWidth).PaddingLeft(1).Render(who.String()),
+	)
+	panel += "\n" + styleDim.Render(strings.Repeat("-", chatLogWidth+chatMembersWidth+1)) + "\n" + m.chatInput.View()
+
+	s += framedSection("#"+m.chatClient.Room(), panel)
+	s += "\n" + styleHelp.Render("enter: send • /me /nick /who /join <room> • pgup/pgdn: scroll • esc: leave")
+	return s
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (m Model) viewInbox() string {
+	header := m.neonBanner("Direct Messages", fmt.Sprintf("%d conversation(s) • %d unread", len(m.convs), m.unreadDMs))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	if len(m.convs) == 0 {
+		s += framedSection("Inbox", styleDim.Render("No messages yet. Press w to write one.")) + "\n"
+		s += "\n" + styleHelp.Render("w: new message • b: back • q: quit")
+		return s
+	}
+
+	var table strings.Builder
+	table.WriteString(fmt.Sprintf("  %s  %s  %s  %s\n",
+		styleTableHead.Width(20).Render("With"),
+		styleTableHead.Width(40).Render("Last message"),
+		styleTableHead.Width(8).Render("New"),
+		styleTableHead.Width(16).Render("When"),
+	))
+	table.WriteString(styleDim.Render(strings.Repeat("=", 89)) + "\n")
+
+	start, end := listWindow(m.convIdx, len(m.convs), fixedViewportHeight-6)
+	for i := start; i < end; i++ {
+		c := m.convs[i]
+		style := styleTableRow
+		indicator := " "
+		if c.Unread > 0 {
+			style = styleTableUnread
+			indicator = "*"
+		}
+		if i == m.convIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		with := strings.Join(c.With(m.username), ", ")
+		if r := []rune(with); len(r) > 17 {
+			with = string(r[:15]) + "..."
+		}
+		last := c.Last.Author + ": " + strings.Join(strings.Fields(c.Last.Content), " ")
+		if r := []rune(last); len(r) > 35 {
+			last = string(r[:35]) + "..."
+		}
+		unread := ""
+		if c.Unread > 0 {
+			unread = fmt.Sprintf("%d", c.Unread)
+		}
+		table.WriteString(fmt.Sprintf("%s %s %s %s %s\n",
+			style.Render(indicator),
+			style.Width(20).Render(with),
+			style.Width(40).Render(last),
+			style.Width(8).Render(unread),
+			style.Width(16).Render(c.Last.CreatedAt.Format("06-01-02 15:04")),
+		))
+	}
+
+	s += framedSection("Inbox", table.String())
+	s += "\n" + styleHelp.Render("j/k: move • enter: open • w: new message • b: back • q: quit")
+	return s
+}
+
+// renderConversation lays out the messages of the active conversation.
+func (m Model) renderConversation() string {
+	var out strings.Builder
+	for i, msg := range m.activeConv.Messages {
+		author := styleCommentAuthor.Render(msg.Author)
+		if msg.Author == m.username {
+			author = styleMetaValue.Render("you")
+		}
+		out.WriteString(fmt.Sprintf("%s %s\n", author, styleCommentMeta.Render(msg.CreatedAt.Format("2006-01-02 15:04"))))
+		for _, line := range strings.Split(msg.Content, "\n") {
+			out.WriteString("  " + styleCommentContent.Render(line) + "\n")
+		}
+		if i < len(m.activeConv.Messages)-1 {
+			out.WriteString("\n")
+		}
+	}
+	return out.String()
+}
+
+func (m Model) viewConversation() string {
+	with := strings.Join(m.activeConv.With(m.username), ", ")
+	header := m.neonBanner("Chat with "+with, fmt.Sprintf("%d message(s) • private", len(m.activeConv.Messages)))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	m.viewport.Height = fixedViewportHeight
+	m.viewport.Width = fixedViewportWidth
+	s += framedSection("Conversation", m.viewport.View())
+	s += "\n" + styleHelp.Render("j/k: scroll • r: reply • b: back • q: quit")
+	return s
+}
+
+func (m Model) viewDMCompose() string {
+	title := "New Message"
+	var form string
+	if m.activeConv.ID == "" {
+		form = fmt.Sprintf("%s\n%s\n\n", styleMetaLabel.Render("To:"), m.dmTo.View())
+	} else {
+		title = "Reply to " + strings.Join(m.activeConv.With(m.username), ", ")
+	}
+	form += fmt.Sprintf("%s\n%s", styleMetaLabel.Render("Message:"), m.textarea.View())
+
+	header := m.neonBanner(title, "private • send with Ctrl+S")
+	help := styleHelp.Render("Tab: switch fields • Ctrl+S: send • Esc: cancel")
+	return fmt.Sprintf("%s\n%s\n\n%s\n\n%s",
+		header,
+		m.accentBar(),
+		framedSection("Direct Message", form),
+		help,
+	)
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"ag/internal/bbs"
+)
+
+func (m Model) viewNotifications() string {
+	header := m.neonBanner("Notifications", fmt.Sprintf("%d unread of %d", m.unreadNotes, len(m.notifications)))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	if len(m.notifications) == 0 {
+		s += framedSection("Inbox", styleDim.Render("No notifications yet. Follow boards and threads with f.")) + "\n"
+		s += "\n" + styleHelp.Render("b: back • q: quit")
+		return s
+	}
+
+	var table strings.Builder
+	table.WriteString(fmt.Sprintf("  %s  %s  %s  %s\n",
+		styleTableHead.Width(16).Render("When"),
+		styleTableHead.Width(12).Render("Board"),
+		styleTableHead.Width(40).Render("What"),
+		styleTableHead.Width(15).Render("From"),
+	))
+	table.WriteString(styleDim.Render(strings.Repeat("=", 85)) + "\n")
+
+	start, end := listWindow(m.noteIdx, len(m.notifications), fixedViewportHeight-6)
+	for i := start; i < end; i++ {
+		n := m.notifications[i]
+		style := styleTableRow
+		indicator := " "
+		if !n.Read {
+			style = styleTableUnread
+			indicator = "*"
+		}
+		if i == m.noteIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		title := n.Title
+		if len(title) > 24 {
+			title = title[:24] + "..."
+		}
+		table.WriteString(fmt.Sprintf("%s %s %s %s %s\n",
+			style.Render(indicator),
+			style.Width(16).Render(n.CreatedAt.Format("06-01-02 15:04")),
+			style.Width(12).Render(n.Board),
+			style.Width(40).Render(notificationLabel(n.Kind)+": "+title),
+			style.Width(15).Render(n.Actor),
+		))
+	}
+
+	s += framedSection("Inbox", table.String())
+	s += "\n" + styleHelp.Render("j/k: move • enter: open • a: mark all read • b: back • q: quit")
+	return s
+}
+
+func notificationLabel(kind bbs.NotificationKind) string {
+	switch kind {
+	case bbs.NotifyPost:
+		return "new post"
+	case bbs.NotifyReply:
+		return "reply"
+	case bbs.NotifyMention:
+		return "mentioned you"
+	default:
+		return "comment"
+	}
+}
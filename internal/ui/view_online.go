@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+// shortDuration renders d as 45s, 12m or 3h05m.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+func clip(s string, width int) string {
+	if r := []rune(s); len(r) > width-2 {
+		return string(r[:width-3]) + "…"
+	}
+	return s
+}
+
+func (m Model) viewOnline() string {
+	admin := m.board.Role(m.username) == bbs.RoleAdmin
+	header := m.neonBanner("Who's Online", fmt.Sprintf("%d session(s) connected", len(m.online)))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	type column struct {
+		title string
+		width int
+	}
+	columns := []column{{"User", 16}, {"Where", 16}, {"Online", 10}, {"Idle", 8}}
+	if admin {
+		columns = []column{{"User", 14}, {"Where", 12}, {"Online", 10}, {"Idle", 8}, {"Address", 22}, {"Client", 18}}
+	}
+
+	var table strings.Builder
+	heads := make([]string, len(columns))
+	total := 0
+	for i, c := range columns {
+		heads[i] = styleTableHead.Width(c.width).Render(c.title)
+		total += c.width + 1
+	}
+	table.WriteString("    " + strings.Join(heads, " ") + "\n")
+	table.WriteString(styleDim.Render(strings.Repeat("=", total+3)) + "\n")
+
+	now := time.Now()
+	start, end := listWindow(m.onlineIdx, len(m.online), fixedViewportHeight-6)
+	for i := start; i < end; i++ {
+		o := m.online[i]
+		style := styleTableRow
+		indicator := " "
+		if o.Self {
+			style = styleTableUnread
+		}
+		if i == m.onlineIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		user := o.User
+		if o.Self {
+			user += " (you)"
+		}
+		where := o.Board
+		if where == "" {
+			where = "-"
+		}
+		cells := []string{user, where, shortDuration(now.Sub(o.ConnectedAt)), shortDuration(now.Sub(o.LastActive))}
+		if admin {
+			cells = append(cells, o.RemoteAddr, o.ClientVersion)
+		}
+		rendered := make([]string, len(cells))
+		for j, cell := range cells {
+			rendered[j] = style.Width(columns[j].width).Render(clip(cell, columns[j].width))
+		}
+		table.WriteString(style.Render(indicator) + " " + strings.Join(rendered, " ") + "\n")
+	}
+
+	s += framedSection("Online", table.String())
+	s += "\n" + styleHelp.Render("j/k: move • r: refresh • b: back • q: quit")
+	return s
+}
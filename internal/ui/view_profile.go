@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// formatSeen renders a last-seen time for the profile screen.
+func formatSeen(t time.Time, online bool) string {
+	switch {
+	case online:
+		return "online now"
+	case t.IsZero():
+		return "never"
+	default:
+		return t.Format("2006-01-02 15:04")
+	}
+}
+
+func (m Model) viewProfile() string {
+	p := m.profile
+	title := "Profile: " + p.Name()
+	subtitle := "@" + p.User
+	if p.User == m.username {
+		subtitle += " • this is you"
+	}
+	header := m.neonBanner(title, subtitle)
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	joined := "unknown"
+	if !p.JoinedAt.IsZero() {
+		joined = p.JoinedAt.Format("2006-01-02")
+	}
+	field := func(label, value string) string {
+		return styleMetaLabel.Width(12).Render(label) + styleMetaValue.Render(value) + "\n"
+	}
+	var info strings.Builder
+	info.WriteString(field("Name", p.Name()))
+	info.WriteString(field("Joined", joined))
+	info.WriteString(field("Last seen", formatSeen(p.LastSeen, m.isOnline(p.User))))
+	info.WriteString(field("Activity", fmt.Sprintf("%d post(s) • %d comment(s)", p.Posts, p.Comments)))
+	if p.Signature != "" {
+		sig := p.Signature
+		if p.AppendSignature {
+			sig += styleDim.Render("  (added to posts)")
+		}
+		info.WriteString(field("Signature", sig))
+	}
+	if p.Bio != "" {
+		info.WriteString("\n" + lipgloss.NewStyle().Width(fixedViewportWidth-8).Render(styleCommentContent.Render(p.Bio)) + "\n")
+	}
+	panel := lipgloss.NewStyle().Width(fixedViewportWidth - 4)
+	s += framedSection("About", panel.Render(strings.TrimRight(info.String(), "\n"))) + "\n"
+
+	var list strings.Builder
+	if len(m.profilePosts) == 0 {
+		list.WriteString(styleDim.Render("No posts yet."))
+	}
+	start, end := listWindow(m.profileIdx, len(m.profilePosts), 6)
+	for i := start; i < end; i++ {
+		bp := m.profilePosts[i]
+		style := styleTableRow
+		indicator := " "
+		if i == m.profileIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		title := bp.Title
+		if r := []rune(title); len(r) > 45 {
+			title = string(r[:45]) + "..."
+		}
+		list.WriteString(fmt.Sprintf("%s %s %s %s\n",
+			style.Render(indicator),
+			style.Width(12).Render(bp.Board),
+			style.Width(50).Render(title),
+			style.Width(16).Render(bp.CreatedAt.Format("06-01-02 15:04")),
+		))
+	}
+	s += framedSection("Recent posts", panel.Render(strings.TrimRight(list.String(), "\n")))
+
+	help := "j/k: move • enter: read • b: back • q: quit"
+	if p.User == m.username {
+		help = "j/k: move • enter: read • e: edit profile • t: API tokens • b: back • q: quit"
+	}
+	s += "\n" + styleHelp.Render(help)
+	return s
+}
+
+func (m Model) viewProfileEdit() string {
+	appendSig := "[ ] add signature to new posts and comments"
+	if m.profileAppend {
+		appendSig = "[x] add signature to new posts and comments"
+	}
+	form := fmt.Sprintf("%s\n%s\n\n%s\n%s\n%s\n\n%s\n%s",
+		styleMetaLabel.Render("Display name:"), m.profileName.View(),
+		styleMetaLabel.Render("Signature:"), m.profileSig.View(), styleMetaValue.Render(appendSig),
+		styleMetaLabel.Render("Bio:"), m.textarea.View(),
+	)
+
+	header := m.neonBanner("Edit Profile", "@"+m.username+" • save with Ctrl+S")
+	help := styleHelp.Render("Tab: switch fields • Ctrl+T: toggle signature • Ctrl+S: save • Esc: cancel")
+	return fmt.Sprintf("%s\n%s\n\n%s\n\n%s",
+		header,
+		m.accentBar(),
+		framedSection("Profile", form),
+		help,
+	)
+}
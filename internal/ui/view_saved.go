@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (m Model) viewSaved() string {
+	header := m.neonBanner("Saved Posts", fmt.Sprintf("%d bookmark(s) for %s", len(m.saved), m.username))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	if len(m.saved) == 0 {
+		s += framedSection("Saved", styleDim.Render("Nothing saved yet. Press s while reading a post to bookmark it.")) + "\n"
+		s += "\n" + styleHelp.Render("b: back • q: quit")
+		return s
+	}
+
+	var table strings.Builder
+	table.WriteString(fmt.Sprintf("  %s  %s  %s  %s\n",
+		styleTableHead.Width(12).Render("Board"),
+		styleTableHead.Width(40).Render("Title"),
+		styleTableHead.Width(15).Render("Author"),
+		styleTableHead.Width(16).Render("Saved"),
+	))
+	table.WriteString(styleDim.Render(strings.Repeat("=", 85)) + "\n")
+
+	start, end := listWindow(m.savedIdx, len(m.saved), fixedViewportHeight-6)
+	for i := start; i < end; i++ {
+		sp := m.saved[i]
+		style := styleTableRow
+		indicator := " "
+		if i == m.savedIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		title, author := sp.Title, sp.Post.Author
+		if !sp.Missing {
+			title = sp.Post.Title
+		}
+		if len(title) > 25 {
+			title = title[:25] + "..."
+		}
+		if sp.Missing {
+			title = "[deleted] " + title
+			author = "-"
+		}
+		table.WriteString(fmt.Sprintf("%s %s %s %s %s\n",
+			style.Render(indicator),
+			style.Width(12).Render(sp.Board),
+			style.Width(40).Render(title),
+			style.Width(15).Render(author),
+			style.Width(16).Render(sp.SavedAt.Format("06-01-02 15:04")),
+		))
+	}
+
+	s += framedSection("Saved", table.String())
+	s += "\n" + styleHelp.Render("j/k: move • enter: read • x: remove • b: back • q: quit")
+	return s
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// searchResultsShown is how many results fit on screen; each takes two lines.
+const searchResultsShown = 6
+
+// highlightTerms renders text in base with every word starting with one of
+// terms, ignoring case, in styleMatch. Terms are stems, so the rest of the
+// word (a plural, a Korean particle) is highlighted with them.
+func highlightTerms(text string, terms []string, base lipgloss.Style) string {
+	if len(terms) == 0 {
+		return base.Render(text)
+	}
+	quoted := make([]string, 0, len(terms))
+	for _, t := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(t))
+	}
+	// Longer terms first, so "gophers" wins over "go".
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	re := regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)[\p{L}\p{N}]*`)
+
+	var out strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		out.WriteString(base.Render(text[last:loc[0]]))
+		out.WriteString(styleMatch.Render(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	out.WriteString(base.Render(text[last:]))
+	return out.String()
+}
+
+func (m Model) viewSearch() string {
+	subtitle := "across all boards"
+	if m.globalInput.Value() != "" && !m.globalInput.Focused() {
+		subtitle = fmt.Sprintf("%d result(s) • across all boards", len(m.found))
+	}
+	header := m.neonBanner("Search", subtitle)
+	s := header + "\n" + m.accentBar() + "\n\n"
+	s += styleMetaLabel.Render("Search: ") + m.globalInput.View() + "\n\n"
+
+	var body strings.Builder
+	switch {
+	case m.globalInput.Focused() && len(m.found) == 0:
+		body.WriteString(styleDim.Render("Type a query and press enter."))
+	case len(m.found) == 0:
+		body.WriteString(styleDim.Render("Nothing found."))
+	}
+	start, end := listWindow(m.foundIdx, len(m.found), searchResultsShown)
+	for i := start; i < end; i++ {
+		r := m.found[i]
+		style := styleTableRow
+		indicator := " "
+		if i == m.foundIdx && !m.globalInput.Focused() {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		title := r.Title
+		if runes := []rune(title); len(runes) > 42 {
+			title = string(runes[:42]) + "..."
+		}
+		body.WriteString(fmt.Sprintf("%s %s %s %s\n",
+			style.Render(indicator),
+			style.Width(12).Render(r.Board),
+			style.Width(48).Render(title),
+			style.Width(20).Render(r.Author+" "+r.CreatedAt.Format("06-01-02")),
+		))
+		snip := lipgloss.NewStyle().Width(fixedViewportWidth - 10).MaxHeight(1).
+			Render(highlightTerms(r.Snippet, r.Terms, styleDim))
+		body.WriteString("      " + snip + "\n")
+	}
+
+	s += framedSection("Results", lipgloss.NewStyle().Width(fixedViewportWidth-4).Render(strings.TrimRight(body.String(), "\n")))
+	help := "j/k: move • enter: read • /: new search • b: back • q: quit"
+	if m.globalInput.Focused() {
+		help = "enter: search • esc: cancel"
+	}
+	s += "\n" + styleHelp.Render(help)
+	return s
+}
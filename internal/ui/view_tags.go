@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tagCloudSize caps how many tags appear in the cloud above the list.
+const tagCloudSize = 12
+
+func (m Model) viewTags() string {
+	header := m.neonBanner("Tag Cloud", fmt.Sprintf("%d tag(s) across all boards", len(m.tags)))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	if len(m.tags) == 0 {
+		s += framedSection("Tags", styleDim.Render("No tagged posts yet. Add tags when composing a post.")) + "\n"
+		s += "\n" + styleHelp.Render("b: back • q: quit")
+		return s
+	}
+
+	var body strings.Builder
+	cloud := make([]string, 0, tagCloudSize)
+	for i, t := range m.tags {
+		if i == tagCloudSize {
+			break
+		}
+		cloud = append(cloud, fmt.Sprintf("#%s %d", t.Tag, t.Count))
+	}
+	body.WriteString(badgeLine(cloud...))
+	body.WriteString("\n\n")
+
+	body.WriteString(fmt.Sprintf("  %s  %s\n",
+		styleTableHead.Width(40).Render("Tag"),
+		styleTableHead.Width(15).Render("Posts"),
+	))
+	body.WriteString(styleDim.Render(strings.Repeat("=", 60)) + "\n")
+
+	start, end := listWindow(m.tagIdx, len(m.tags), fixedViewportHeight-8)
+	for i := start; i < end; i++ {
+		t := m.tags[i]
+		style := styleTableRow
+		indicator := " "
+		if i == m.tagIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		body.WriteString(fmt.Sprintf("%s %s  %s\n",
+			style.Render(indicator),
+			style.Width(40).Render("#"+t.Tag),
+			style.Width(15).Render(fmt.Sprintf("%d posts", t.Count)),
+		))
+	}
+
+	s += framedSection("Tags", body.String())
+	s += "\n" + styleHelp.Render("j/k: navigate • enter: posts with tag • b: back • q: quit")
+	return s
+}
+
+func (m Model) viewResults() string {
+	header := m.neonBanner(m.resultsTitle, fmt.Sprintf("%d post(s) across all boards", len(m.results)))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	if len(m.results) == 0 {
+		s += framedSection("Results", styleDim.Render("No posts found.")) + "\n"
+		s += "\n" + styleHelp.Render("b: back • q: quit")
+		return s
+	}
+
+	var table strings.Builder
+	table.WriteString(fmt.Sprintf("  %s  %s  %s  %s\n",
+		styleTableHead.Width(12).Render("Board"),
+		styleTableHead.Width(40).Render("Title"),
+		styleTableHead.Width(15).Render("Author"),
+		styleTableHead.Width(16).Render("Date"),
+	))
+	table.WriteString(styleDim.Render(strings.Repeat("=", 85)) + "\n")
+
+	start, end := listWindow(m.resultIdx, len(m.results), fixedViewportHeight-6)
+	for i := start; i < end; i++ {
+		r := m.results[i]
+		style := styleTableRow
+		indicator := " "
+		if i == m.resultIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		title := r.Title
+		if len(title) > 35 {
+			title = title[:35] + "..."
+		}
+		table.WriteString(fmt.Sprintf("%s %s %s %s %s\n",
+			style.Render(indicator),
+			style.Width(12).Render(r.Board),
+			style.Width(40).Render(title),
+			style.Width(15).Render(r.Author),
+			style.Width(16).Render(r.CreatedAt.Format("06-01-02 15:04")),
+		))
+	}
+
+	s += framedSection("Results", table.String())
+	s += "\n" + styleHelp.Render("j/k: move • enter: read • b: back • q: quit")
+	return s
+}
+
+// listWindow returns the slice bounds of a scrolling window of size rows
+// that keeps idx visible.
+func listWindow(idx, total, rows int) (int, int) {
+	if rows <= 0 || total <= rows {
+		return 0, total
+	}
+	start := idx - rows/2
+	if start < 0 {
+		start = 0
+	}
+	if start+rows > total {
+		start = total - rows
+	}
+	return start, start + rows
+}
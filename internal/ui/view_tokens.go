@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (m Model) viewTokens() string {
+	header := m.neonBanner("API Tokens", fmt.Sprintf("%d token(s) acting as %s", len(m.tokens), m.username))
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	var list strings.Builder
+	if len(m.tokens) == 0 {
+		list.WriteString(styleDim.Render("No tokens yet. Press n to issue one for a bot or script."))
+	}
+	start, end := listWindow(m.tokenIdx, len(m.tokens), fixedViewportHeight-10)
+	for i := start; i < end; i++ {
+		t := m.tokens[i]
+		style := styleTableRow
+		indicator := " "
+		if i == m.tokenIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		list.WriteString(fmt.Sprintf("%s %s %s %s\n",
+			style.Render(indicator),
+			style.Width(10).Render(t.ID),
+			style.Width(42).Render(t.Name),
+			style.Width(16).Render(t.CreatedAt.Format("06-01-02 15:04")),
+		))
+	}
+	s += framedSection("Tokens", strings.TrimRight(list.String(), "\n")) + "\n"
+
+	switch {
+	case m.tokenName.Focused():
+		s += framedSection("New token", styleMetaLabel.Render("Name:")+"\n"+m.tokenName.View()) + "\n"
+	case m.tokenSecret != "":
+		note := styleMetaValue.Render(m.tokenSecret) + "\n" +
+			styleDim.Render("Copy it now; it is not shown again. Send it as \"Authorization: Bearer <token>\".")
+		s += framedSection("New token", note) + "\n"
+	}
+
+	help := "j/k: move • n: new token • x: revoke • b: back • q: quit"
+	if m.tokenName.Focused() {
+		help = "enter: issue • esc: cancel"
+	}
+	s += "\n" + styleHelp.Render(help)
+	return s
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"ag/internal/webhook"
+)
+
+func (m Model) viewWebhooks() string {
+	subtitle := fmt.Sprintf("%d hook(s) • %d queued • last %d attempt(s)",
+		len(m.webhooks.Hooks()), m.webhooks.Pending(), len(m.hookLog))
+	header := m.neonBanner("Webhooks", subtitle)
+	s := header + "\n" + m.accentBar() + "\n\n"
+
+	type column struct {
+		title string
+		width int
+	}
+	columns := []column{{"When", 16}, {"Hook", 12}, {"Event", 8}, {"Post", 16}, {"Try", 6}, {"Result", 9}, {"Status", 8}}
+
+	var table strings.Builder
+	heads := make([]string, len(columns))
+	total := 0
+	for i, c := range columns {
+		heads[i] = styleTableHead.Width(c.width).Render(c.title)
+		total += c.width + 1
+	}
+	table.WriteString("    " + strings.Join(heads, " ") + "\n")
+	table.WriteString(styleDim.Render(strings.Repeat("=", total+3)) + "\n")
+	if len(m.hookLog) == 0 {
+		table.WriteString(styleDim.Render("Nothing delivered yet."))
+	}
+
+	start, end := listWindow(m.hookIdx, len(m.hookLog), fixedViewportHeight-10)
+	for i := start; i < end; i++ {
+		a := m.hookLog[i]
+		style := styleTableRow
+		indicator := " "
+		if a.Result == webhook.ResultFailed || a.Result == webhook.ResultGone {
+			style = styleTableUnread
+		}
+		if i == m.hookIdx {
+			style = styleTableSelected
+			indicator = ">"
+		}
+		status := "-"
+		if a.Status != 0 {
+			status = fmt.Sprint(a.Status)
+		}
+		cells := []string{
+			a.At.Local().Format("01-02 15:04:05"),
+			a.Hook,
+			string(a.Event),
+			fmt.Sprintf("%s #%d", a.Board, a.PostID),
+			fmt.Sprint(a.Attempt),
+			string(a.Result),
+			status,
+		}
+		rendered := make([]string, len(cells))
+		for j, cell := range cells {
+			rendered[j] = style.Width(columns[j].width).Render(clip(cell, columns[j].width))
+		}
+		table.WriteString(style.Render(indicator) + " " + strings.Join(rendered, " ") + "\n")
+	}
+	s += framedSection("Deliveries", strings.TrimRight(table.String(), "\n")) + "\n"
+
+	if m.hookIdx < len(m.hookLog) {
+		a := m.hookLog[m.hookIdx]
+		detail := styleMetaLabel.Render("Delivery: ") + styleMetaValue.Render(a.Delivery)
+		if !a.NextAt.IsZero() {
+			detail += "\n" + styleMetaLabel.Render("Next try: ") + styleMetaValue.Render(a.NextAt.Local().Format("2006-01-02 15:04:05"))
+		}
+		if a.Error != "" {
+			detail += "\n" + styleMetaLabel.Render("Error: ") + styleMetaValue.Render(clip(a.Error, 80))
+		}
+		s += framedSection("Selected", detail) + "\n"
+	}
+
+	s += "\n" + styleHelp.Render("j/k: move • r: refresh • b: back • q: quit")
+	return s
+}
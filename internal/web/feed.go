@@ -0,0 +1,330 @@
+package web
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+// FeedFormat is a syndication format the mirror writes feeds in.
+type FeedFormat string
+
+const (
+	FeedAtom FeedFormat = "atom"
+	FeedRSS  FeedFormat = "rss"
+)
+
+// FeedFormats lists every format ExportFeeds writes.
+var FeedFormats = []FeedFormat{FeedAtom, FeedRSS}
+
+func (f FeedFormat) String() string { return string(f) }
+
+// ContentType returns the media type feeds of the format are served as.
+func (f FeedFormat) ContentType() string {
+	if f == FeedRSS {
+		return "application/rss+xml; charset=utf-8"
+	}
+	return "application/atom+xml; charset=utf-8"
+}
+
+// feedSize caps the entries in a feed; readers only poll for what is new.
+const feedSize = 50
+
+// feedPath returns the path of the feed of board, or of every public board
+// if board is empty. ExportFeeds writes files at the same paths.
+func feedPath(board string, f FeedFormat) string {
+	if board == "" {
+		return "/feed." + f.String()
+	}
+	return "/b/" + url.PathEscape(board) + "/feed." + f.String()
+}
+
+// feedEntry is a post and the board it was written on.
+type feedEntry struct {
+	board string
+	post  bbs.Post
+}
+
+// feedEntries returns the most recently updated posts of board, or of every
+// public board if board is empty, newest first.
+func (m *Mirror) feedEntries(board string) ([]feedEntry, error) {
+	var boards []string
+	if board == "" {
+		for _, s := range m.board.ListBoards() {
+			if !s.Private {
+				boards = append(boards, s.Name)
+			}
+		}
+	} else {
+		if _, ok := m.publicBoard(board); !ok {
+			return nil, bbs.ErrBoardNotFound
+		}
+		boards = []string{board}
+	}
+	// Pick the entries from the board indexes and load only those posts.
+	var entries []feedEntry
+	for _, ref := range m.board.ListRecentlyUpdated(boards, feedSize) {
+		p, err := m.board.GetPost(ref.Board, ref.ID)
+		if errors.Is(err, bbs.ErrPostNotFound) {
+			continue // deleted since the index was read
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, feedEntry{board: ref.Board, post: p})
+	}
+	return entries, nil
+}
+
+// WriteFeed writes the feed of a public board, or of every public board if
+// board is empty, with links under base, the absolute URL of the mirror.
+func (m *Mirror) WriteFeed(w io.Writer, board string, f FeedFormat, base string) error {
+	entries, err := m.feedEntries(board)
+	if err != nil {
+		return err
+	}
+	title, page := "All boards", base+"/"
+	if board != "" {
+		title, page = board, base+"/b/"+url.PathEscape(board)
+	}
+	// A feed is as new as its newest entry, so unchanged boards keep
+	// producing identical feeds.
+	var updated time.Time
+	if len(entries) > 0 {
+		updated = entries[0].post.UpdatedAt()
+	}
+
+	var doc any
+	switch f {
+	case FeedAtom:
+		feed := atomFeed{
+			Title:   title + " · BBS",
+			ID:      page,
+			Updated: updated.UTC().Format(time.RFC3339),
+			Links: []atomLink{
+				{Rel: "self", Type: "application/atom+xml", Href: base + feedPath(board, f)},
+				{Rel: "alternate", Type: "text/html", Href: page},
+			},
+		}
+		for _, e := range entries {
+			feed.Entries = append(feed.Entries, m.atomEntry(e, board == "", base))
+		}
+		doc = feed
+	case FeedRSS:
+		feed := rssFeed{Version: "2.0", Channel: rssChannel{
+			Title:       title + " · BBS",
+			Link:        page,
+			Description: "Latest posts on " + title,
+		}}
+		if !updated.IsZero() {
+			feed.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+		}
+		for _, e := range entries {
+			feed.Channel.Items = append(feed.Channel.Items, m.rssItem(e, board == "", base))
+		}
+		doc = feed
+	default:
+		return fmt.Errorf("unknown feed format %q", f)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// entryTitle names the board in feeds that mix boards.
+func entryTitle(e feedEntry, mixed bool) string {
+	if mixed {
+		return "[" + e.board + "] " + e.post.Title
+	}
+	return e.post.Title
+}
+
+func postURL(base string, e feedEntry) string {
+	return fmt.Sprintf("%s/b/%s/%d", base, url.PathEscape(e.board), e.post.ID)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomPerson     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func (m *Mirror) atomEntry(e feedEntry, mixed bool, base string) atomEntry {
+	link := postURL(base, e)
+	entry := atomEntry{
+		Title:     entryTitle(e, mixed),
+		ID:        link,
+		Link:      atomLink{Rel: "alternate", Type: "text/html", Href: link},
+		Published: e.post.CreatedAt.UTC().Format(time.RFC3339),
+		Updated:   e.post.UpdatedAt().UTC().Format(time.RFC3339),
+		Author:    atomPerson{Name: e.post.Author},
+		Content:   atomContent{Type: "html", Body: string(m.markdown(e.post.Content))},
+	}
+	for _, tag := range e.post.Tags {
+		entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+	}
+	return entry
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func (m *Mirror) rssItem(e feedEntry, mixed bool, base string) rssItem {
+	link := postURL(base, e)
+	return rssItem{
+		Title:       entryTitle(e, mixed),
+		Link:        link,
+		GUID:        rssGUID{IsPermaLink: true, Value: link},
+		PubDate:     e.post.UpdatedAt().UTC().Format(time.RFC1123Z),
+		Categories:  e.post.Tags,
+		Description: string(m.markdown(e.post.Content)),
+	}
+}
+
+// baseFor returns the absolute URL of the mirror as seen by r.
+func (m *Mirror) baseFor(r *http.Request) string {
+	if m.baseURL != "" {
+		return m.baseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func (m *Mirror) feed(f FeedFormat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		err := m.WriteFeed(&buf, r.PathValue("board"), f, m.baseFor(r))
+		if errors.Is(err, bbs.ErrBoardNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			log.Printf("web: feed %s: %v", r.URL.Path, err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		serve(w, r, f.ContentType(), buf.Bytes())
+	}
+}
+
+// ExportFeeds writes every feed the mirror serves under dir, at the paths
+// they are served from, so dir can be published as static files at the
+// base URL. It needs WithBaseURL.
+func (m *Mirror) ExportFeeds(dir string) error {
+	if m.baseURL == "" {
+		return errors.New("exporting feeds needs a base URL")
+	}
+	boards := []string{""}
+	for _, s := range m.board.ListBoards() {
+		// "." and ".." would escape their directory.
+		if !s.Private && s.Name != "." && s.Name != ".." {
+			boards = append(boards, s.Name)
+		}
+	}
+	for _, board := range boards {
+		for _, f := range FeedFormats {
+			var buf bytes.Buffer
+			if err := m.WriteFeed(&buf, board, f, m.baseURL); err != nil {
+				return fmt.Errorf("feed %s: %w", feedPath(board, f), err)
+			}
+			path := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(feedPath(board, f), "/")))
+			if err := writeFile(path, buf.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFile replaces path with data in one step, so a web server serving
+// dir never sees half a feed.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
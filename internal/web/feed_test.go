@@ -0,0 +1,98 @@
+package web
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+func TestFeedsListPublicPostsNewestFirst(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := bbs.NewWithBoards(func() time.Time { now = now.Add(time.Minute); return now }, []string{"general", "go", "staff"}, nil, nil,
+		bbs.WithRoles(map[string]bbs.Role{"mod": bbs.RoleModerator}))
+	_ = b.SetBoardPrivate("staff", true, "mod")
+	_, _ = b.AddPost("general", "alice", "first", "**hi**", "intro")
+	_, _ = b.AddPost("go", "bob", "generics", "body")
+	_, _ = b.AddPost("staff", "mod", "secret", "body")
+	srv := httptest.NewServer(New(b))
+	defer srv.Close()
+
+	resp, body := get(t, srv.URL+"/feed.atom")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != FeedAtom.ContentType() || resp.Header.Get("ETag") == "" {
+		t.Fatalf("atom feed: %d %v", resp.StatusCode, resp.Header)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal([]byte(body), &feed); err != nil {
+		t.Fatalf("parse atom: %v\n%s", err, body)
+	}
+	if len(feed.Entries) != 2 || feed.Entries[0].Title != "[go] generics" || feed.Entries[1].Title != "[general] first" {
+		t.Fatalf("unexpected entries: %+v", feed.Entries)
+	}
+	first := feed.Entries[1]
+	if first.ID != srv.URL+"/b/general/1" || first.Author.Name != "alice" || first.Content.Body != "<p><strong>hi</strong></p>\n" ||
+		len(first.Categories) != 1 || first.Categories[0].Term != "intro" {
+		t.Fatalf("unexpected entry: %+v", first)
+	}
+	if feed.Updated != feed.Entries[0].Updated || first.Updated != "2025-01-01T00:01:00Z" {
+		t.Fatalf("unexpected updated times: feed %s, entry %s", feed.Updated, first.Updated)
+	}
+
+	_, body = get(t, srv.URL+"/b/general/feed.rss")
+	var rss rssFeed
+	if err := xml.Unmarshal([]byte(body), &rss); err != nil {
+		t.Fatalf("parse rss: %v\n%s", err, body)
+	}
+	if len(rss.Channel.Items) != 1 || rss.Channel.Items[0].Title != "first" || rss.Channel.Items[0].GUID.Value != srv.URL+"/b/general/1" {
+		t.Fatalf("unexpected rss: %+v", rss.Channel)
+	}
+
+	// Like Atom's updated, an item's date moves with edits.
+	edited, err := b.EditPost("general", 1, "alice", "first", "edited", "intro")
+	if err != nil {
+		t.Fatalf("EditPost: %v", err)
+	}
+	_, body = get(t, srv.URL+"/b/general/feed.rss")
+	rss = rssFeed{}
+	if err := xml.Unmarshal([]byte(body), &rss); err != nil {
+		t.Fatalf("parse rss: %v\n%s", err, body)
+	}
+	if want := edited.UpdatedAt().UTC().Format(time.RFC1123Z); rss.Channel.Items[0].PubDate != want || rss.Channel.LastBuildDate != want {
+		t.Fatalf("expected item and build dates %s, got %+v", want, rss.Channel)
+	}
+
+	if resp, _ := get(t, srv.URL+"/b/staff/feed.atom"); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the private board's feed to be hidden, got %d", resp.StatusCode)
+	}
+	if _, body := get(t, srv.URL+"/b/general"); !strings.Contains(body, `<link rel="alternate" type="application/atom+xml" href="/b/general/feed.atom">`) {
+		t.Fatalf("expected the board page to advertise its feed:\n%s", body)
+	}
+}
+
+func TestExportFeedsWritesServedPaths(t *testing.T) {
+	b := bbs.NewWithBoards(func() time.Time { return time.Unix(0, 0) }, []string{"general"}, nil, nil)
+	_, _ = b.AddPost("general", "alice", "hello", "body")
+	if err := New(b).ExportFeeds(t.TempDir()); err == nil {
+		t.Fatal("expected an error without a base URL")
+	}
+
+	dir := t.TempDir()
+	if err := New(b, WithBaseURL("https://bbs.example.com/")).ExportFeeds(dir); err != nil {
+		t.Fatalf("ExportFeeds: %v", err)
+	}
+	for _, name := range []string{"feed.atom", "feed.rss", "b/general/feed.atom", "b/general/feed.rss"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if !strings.Contains(string(data), "https://bbs.example.com/b/general/1") {
+			t.Fatalf("%s does not link the post:\n%s", name, data)
+		}
+	}
+}
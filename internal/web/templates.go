@@ -0,0 +1,92 @@
+package web
+
+import (
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+var funcs = template.FuncMap{
+	"date":     func(t interface{ Format(string) string }) string { return t.Format("2006-01-02 15:04") },
+	"pathpart": url.PathEscape,
+	"join":     strings.Join,
+}
+
+const layout = `<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · BBS</title>
+{{with .Feed}}<link rel="alternate" type="application/atom+xml" href="{{.}}">{{end}}
+<style>
+body { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; max-width: 52rem; margin: 2rem auto; padding: 0 1rem; color: #ddd; background: #111; }
+a { color: #c9f; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .3rem .6rem; border-bottom: 1px solid #333; }
+.meta, .dim { color: #888; }
+.badge { color: #fc6; }
+.comment { border-left: 2px solid #444; padding-left: 1rem; margin: 1rem 0; }
+.reply { margin-left: 2rem; }
+pre { overflow-x: auto; background: #1b1b1b; padding: .6rem; }
+img { max-width: 100%; }
+nav { margin: 1rem 0; }
+</style>
+</head>
+<body>
+<header><a href="/">Boards</a> <span class="dim">· read-only mirror</span></header>
+<main>
+{{template "content" .}}
+</main>
+</body>
+</html>
+`
+
+func page(content string) *template.Template {
+	t := template.Must(template.New("layout").Funcs(funcs).Parse(layout))
+	template.Must(t.New("content").Parse(content))
+	return t
+}
+
+var boardsPage = page(`<h1>Boards</h1>
+{{if .Boards}}
+<table>
+<tr><th>Board</th><th>Posts</th><th>State</th></tr>
+{{range .Boards}}<tr><td><a href="/b/{{pathpart .Name}}">{{.Name}}</a></td><td>{{.PostCount}}</td><td>{{.State}}</td></tr>
+{{end}}</table>
+{{else}}<p class="dim">No boards.</p>{{end}}
+<p class="dim">Feeds of all boards: <a href="/feed.atom">Atom</a> · <a href="/feed.rss">RSS</a></p>
+`)
+
+var postsPage = page(`<h1>{{.Board.Name}}</h1>
+<p class="meta">{{.Page.Total}} posts{{if ne .Board.State.String "open"}} · <span class="badge">{{.Board.State}}</span>{{end}} · <a href="{{.Feed}}">Atom</a> · <a href="/b/{{pathpart .Board.Name}}/feed.rss">RSS</a></p>
+<nav>sort:{{range .Sorts}} {{if eq . $.Sort}}<b>{{.}}</b>{{else}}<a href="{{$.SortURL .}}">{{.}}</a>{{end}}{{end}}</nav>
+{{if .Page.Posts}}
+<table>
+<tr><th>#</th><th>Title</th><th>Author</th><th>Date</th><th>Comments</th><th>Score</th></tr>
+{{range .Page.Posts}}<tr>
+<td>{{.ID}}</td>
+<td><a href="/b/{{pathpart $.Board.Name}}/{{.ID}}">{{.Title}}</a>{{if .Locked}} <span class="badge">locked</span>{{end}}{{if .Tags}} <span class="dim">#{{join .Tags " #"}}</span>{{end}}</td>
+<td>{{.Author}}</td><td>{{date .CreatedAt}}</td><td>{{.Comments}}</td><td>{{printf "%+d" .Score}}</td>
+</tr>
+{{end}}</table>
+{{else}}<p class="dim">No posts.</p>{{end}}
+<nav>{{if not .First}}<a href="{{.SortURL .Sort}}">« first page</a> {{end}}{{if .Page.Next}}<a href="{{.NextURL}}">next page »</a>{{end}}</nav>
+`)
+
+var postPage = page(`<p><a href="/b/{{pathpart .Board.Name}}">« {{.Board.Name}}</a></p>
+<h1>{{.Post.Title}}</h1>
+<p class="meta">by {{.Post.Author}} · {{date .Post.CreatedAt}}{{if not .Post.EditedAt.IsZero}} · edited {{date .Post.EditedAt}}{{end}} · score {{printf "%+d" .Post.Score}}{{if .Post.Locked}} · <span class="badge">locked</span>{{end}}{{if .Post.Tags}} · #{{join .Post.Tags " #"}}{{end}}</p>
+<article>{{.Content}}</article>
+{{if .Post.Attachments}}
+<h2>Attachments</h2>
+<ul>{{range .Post.Attachments}}<li>{{.Name}} <span class="dim">({{.Size}} bytes, {{.Type}})</span></li>{{end}}</ul>
+<p class="dim">Attachments are downloaded with scp over SSH.</p>
+{{end}}
+<h2>{{len .Comments}} comments</h2>
+{{range .Comments}}<div class="comment{{if .Reply}} reply{{end}}">
+<p class="meta">{{.Author}} · {{date .CreatedAt}} · {{printf "%+d" .Score}}</p>
+{{.Content}}
+</div>
+{{end}}
+`)
@@ -0,0 +1,265 @@
+// Package web serves a read-only HTML mirror of the BBS for readers without
+// SSH access. Pages are rendered straight from the in-memory BBS; private
+// boards are not served.
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"ag/internal/bbs"
+)
+
+// maxAge is how long browsers and proxies may reuse a page without asking.
+// Pages carry an ETag, so asking again is cheap.
+const maxAge = 60 * time.Second
+
+// Mirror is an http.Handler for the mirror.
+type Mirror struct {
+	board    *bbs.BBS
+	mux      *http.ServeMux
+	md       goldmark.Markdown
+	policy   *bluemonday.Policy
+	pageSize int
+	baseURL  string // absolute URL of "/" in feeds; see WithBaseURL
+}
+
+// Option configures optional Mirror behaviour.
+type Option func(*Mirror)
+
+// WithPageSize lists n posts per page instead of bbs.DefaultPageSize.
+func WithPageSize(n int) Option {
+	return func(m *Mirror) {
+		m.pageSize = n
+	}
+}
+
+// WithBaseURL sets the external URL of the mirror, such as
+// "https://bbs.example.com", which feeds link to. Without it feeds served
+// over HTTP link to the host they were requested from, and ExportFeeds
+// fails.
+func WithBaseURL(u string) Option {
+	return func(m *Mirror) {
+		m.baseURL = strings.TrimSuffix(u, "/")
+	}
+}
+
+// New returns a mirror of board.
+func New(board *bbs.BBS, opts ...Option) *Mirror {
+	m := &Mirror{
+		board:    board,
+		mux:      http.NewServeMux(),
+		md:       goldmark.New(goldmark.WithExtensions(extension.GFM)),
+		policy:   bluemonday.UGCPolicy(),
+		pageSize: bbs.DefaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.mux.HandleFunc("GET /{$}", m.boards)
+	m.mux.HandleFunc("GET /b/{board}", m.posts)
+	m.mux.HandleFunc("GET /b/{board}/{id}", m.post)
+	m.mux.HandleFunc("GET /feed.atom", m.feed(FeedAtom))
+	m.mux.HandleFunc("GET /feed.rss", m.feed(FeedRSS))
+	m.mux.HandleFunc("GET /b/{board}/feed.atom", m.feed(FeedAtom))
+	m.mux.HandleFunc("GET /b/{board}/feed.rss", m.feed(FeedRSS))
+	return m
+}
+
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+// publicBoard returns the summary of a board the mirror may show.
+func (m *Mirror) publicBoard(name string) (bbs.BoardSummary, bool) {
+	for _, s := range m.board.ListBoards() {
+		if s.Name == name {
+			return s, !s.Private
+		}
+	}
+	return bbs.BoardSummary{}, false
+}
+
+// markdown renders Markdown source to sanitized HTML.
+func (m *Mirror) markdown(src string) template.HTML {
+	var buf bytes.Buffer
+	if err := m.md.Convert([]byte(src), &buf); err != nil {
+		return template.HTML(template.HTMLEscapeString(src))
+	}
+	return template.HTML(m.policy.SanitizeBytes(buf.Bytes()))
+}
+
+// render executes page into a buffer and serves it.
+func render(w http.ResponseWriter, r *http.Request, page *template.Template, data any) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, data); err != nil {
+		log.Printf("web: render %s: %v", r.URL.Path, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	serve(w, r, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// serve writes body with an ETag, so conditional requests are answered with
+// 304 Not Modified.
+func serve(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	sum := sha256.Sum256(body)
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	h.Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	h.Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+}
+
+type boardsData struct {
+	Title  string
+	Feed   string // Atom feed advertised by the page
+	Boards []bbs.BoardSummary
+}
+
+func (m *Mirror) boards(w http.ResponseWriter, r *http.Request) {
+	var public []bbs.BoardSummary
+	for _, s := range m.board.ListBoards() {
+		if !s.Private {
+			public = append(public, s)
+		}
+	}
+	render(w, r, boardsPage, boardsData{Title: "Boards", Feed: feedPath("", FeedAtom), Boards: public})
+}
+
+type postsData struct {
+	Title string
+	Feed  string
+	Board bbs.BoardSummary
+	Sort  bbs.PostSort
+	Sorts []bbs.PostSort
+	Page  bbs.PostPage
+	First bool // no cursor was given
+}
+
+// NextURL links the page after this one.
+func (d postsData) NextURL() string {
+	return postsURL(d.Board.Name, d.Sort, d.Page.Next)
+}
+
+// SortURL links the first page in another order.
+func (d postsData) SortURL(s bbs.PostSort) string {
+	return postsURL(d.Board.Name, s, "")
+}
+
+func postsURL(board string, sort bbs.PostSort, after string) string {
+	q := url.Values{}
+	if sort != bbs.SortNewest {
+		q.Set("sort", sort.String())
+	}
+	if after != "" {
+		q.Set("after", after)
+	}
+	u := "/b/" + url.PathEscape(board)
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u
+}
+
+// parseSort reads a sort from its String form. The mirror lists the newest
+// posts first unless told otherwise.
+func parseSort(s string) (bbs.PostSort, bool) {
+	if s == "" {
+		return bbs.SortNewest, true
+	}
+	for _, sort := range bbs.PostSorts {
+		if sort.String() == s {
+			return sort, true
+		}
+	}
+	return "", false
+}
+
+func (m *Mirror) posts(w http.ResponseWriter, r *http.Request) {
+	board, ok := m.publicBoard(r.PathValue("board"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	sort, ok := parseSort(r.URL.Query().Get("sort"))
+	if !ok {
+		http.Error(w, "unknown sort", http.StatusBadRequest)
+		return
+	}
+	after := r.URL.Query().Get("after")
+	page, err := m.board.ListPostPage(board.Name, bbs.PageOptions{
+		ListPostsOptions: bbs.ListPostsOptions{Sort: sort},
+		After:            after,
+		Limit:            m.pageSize,
+	})
+	if errors.Is(err, bbs.ErrBadCursor) {
+		http.Error(w, "bad page cursor", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	render(w, r, postsPage, postsData{
+		Title: board.Name,
+		Feed:  feedPath(board.Name, FeedAtom),
+		Board: board,
+		Sort:  sort,
+		Sorts: bbs.PostSorts,
+		Page:  page,
+		First: after == "",
+	})
+}
+
+type postData struct {
+	Title    string
+	Feed     string
+	Board    bbs.BoardSummary
+	Post     bbs.Post
+	Content  template.HTML
+	Comments []commentData
+}
+
+type commentData struct {
+	bbs.Comment
+	Content template.HTML
+	Reply   bool
+}
+
+func (m *Mirror) post(w http.ResponseWriter, r *http.Request) {
+	board, ok := m.publicBoard(r.PathValue("board"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	p, err := m.board.GetPost(board.Name, id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	data := postData{Title: p.Title, Feed: feedPath(board.Name, FeedAtom), Board: board, Post: p, Content: m.markdown(p.Signed())}
+	for _, c := range p.Comments {
+		data.Comments = append(data.Comments, commentData{Comment: c, Content: m.markdown(c.Signed()), Reply: c.ParentID > 0})
+	}
+	render(w, r, postPage, data)
+}
@@ -0,0 +1,121 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+func newMirror(t *testing.T) (*bbs.BBS, *httptest.Server) {
+	t.Helper()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := bbs.NewWithBoards(func() time.Time { return now }, []string{"general", "staff"}, nil, nil,
+		bbs.WithRoles(map[string]bbs.Role{"mod": bbs.RoleModerator}))
+	if err := b.SetBoardPrivate("staff", true, "mod"); err != nil {
+		t.Fatalf("SetBoardPrivate: %v", err)
+	}
+	srv := httptest.NewServer(New(b, WithPageSize(2)))
+	t.Cleanup(srv.Close)
+	return b, srv
+}
+
+func get(t *testing.T, url string, header ...string) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body)
+}
+
+func TestMirrorListsPublicBoardsAndPages(t *testing.T) {
+	b, srv := newMirror(t)
+	for i := 1; i <= 3; i++ {
+		_, _ = b.AddPost("general", "alice", fmt.Sprintf("post %d", i), "")
+	}
+	_, _ = b.AddPost("staff", "mod", "secret plans", "")
+
+	resp, body := get(t, srv.URL+"/")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `href="/b/general"`) || strings.Contains(body, "staff") {
+		t.Fatalf("board list: %d\n%s", resp.StatusCode, body)
+	}
+
+	// Newest first, two per page.
+	_, body = get(t, srv.URL+"/b/general")
+	if !strings.Contains(body, "post 3") || !strings.Contains(body, "post 2") || strings.Contains(body, "post 1") {
+		t.Fatalf("first page:\n%s", body)
+	}
+	start := strings.Index(body, `href="/b/general?after=`)
+	if start < 0 {
+		t.Fatalf("expected a next page link:\n%s", body)
+	}
+	next := body[start+len(`href="`):]
+	next = strings.ReplaceAll(next[:strings.Index(next, `"`)], "&amp;", "&")
+	_, body = get(t, srv.URL+next)
+	if !strings.Contains(body, "post 1") || strings.Contains(body, "post 3") {
+		t.Fatalf("second page:\n%s", body)
+	}
+
+	for _, path := range []string{"/b/staff", "/b/staff/1", "/b/nowhere", "/b/general/99", "/b/general/x"} {
+		if resp, _ := get(t, srv.URL+path); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, resp.StatusCode)
+		}
+	}
+	if resp, _ := get(t, srv.URL+"/b/general?after=garbage"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for a bad cursor, got %d", resp.StatusCode)
+	}
+}
+
+func TestMirrorRendersSanitizedMarkdown(t *testing.T) {
+	b, srv := newMirror(t)
+	post, _ := b.AddPost("general", "alice", "<b>title</b>", "# Hello\n\n**bold** <script>alert(1)</script> [x](javascript:alert(1))")
+	_, _ = b.AddComment("general", post.ID, "bob", "a *reply*", 0)
+
+	resp, body := get(t, srv.URL+"/b/general/1")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("post page: %d", resp.StatusCode)
+	}
+	for _, want := range []string{"<h1>Hello</h1>", "<strong>bold</strong>", "&lt;b&gt;title&lt;/b&gt;", "a <em>reply</em>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in\n%s", want, body)
+		}
+	}
+	for _, bad := range []string{"<script>", "javascript:"} {
+		if strings.Contains(body, bad) {
+			t.Errorf("unsanitized %q in\n%s", bad, body)
+		}
+	}
+}
+
+func TestMirrorCachingHeaders(t *testing.T) {
+	b, srv := newMirror(t)
+	post, _ := b.AddPost("general", "alice", "hello", "body")
+
+	resp, _ := get(t, srv.URL+"/b/general/1")
+	etag := resp.Header.Get("ETag")
+	if etag == "" || !strings.Contains(resp.Header.Get("Cache-Control"), "max-age=") {
+		t.Fatalf("missing caching headers: %v", resp.Header)
+	}
+	if resp, _ := get(t, srv.URL+"/b/general/1", "If-None-Match", etag); resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", resp.StatusCode)
+	}
+
+	// A new comment changes the page and its ETag.
+	_, _ = b.AddComment("general", post.ID, "bob", "hi", 0)
+	resp, body := get(t, srv.URL+"/b/general/1", "If-None-Match", etag)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag || !strings.Contains(body, "1 comments") {
+		t.Fatalf("expected a fresh page, got %d %v", resp.StatusCode, resp.Header)
+	}
+}
@@ -0,0 +1,615 @@
+// Package webhook delivers board events to HTTP endpoints that admins
+// configure. Each delivery is a signed JSON POST. Deliveries are saved to a
+// queue on disk before they are sent, and failed ones stay there and are
+// retried with exponential backoff, across restarts, until they succeed or
+// run out of attempts. Every hook is delivered to on its own, so a slow or
+// dead endpoint only holds up its own deliveries.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+// Headers sent with every delivery. The signature is the hex HMAC-SHA256
+// of the body under the hook's secret, as "sha256=<hex>".
+const (
+	HeaderEvent     = "X-BBS-Event"
+	HeaderDelivery  = "X-BBS-Delivery"
+	HeaderSignature = "X-BBS-Signature-256"
+)
+
+const (
+	// logSize is how many delivery attempts the log keeps.
+	logSize = 500
+	// maxResponse caps how much of a response body is read for the log.
+	maxResponse = 512
+)
+
+// DefaultEvents are the events a hook without an event list receives.
+var DefaultEvents = []bbs.EventKind{bbs.EventPost, bbs.EventComment, bbs.EventEdit, bbs.EventDelete}
+
+// Hook sends events to URL.
+type Hook struct {
+	ID     string          `json:"id"` // names the hook in the delivery log
+	URL    string          `json:"url"`
+	Secret string          `json:"secret"`
+	Board  string          `json:"board,omitempty"`  // empty for every board
+	Events []bbs.EventKind `json:"events,omitempty"` // empty for DefaultEvents
+}
+
+// wants reports whether the hook receives e.
+func (h Hook) wants(e bbs.Event) bool {
+	if h.Board != "" && h.Board != e.Board {
+		return false
+	}
+	events := h.Events
+	if len(events) == 0 {
+		events = DefaultEvents
+	}
+	return slices.Contains(events, e.Kind)
+}
+
+// Config is the webhook configuration file.
+type Config struct {
+	Hooks []Hook `json:"hooks"`
+}
+
+// LoadConfig reads and checks a webhook configuration file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parse webhooks: %w", err)
+	}
+	seen := make(map[string]bool)
+	for _, h := range cfg.Hooks {
+		switch u, err := url.Parse(h.URL); {
+		case h.ID == "" || seen[h.ID]:
+			return Config{}, fmt.Errorf("webhook %q: ids must be set and unique", h.ID)
+		case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+			return Config{}, fmt.Errorf("webhook %q: url must be http or https", h.ID)
+		case h.Secret == "":
+			return Config{}, fmt.Errorf("webhook %q: secret is required", h.ID)
+		}
+		seen[h.ID] = true
+	}
+	return cfg, nil
+}
+
+// Sign returns the signature header value of body under secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body under secret.
+// Receivers written in Go may use it.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Payload is the JSON body of a delivery. Retries send the same body.
+type Payload struct {
+	Delivery string          `json:"delivery"`
+	Event    bbs.EventKind   `json:"event"`
+	Board    string          `json:"board"`
+	Actor    string          `json:"actor"`
+	At       time.Time       `json:"at"`
+	Post     PostPayload     `json:"post"`
+	Comment  *CommentPayload `json:"comment,omitempty"`
+}
+
+// PostPayload is the post an event is about; for deletes, as it was.
+type PostPayload struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	EditedAt  time.Time `json:"edited_at,omitzero"`
+}
+
+// CommentPayload is the comment of a comment event.
+type CommentPayload struct {
+	ID        int       `json:"id"`
+	ParentID  int       `json:"parent_id,omitempty"`
+	Author    string    `json:"author"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func payloadOf(id string, e bbs.Event) Payload {
+	p := Payload{
+		Delivery: id,
+		Event:    e.Kind,
+		Board:    e.Board,
+		Actor:    e.Actor,
+		At:       e.At,
+		Post: PostPayload{
+			ID:        e.Post.ID,
+			Title:     e.Post.Title,
+			Author:    e.Post.Author,
+			Content:   e.Post.Content,
+			Tags:      e.Post.Tags,
+			CreatedAt: e.Post.CreatedAt,
+			EditedAt:  e.Post.EditedAt,
+		},
+	}
+	if e.Kind == bbs.EventComment {
+		c := e.Comment
+		p.Comment = &CommentPayload{ID: c.ID, ParentID: c.ParentID, Author: c.Author, Content: c.Content, CreatedAt: c.CreatedAt}
+	}
+	return p
+}
+
+// delivery is a queued request.
+type delivery struct {
+	ID        string        `json:"id"`
+	Hook      string        `json:"hook"`
+	Event     bbs.EventKind `json:"event"`
+	Board     string        `json:"board"`
+	PostID    int           `json:"post_id"`
+	Body      string        `json:"body"` // exactly as signed
+	Attempts  int           `json:"attempts"`
+	NextAt    time.Time     `json:"next_at"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Result is the outcome of a delivery attempt.
+type Result string
+
+const (
+	ResultOK     Result = "ok"     // the endpoint answered 2xx
+	ResultRetry  Result = "retry"  // it failed and will be tried again
+	ResultFailed Result = "failed" // it failed for the last time
+	ResultGone   Result = "gone"   // its hook is no longer configured
+)
+
+// Attempt is one delivery attempt in the log.
+type Attempt struct {
+	Delivery string        `json:"delivery"`
+	Hook     string        `json:"hook"`
+	Event    bbs.EventKind `json:"event"`
+	Board    string        `json:"board"`
+	PostID   int           `json:"post_id"`
+	Attempt  int           `json:"attempt"`
+	At       time.Time     `json:"at"`
+	Status   int           `json:"status,omitempty"` // HTTP status; 0 if there was no response
+	Error    string        `json:"error,omitempty"`
+	Result   Result        `json:"result"`
+	NextAt   time.Time     `json:"next_at,omitzero"` // set for ResultRetry
+}
+
+// Backoff spaces out retries: the nth retry waits Base·2ⁿ⁻¹, at most Max,
+// and a delivery is given up after Attempts tries.
+type Backoff struct {
+	Base     time.Duration
+	Max      time.Duration
+	Attempts int
+}
+
+// DefaultBackoff retries for about twelve hours.
+var DefaultBackoff = Backoff{Base: 30 * time.Second, Max: 4 * time.Hour, Attempts: 12}
+
+func (b Backoff) delay(attempts int) time.Duration {
+	d := b.Base
+	for i := 1; i < attempts && d < b.Max; i++ {
+		d *= 2
+	}
+	return min(d, b.Max)
+}
+
+// state is what the dispatcher keeps on disk.
+type state struct {
+	Pending []delivery `json:"pending"`
+	Log     []Attempt  `json:"log"`
+}
+
+// Dispatcher queues events for the configured hooks and delivers them.
+type Dispatcher struct {
+	hooks   map[string]Hook
+	order   []Hook
+	path    string // empty keeps the queue in memory only
+	key     []byte // encrypts the queue file when set
+	client  *http.Client
+	now     func() time.Time
+	backoff Backoff
+
+	mu      sync.Mutex
+	state   state
+	dirty   bool // state changed since it was saved
+	started bool
+
+	// Enqueue leaves deliveries in handoff for the saver. It has its own
+	// lock because mu is held while the queue is written.
+	handoffMu sync.Mutex
+	handoff   []delivery
+	arrived   chan struct{}            // handoff is not empty
+	wakes     map[string]chan struct{} // per hook: new deliveries for its worker
+	stop      chan struct{}
+	saver     sync.WaitGroup
+	workers   sync.WaitGroup
+}
+
+// Option configures optional Dispatcher behaviour.
+type Option func(*Dispatcher)
+
+// WithDir keeps the queue and the delivery log in dir, so they survive
+// restarts.
+func WithDir(dir string) Option {
+	return func(d *Dispatcher) {
+		d.path = filepath.Join(dir, "queue.json")
+	}
+}
+
+// WithEncryptionKey encrypts the queue file with key, as bbs.PostFile does
+// with posts.
+func WithEncryptionKey(key []byte) Option {
+	return func(d *Dispatcher) {
+		d.key = key
+	}
+}
+
+// WithClient sends deliveries with c instead of a client with a 10 second
+// timeout.
+func WithClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+// WithBackoff replaces DefaultBackoff.
+func WithBackoff(b Backoff) Option {
+	return func(d *Dispatcher) {
+		d.backoff = b
+	}
+}
+
+// WithClock replaces time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(d *Dispatcher) {
+		d.now = now
+	}
+}
+
+// New returns a dispatcher for hooks, with the queue left by a previous run
+// if WithDir is given. Events are saved to the queue from the start; call
+// Start to begin delivering them.
+func New(hooks []Hook, opts ...Option) (*Dispatcher, error) {
+	d := &Dispatcher{
+		hooks:   make(map[string]Hook, len(hooks)),
+		order:   hooks,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+		backoff: DefaultBackoff,
+		arrived: make(chan struct{}, 1),
+		wakes:   make(map[string]chan struct{}, len(hooks)),
+		stop:    make(chan struct{}),
+	}
+	for _, h := range hooks {
+		d.hooks[h.ID] = h
+		d.wakes[h.ID] = make(chan struct{}, 1)
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	d.saver.Add(1)
+	go d.runSaver()
+	return d, nil
+}
+
+func (d *Dispatcher) load() error {
+	if d.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(d.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read webhook queue: %w", err)
+	}
+	data = bbs.OpenSealed(d.key, data)
+	if err := json.Unmarshal(data, &d.state); err != nil {
+		return fmt.Errorf("parse webhook queue: %w", err)
+	}
+	return nil
+}
+
+// save writes the state if it changed. Callers must hold d.mu.
+func (d *Dispatcher) save() error {
+	if d.path == "" || !d.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(d.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Pending deliveries carry post bodies, so they are sealed like posts.
+	if err := bbs.WriteSealed(d.path, d.key, data); err != nil {
+		return err
+	}
+	d.dirty = false
+	return nil
+}
+
+// Watch queues a delivery to every interested hook for each event on b.
+func (d *Dispatcher) Watch(b *bbs.BBS) {
+	b.Listen(d.Enqueue)
+}
+
+// Enqueue queues deliveries of e. It runs while board locks are held, so it
+// never waits: it leaves them for the saver, which writes them to disk
+// before any is sent. Events after Close are dropped.
+func (d *Dispatcher) Enqueue(e bbs.Event) {
+	var batch []delivery
+	for _, h := range d.order {
+		if !h.wants(e) {
+			continue
+		}
+		id := newID()
+		body, err := json.Marshal(payloadOf(id, e))
+		if err != nil {
+			continue
+		}
+		batch = append(batch, delivery{
+			ID: id, Hook: h.ID, Event: e.Kind, Board: e.Board, PostID: e.PostID,
+			Body: string(body), NextAt: d.now(), CreatedAt: d.now(),
+		})
+	}
+	if len(batch) == 0 {
+		return
+	}
+	d.handoffMu.Lock()
+	d.handoff = append(d.handoff, batch...)
+	d.handoffMu.Unlock()
+	select {
+	case d.arrived <- struct{}{}:
+	default:
+	}
+}
+
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// runSaver adds handed-off deliveries to the queue until Close, saving the
+// queue before the hooks' workers see them.
+func (d *Dispatcher) runSaver() {
+	defer d.saver.Done()
+	for {
+		select {
+		case <-d.arrived:
+			d.accept()
+		case <-d.stop:
+			// Keep what was handed off before Close.
+			d.accept()
+			return
+		}
+	}
+}
+
+// accept queues everything handed off so far, saves the queue in one write
+// and wakes the workers of the hooks concerned.
+func (d *Dispatcher) accept() {
+	d.handoffMu.Lock()
+	batch := d.handoff
+	d.handoff = nil
+	d.handoffMu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	d.state.Pending = append(d.state.Pending, batch...)
+	d.dirty = true
+	_ = d.save()
+	d.mu.Unlock()
+	for _, dl := range batch {
+		select {
+		case d.wakes[dl.Hook] <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start delivers queued events in the background until Close, with one
+// worker per hook. Deliveries left for hooks that are no longer configured
+// are dropped.
+func (d *Dispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started {
+		return
+	}
+	d.started = true
+	d.state.Pending = slices.DeleteFunc(d.state.Pending, func(dl delivery) bool {
+		if _, ok := d.hooks[dl.Hook]; ok {
+			return false
+		}
+		d.record(Attempt{
+			Delivery: dl.ID, Hook: dl.Hook, Event: dl.Event, Board: dl.Board, PostID: dl.PostID,
+			Attempt: dl.Attempts + 1, At: d.now(), Result: ResultGone,
+		})
+		return true
+	})
+	for _, h := range d.order {
+		d.workers.Add(1)
+		go d.runWorker(h)
+	}
+}
+
+// Close stops delivering and saves the queue. Deliveries not yet sent are
+// sent after the next Start.
+func (d *Dispatcher) Close() error {
+	close(d.stop)
+	d.workers.Wait()
+	d.saver.Wait()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.save()
+}
+
+// runWorker delivers the queue of one hook, one delivery at a time.
+func (d *Dispatcher) runWorker(h Hook) {
+	defer d.workers.Done()
+	for {
+		d.mu.Lock()
+		_ = d.save()
+		next, wait, ok := d.due(h.ID)
+		d.mu.Unlock()
+		if ok && wait <= 0 {
+			d.attempt(h, next)
+			continue
+		}
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if ok {
+			timer = time.NewTimer(wait)
+			fire = timer.C
+		}
+		select {
+		case <-d.stop:
+			return
+		case <-d.wakes[h.ID]:
+		case <-fire:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// due returns the hook's queued delivery that is due first and how long
+// until it is. Callers must hold d.mu.
+func (d *Dispatcher) due(hook string) (delivery, time.Duration, bool) {
+	var first delivery
+	found := false
+	for _, p := range d.state.Pending {
+		if p.Hook == hook && (!found || p.NextAt.Before(first.NextAt)) {
+			first, found = p, true
+		}
+	}
+	if !found {
+		return delivery{}, 0, false
+	}
+	return first, first.NextAt.Sub(d.now()), true
+}
+
+// attempt sends one delivery to h and records the outcome.
+func (d *Dispatcher) attempt(h Hook, dl delivery) {
+	entry := Attempt{
+		Delivery: dl.ID, Hook: dl.Hook, Event: dl.Event, Board: dl.Board, PostID: dl.PostID,
+		Attempt: dl.Attempts + 1,
+	}
+	entry.Status, entry.Error = d.send(h, dl)
+	entry.At = d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i := slices.IndexFunc(d.state.Pending, func(p delivery) bool { return p.ID == dl.ID })
+	if i < 0 {
+		return
+	}
+	switch {
+	case entry.Error == "":
+		entry.Result = ResultOK
+	case entry.Attempt >= d.backoff.Attempts:
+		entry.Result = ResultFailed
+	default:
+		entry.Result = ResultRetry
+		entry.NextAt = entry.At.Add(d.backoff.delay(entry.Attempt))
+		d.state.Pending[i].Attempts = entry.Attempt
+		d.state.Pending[i].NextAt = entry.NextAt
+	}
+	if entry.Result != ResultRetry {
+		d.state.Pending = slices.Delete(d.state.Pending, i, i+1)
+	}
+	d.record(entry)
+}
+
+// record adds entry to the log. Callers must hold d.mu.
+func (d *Dispatcher) record(entry Attempt) {
+	d.state.Log = append(d.state.Log, entry)
+	if n := len(d.state.Log); n > logSize {
+		d.state.Log = slices.Clone(d.state.Log[n-logSize:])
+	}
+	d.dirty = true
+}
+
+// send posts a delivery and returns the response status and, unless the
+// endpoint answered 2xx, what went wrong.
+func (d *Dispatcher) send(h Hook, dl delivery) (int, string) {
+	req, err := http.NewRequest(http.MethodPost, h.URL, strings.NewReader(dl.Body))
+	if err != nil {
+		return 0, err.Error()
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bbs-webhook")
+	req.Header.Set(HeaderEvent, string(dl.Event))
+	req.Header.Set(HeaderDelivery, dl.ID)
+	req.Header.Set(HeaderSignature, Sign(h.Secret, []byte(dl.Body)))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		msg := resp.Status
+		if s := strings.Join(strings.Fields(string(snippet)), " "); s != "" {
+			msg += ": " + s
+		}
+		return resp.StatusCode, msg
+	}
+	return resp.StatusCode, ""
+}
+
+// Log returns the most recent delivery attempts, newest first.
+func (d *Dispatcher) Log() []Attempt {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := slices.Clone(d.state.Log)
+	slices.Reverse(out)
+	return out
+}
+
+// Pending returns how many deliveries are waiting to be sent.
+func (d *Dispatcher) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.state.Pending)
+}
+
+// Hooks returns the configured hooks.
+func (d *Dispatcher) Hooks() []Hook {
+	return slices.Clone(d.order)
+}
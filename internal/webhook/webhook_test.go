@@ -0,0 +1,412 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ag/internal/bbs"
+)
+
+// receiver records the deliveries it is sent, failing the first fail of
+// them with a 503.
+type receiver struct {
+	t      *testing.T
+	secret string
+
+	mu     sync.Mutex
+	fail   int
+	got    []Payload
+	ids    []string
+	bodies []string
+}
+
+func newReceiver(t *testing.T, secret string, fail int) (*receiver, string) {
+	r := &receiver{t: t, secret: secret, fail: fail}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	if !Verify(r.secret, body, req.Header.Get(HeaderSignature)) {
+		r.t.Errorf("bad signature %q", req.Header.Get(HeaderSignature))
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = append(r.ids, req.Header.Get(HeaderDelivery))
+	r.bodies = append(r.bodies, string(body))
+	if r.fail > 0 {
+		r.fail--
+		http.Error(w, "try later", http.StatusServiceUnavailable)
+		return
+	}
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		r.t.Errorf("decode payload: %v", err)
+	}
+	if string(p.Event) != req.Header.Get(HeaderEvent) {
+		r.t.Errorf("event header %q does not match payload %q", req.Header.Get(HeaderEvent), p.Event)
+	}
+	r.got = append(r.got, p)
+}
+
+func (r *receiver) payloads() []Payload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Payload(nil), r.got...)
+}
+
+// waitFor polls cond until it holds or a couple of seconds pass.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newBBS() *bbs.BBS {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return bbs.NewWithBoards(func() time.Time { return now }, []string{"general", "news"}, nil, nil)
+}
+
+var quick = Backoff{Base: time.Millisecond, Max: 4 * time.Millisecond, Attempts: 5}
+
+func TestDeliversSignedEvents(t *testing.T) {
+	r, url := newReceiver(t, "s3cret", 0)
+	d, err := New([]Hook{{ID: "all", URL: url, Secret: "s3cret"}}, WithBackoff(quick))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b := newBBS()
+	d.Watch(b)
+	d.Start()
+	defer d.Close()
+
+	post, _ := b.AddPost("general", "alice", "hello", "first")
+	_, _ = b.AddComment("general", post.ID, "bob", "welcome", 0)
+	_, _ = b.EditPost("general", post.ID, "alice", "hello again", "first")
+	_ = b.DeletePost("general", post.ID, "alice")
+
+	waitFor(t, "four deliveries", func() bool { return len(r.payloads()) == 4 && len(d.Log()) == 4 })
+	got := r.payloads()
+	want := []bbs.EventKind{bbs.EventPost, bbs.EventComment, bbs.EventEdit, bbs.EventDelete}
+	for i, p := range got {
+		if p.Event != want[i] || p.Board != "general" || p.Post.ID != post.ID {
+			t.Fatalf("delivery %d: %+v", i, p)
+		}
+	}
+	if got[1].Comment == nil || got[1].Comment.Content != "welcome" || got[1].Actor != "bob" {
+		t.Fatalf("comment delivery: %+v", got[1])
+	}
+	if got[2].Post.Title != "hello again" || got[2].Post.EditedAt.IsZero() {
+		t.Fatalf("edit delivery: %+v", got[2])
+	}
+	if log := d.Log(); len(log) != 4 || log[0].Event != bbs.EventDelete || log[0].Result != ResultOK || log[0].Status != http.StatusOK {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+}
+
+func TestFiltersByBoardAndEvent(t *testing.T) {
+	r, url := newReceiver(t, "k", 0)
+	d, err := New([]Hook{{ID: "news-posts", URL: url, Secret: "k", Board: "news", Events: []bbs.EventKind{bbs.EventPost}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b := newBBS()
+	d.Watch(b)
+	d.Start()
+	defer d.Close()
+
+	_, _ = b.AddPost("general", "alice", "elsewhere", "")
+	post, _ := b.AddPost("news", "alice", "headline", "")
+	_, _ = b.AddComment("news", post.ID, "bob", "not sent", 0)
+	_, _ = b.AddPost("news", "alice", "second", "")
+
+	waitFor(t, "two deliveries", func() bool { return len(r.payloads()) == 2 })
+	time.Sleep(20 * time.Millisecond)
+	if got := r.payloads(); len(got) != 2 || got[0].Post.Title != "headline" || got[1].Post.Title != "second" {
+		t.Fatalf("unexpected deliveries: %+v", got)
+	}
+}
+
+func TestRetriesWithBackoff(t *testing.T) {
+	r, url := newReceiver(t, "k", 2)
+	d, err := New([]Hook{{ID: "flaky", URL: url, Secret: "k"}}, WithBackoff(quick))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b := newBBS()
+	d.Watch(b)
+	d.Start()
+	defer d.Close()
+
+	_, _ = b.AddPost("general", "alice", "eventually", "")
+	waitFor(t, "a successful delivery", func() bool { return len(r.payloads()) == 1 && d.Pending() == 0 })
+
+	r.mu.Lock()
+	ids := append([]string(nil), r.ids...)
+	r.mu.Unlock()
+	if len(ids) != 3 || ids[0] != ids[1] || ids[1] != ids[2] {
+		t.Fatalf("expected three tries of one delivery, got %v", ids)
+	}
+	log := d.Log()
+	if len(log) != 3 || log[2].Result != ResultRetry || log[2].Status != http.StatusServiceUnavailable || log[2].Error == "" ||
+		log[1].Attempt != 2 || log[0].Result != ResultOK || log[0].Attempt != 3 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	if d.Pending() != 0 {
+		t.Fatalf("expected an empty queue, got %d", d.Pending())
+	}
+}
+
+func TestGivesUpAfterLastAttempt(t *testing.T) {
+	_, url := newReceiver(t, "k", 100)
+	d, err := New([]Hook{{ID: "down", URL: url, Secret: "k"}}, WithBackoff(Backoff{Base: time.Millisecond, Max: time.Millisecond, Attempts: 3}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b := newBBS()
+	d.Watch(b)
+	d.Start()
+	defer d.Close()
+
+	_, _ = b.AddPost("general", "alice", "lost", "")
+	waitFor(t, "the delivery to be dropped", func() bool { return len(d.Log()) == 3 && d.Pending() == 0 })
+	if log := d.Log(); log[0].Result != ResultFailed || log[0].Attempt != 3 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+}
+
+func TestQueueSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	r, url := newReceiver(t, "k", 0)
+	hooks := []Hook{{ID: "later", URL: url, Secret: "k"}}
+
+	// The first dispatcher is never started, as if the process stopped
+	// before it could deliver.
+	first, err := New(hooks, WithDir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b := newBBS()
+	first.Watch(b)
+	_, _ = b.AddPost("general", "alice", "queued", "")
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(r.payloads()) != 0 {
+		t.Fatal("an unstarted dispatcher delivered")
+	}
+
+	second, err := New(hooks, WithDir(dir))
+	if err != nil {
+		t.Fatalf("New after restart: %v", err)
+	}
+	if second.Pending() != 1 {
+		t.Fatalf("expected one queued delivery after restart, got %d", second.Pending())
+	}
+	second.Start()
+	waitFor(t, "the queued delivery", func() bool { return len(r.payloads()) == 1 })
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := r.payloads()[0]; got.Post.Title != "queued" {
+		t.Fatalf("unexpected delivery: %+v", got)
+	}
+
+	third, err := New(hooks, WithDir(dir))
+	if err != nil {
+		t.Fatalf("New after second restart: %v", err)
+	}
+	if third.Pending() != 0 || len(third.Log()) != 1 || third.Log()[0].Result != ResultOK {
+		t.Fatalf("expected the log to persist and the queue to drain: %d %+v", third.Pending(), third.Log())
+	}
+}
+
+func TestQueueIsSavedBeforeClose(t *testing.T) {
+	dir := t.TempDir()
+	hooks := []Hook{{ID: "later", URL: "http://127.0.0.1:1/", Secret: "k"}}
+	d, err := New(hooks, WithDir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer d.Close()
+	b := newBBS()
+	d.Watch(b)
+	_, _ = b.AddPost("general", "alice", "queued", "")
+
+	// Another dispatcher reading the directory sees the delivery, as a
+	// restart after a crash would.
+	waitFor(t, "the delivery on disk", func() bool {
+		again, err := New(hooks, WithDir(dir))
+		if err != nil {
+			return false
+		}
+		defer again.Close()
+		return again.Pending() == 1
+	})
+}
+
+func TestEnqueueDoesNotWaitForTheSaver(t *testing.T) {
+	d, err := New([]Hook{{ID: "all", URL: "http://127.0.0.1:1/", Secret: "k"}}, WithDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer d.Close()
+
+	// Holding mu stalls the saver the way a slow disk would.
+	d.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			d.Enqueue(bbs.Event{Kind: bbs.EventPost, Board: "general", PostID: i + 1})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Enqueue blocked behind the saver")
+	}
+	d.mu.Unlock()
+	waitFor(t, "every delivery to be queued", func() bool { return d.Pending() == 1000 })
+}
+
+func TestQueueIsEncryptedWithKey(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	hooks := []Hook{{ID: "later", URL: "http://127.0.0.1:1/", Secret: "k"}}
+	d, err := New(hooks, WithDir(dir), WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b := newBBS()
+	d.Watch(b)
+	_, _ = b.AddPost("general", "alice", "confidential", "the plan")
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "queue.json"))
+	if err != nil {
+		t.Fatalf("read queue: %v", err)
+	}
+	if strings.Contains(string(data), "the plan") {
+		t.Fatal("the queue file holds the post in plain text")
+	}
+	again, err := New(hooks, WithDir(dir), WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("New after restart: %v", err)
+	}
+	defer again.Close()
+	if again.Pending() != 1 {
+		t.Fatalf("expected the delivery to read back, got %d pending", again.Pending())
+	}
+}
+
+func TestDeadHookDoesNotHoldUpOthers(t *testing.T) {
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hung.Close()
+	r, url := newReceiver(t, "k", 0)
+	d, err := New([]Hook{{ID: "hung", URL: hung.URL, Secret: "k"}, {ID: "live", URL: url, Secret: "k"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b := newBBS()
+	d.Watch(b)
+	d.Start()
+	defer d.Close()
+	defer close(release)
+
+	_, _ = b.AddPost("general", "alice", "one", "")
+	_, _ = b.AddPost("general", "alice", "two", "")
+	waitFor(t, "the live hook's deliveries", func() bool { return len(r.payloads()) == 2 })
+}
+
+func TestRetryAfterRestartSendsSameBody(t *testing.T) {
+	dir := t.TempDir()
+	r, url := newReceiver(t, "k", 1)
+	hooks := []Hook{{ID: "flaky", URL: url, Secret: "k"}}
+
+	first, err := New(hooks, WithDir(dir), WithBackoff(Backoff{Base: time.Hour, Max: time.Hour, Attempts: 3}))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b := newBBS()
+	first.Watch(b)
+	first.Start()
+	_, _ = b.AddPost("general", "alice", "again", "")
+	waitFor(t, "the failed attempt", func() bool { return len(first.Log()) == 1 })
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Bring the retry forward rather than waiting an hour.
+	now := time.Now().Add(2 * time.Hour)
+	second, err := New(hooks, WithDir(dir), WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("New after restart: %v", err)
+	}
+	second.Start()
+	defer second.Close()
+	waitFor(t, "the retry", func() bool { return len(r.payloads()) == 1 })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.bodies) != 2 || r.bodies[0] != r.bodies[1] || r.ids[0] != r.ids[1] {
+		t.Fatalf("expected the retry to resend the same delivery:\n%s\n%s", r.bodies[0], r.bodies[len(r.bodies)-1])
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(body string) string {
+		path := filepath.Join(dir, "webhooks.json")
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cfg, err := LoadConfig(write(`{"hooks":[{"id":"ci","url":"https://ci.example/hook","secret":"x","board":"general","events":["post"]}]}`))
+	if err != nil || len(cfg.Hooks) != 1 || cfg.Hooks[0].Board != "general" {
+		t.Fatalf("LoadConfig: %+v %v", cfg, err)
+	}
+	for _, bad := range []string{
+		`{"hooks":[{"url":"https://a.example","secret":"x"}]}`,
+		`{"hooks":[{"id":"a","url":"https://a.example","secret":"x"},{"id":"a","url":"https://b.example","secret":"x"}]}`,
+		`{"hooks":[{"id":"a","url":"ftp://a.example","secret":"x"}]}`,
+		`{"hooks":[{"id":"a","url":"https://a.example"}]}`,
+		`{"hooks":`,
+	} {
+		if _, err := LoadConfig(write(bad)); err == nil {
+			t.Errorf("expected %s to be refused", bad)
+		}
+	}
+}
+
+func TestBackoffDoubles(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 10 * time.Second}
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 40: 10 * time.Second} {
+		if got := b.delay(attempts); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}